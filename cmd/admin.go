@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+)
+
+const createAdminUsage = `usage: main create-admin --email EMAIL [--first-name NAME] [--last-name NAME]
+
+Makes the customer with EMAIL an admin. When nobody has signed up with it
+yet, an admin account is added for it, which they get the first time they
+sign in with a provider that has verified the email. The names are only
+used for a new account.`
+
+func createAdmin(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("create-admin")
+	email := fs.String("email", "", "")
+	firstName := fs.String("first-name", "", "")
+	lastName := fs.String("last-name", "", "")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *email == "" {
+		return errUsage
+	}
+	address, err := mail.ParseAddress(*email)
+	if err != nil {
+		return fmt.Errorf("invalid email %q", *email)
+	}
+
+	customer, err := a.customerRepo.GetByEmail(ctx, address.Address)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		customer = &models.Customer{
+			FirstName: *firstName,
+			LastName:  *lastName,
+			Email:     address.Address,
+			Role:      models.RoleAdmin,
+		}
+		if err := a.customerRepo.Create(ctx, customer); err != nil {
+			return err
+		}
+		a.logger.Info().Uint("customerID", customer.ID).Str("email", customer.Email).Msg("Admin added")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Through the customer service, so the change is in the auth audit log
+	_, err = a.customerService.ChangeRole(ctx, 0, customer.ID, &services.CustomerRoleRequest{Role: models.RoleAdmin}, services.ClientInfo{})
+	if errors.Is(err, models.ErrRoleUnchanged) {
+		a.logger.Info().Uint("customerID", customer.ID).Msg("Customer is already an admin")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	a.logger.Info().Uint("customerID", customer.ID).Str("previousRole", customer.Role).Msg("Customer made an admin")
+	return nil
+}
+
+const reindexSearchUsage = `usage: main reindex-search
+
+Rebuilds the indexes of the tables the staff searches read (customers,
+orders and the audit logs) and refreshes their planner statistics. The
+tables can still be read and written while it runs.`
+
+// searchTables are the tables behind the customer directory, the order
+// search and export, and the audit log searches.
+var searchTables = []string{"customers", "orders", "order_items", "auth_events", "audit_events"}
+
+func reindexSearch(ctx context.Context, a *app, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	for _, table := range searchTables {
+		start := time.Now()
+		// REINDEX CONCURRENTLY cannot run in a transaction; raw statements
+		// are not wrapped in one
+		if err := a.db.WithContext(ctx).Exec("REINDEX TABLE CONCURRENTLY " + table).Error; err != nil {
+			return fmt.Errorf("reindexing %s: %w", table, err)
+		}
+		if err := a.db.WithContext(ctx).Exec("ANALYZE " + table).Error; err != nil {
+			return fmt.Errorf("analyzing %s: %w", table, err)
+		}
+		a.logger.Info().Str("table", table).Dur("took", time.Since(start)).Msg("Table reindexed")
+	}
+	return nil
+}
+
+const resendNotificationUsage = `usage: main resend-notification --order ORDER [--kind confirmation|status]
+
+Sends an order's confirmation, with its invoice, or an update with its
+current status to the customer again. ORDER is the order's ID or reference;
+the kind is confirmation unless given.`
+
+func resendNotification(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("resend-notification")
+	orderArg := fs.String("order", "", "")
+	kind := fs.String("kind", "confirmation", "")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *orderArg == "" {
+		return errUsage
+	}
+	send, ok := map[string]func(order *models.Order) error{
+		"confirmation": a.notificationService.SendOrderConfirmation,
+		"status":       a.notificationService.SendStatusUpdate,
+	}[*kind]
+	if !ok {
+		return errUsage
+	}
+
+	// References have letters in them, so a number is an ID
+	var order *models.Order
+	var err error
+	if id, parseErr := strconv.ParseUint(*orderArg, 10, 0); parseErr == nil {
+		order, err = a.orderRepo.GetByID(ctx, uint(id))
+	} else {
+		order, err = a.orderRepo.GetByReference(ctx, models.NormalizeOrderReference(*orderArg))
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("order %s: %w", *orderArg, models.ErrOrderNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := send(order); err != nil {
+		return err
+	}
+	a.logger.Info().Str("reference", order.Reference).Str("kind", *kind).Msg("Notification sent again")
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/audit"
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/pkg/database"
+	"github.com/Mutonya/Savanah/pkg/geoip"
+	"github.com/Mutonya/Savanah/pkg/mpesa"
+	"github.com/Mutonya/Savanah/pkg/payments"
+)
+
+// app is what the server and the other commands share: the configuration,
+// the database and the repositories and services built on it. Sign-in needs
+// the OAuth providers, which only the server sets up, so the auth service is
+// not here.
+type app struct {
+	cfg    *config.Config
+	logger zerolog.Logger
+	db     *gorm.DB
+
+	customerRepo repositories.CustomerRepository
+	identityRepo repositories.IdentityRepository
+	sessionRepo  repositories.SessionRepository
+	orderRepo    repositories.OrderRepository
+
+	apiKeyService       services.APIKeyService
+	categoryService     services.CategoryService
+	invoiceService      services.InvoiceService
+	notificationService services.NotificationService
+	authAuditService    services.AuthAuditService
+	auditService        services.AuditService
+	wishlistService     services.WishlistService
+	productService      services.ProductService
+	promotionService    services.PromotionService
+	taxService          services.TaxService
+	deliveryService     services.DeliveryService
+	addressService      services.AddressService
+	paymentService      services.PaymentService
+	orderService        services.OrderService
+	cartService         services.CartService
+	returnService       services.ReturnService
+	reviewService       services.ReviewService
+	otpService          services.OTPService
+	profileService      services.ProfileService
+	customerService     services.CustomerService
+}
+
+// newApp connects to the database and wires up the repositories and
+// services. Nothing is read from the database yet, so it works on an empty
+// one that is about to be migrated.
+func newApp(cfg *config.Config, logger zerolog.Logger) *app {
+	// Initialize database
+	db, err := database.NewPostgresDB(&database.DBConfig{
+		Host:     cfg.DBHost,
+		Port:     cfg.DBPort,
+		User:     cfg.DBUser,
+		Password: cfg.DBPassword,
+		DBName:   cfg.DBName,
+		SSLMode:  cfg.SSLMode,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	if err := db.Use(audit.Plugin{}); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register the audit trail")
+	}
+
+	// Initialize repositories
+	customerRepo := repositories.NewCustomerRepository(db)
+	identityRepo := repositories.NewIdentityRepository(db)
+	productRepo := repositories.NewProductRepository(db)
+	categoryRepo := repositories.NewCategoryRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+	cartRepo := repositories.NewCartRepository(db)
+	paymentRepo := repositories.NewPaymentRepository(db)
+	couponRepo := repositories.NewCouponRepository(db)
+	taxRateRepo := repositories.NewTaxRateRepository(db)
+	addressRepo := repositories.NewAddressRepository(db)
+	deliveryRepo := repositories.NewDeliveryRepository(db)
+	returnRepo := repositories.NewReturnRepository(db)
+	invoiceRepo := repositories.NewInvoiceRepository(db)
+	wishlistRepo := repositories.NewWishlistRepository(db)
+	reviewRepo := repositories.NewReviewRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	otpRepo := repositories.NewOTPRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	authEventRepo := repositories.NewAuthEventRepository(db)
+	auditEventRepo := repositories.NewAuditEventRepository(db)
+
+	// GeoIP lookups for the auth audit log
+	var geoDB *geoip.DB
+	if cfg.GeoIPDatabase != "" {
+		geoDB, err = geoip.Open(cfg.GeoIPDatabase)
+		if err != nil {
+			logger.Fatal().Err(err).Str("path", cfg.GeoIPDatabase).Msg("Failed to load GeoIP database")
+		}
+	}
+
+	// Initialize M-Pesa client
+	mpesaClient := mpesa.NewClient(mpesa.Config{
+		BaseURL:        cfg.MpesaBaseURL,
+		ConsumerKey:    cfg.MpesaConsumerKey,
+		ConsumerSecret: cfg.MpesaConsumerSecret,
+		ShortCode:      cfg.MpesaShortCode,
+		PassKey:        cfg.MpesaPassKey,
+		CallbackURL:    withCallbackToken(cfg.MpesaCallbackURL, cfg.MpesaCallbackToken),
+
+		InitiatorName:      cfg.MpesaInitiatorName,
+		SecurityCredential: cfg.MpesaSecurityCredential,
+		ResultURL:          withCallbackToken(cfg.MpesaResultURL, cfg.MpesaCallbackToken),
+	}, nil)
+
+	// Initialize services
+	a := &app{
+		cfg:          cfg,
+		logger:       logger,
+		db:           db,
+		customerRepo: customerRepo,
+		identityRepo: identityRepo,
+		sessionRepo:  repositories.NewSessionRepository(db),
+		orderRepo:    orderRepo,
+	}
+	a.apiKeyService = services.NewAPIKeyService(apiKeyRepo)
+	a.categoryService = services.NewCategoryService(categoryRepo)
+	a.invoiceService = services.NewInvoiceService(invoiceRepo, orderRepo, cfg)
+	a.notificationService = services.NewNotificationService(cfg, a.invoiceService, notificationRepo)
+	a.authAuditService = services.NewAuthAuditService(authEventRepo, customerRepo, a.notificationService, geoDB, cfg)
+	a.auditService = services.NewAuditService(auditEventRepo)
+	a.wishlistService = services.NewWishlistService(wishlistRepo, productRepo, a.notificationService)
+	a.productService = services.NewProductService(productRepo, a.wishlistService)
+	a.promotionService = services.NewPromotionService(couponRepo, categoryRepo, productRepo)
+	a.taxService = services.NewTaxService(taxRateRepo, categoryRepo)
+	a.deliveryService = services.NewDeliveryService(deliveryRepo)
+	a.addressService = services.NewAddressService(addressRepo, a.deliveryService)
+	a.paymentService = services.NewPaymentService(paymentRepo, orderRepo, a.notificationService, cfg,
+		payments.NewMpesaProvider(mpesaClient),
+	)
+	a.orderService = services.NewOrderService(orderRepo, productRepo, customerRepo, a.notificationService,
+		a.promotionService, a.taxService, addressRepo, a.deliveryService, a.paymentService)
+	a.cartService = services.NewCartService(cartRepo, productRepo, a.orderService, cfg)
+	a.returnService = services.NewReturnService(returnRepo, orderRepo, a.paymentService, a.notificationService)
+	a.reviewService = services.NewReviewService(reviewRepo, productRepo, orderRepo)
+	a.otpService = services.NewOTPService(otpRepo, a.notificationService, cfg)
+	a.profileService = services.NewProfileService(customerRepo, identityRepo, orderRepo, addressRepo, notificationRepo, a.otpService)
+	a.customerService = services.NewCustomerService(customerRepo, orderRepo, a.authAuditService)
+	return a
+}
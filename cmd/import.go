@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+)
+
+const importProductsUsage = `usage: main import-products FILE.csv
+
+Creates or updates products from a CSV file with a header row. Columns:
+
+  sku          required; the product with this SKU is updated, or else
+               created
+  name         required for a new product
+  price        required for a new product
+  category     required for a new product; a path of names such as
+               Electronics/Phones, whose missing categories are created
+  description  optional
+  stock        optional
+  weight_kg    optional
+
+Empty cells leave an existing product's value as it is. A file with a
+malformed cell is refused before anything is saved; otherwise rows are saved
+one by one, so a row that cannot be saved is reported and the rest are still
+imported.`
+
+// productColumns are the columns an import file may have.
+var productColumns = map[string]bool{
+	"sku": true, "name": true, "description": true, "price": true,
+	"stock": true, "weight_kg": true, "category": true,
+}
+
+// productRow is a product from an import file or the demo catalog. Empty
+// fields and nil pointers were not given.
+type productRow struct {
+	Line        int
+	SKU         string
+	Name        string
+	Description string
+	Price       float64
+	Stock       *int
+	WeightKg    *float64
+	Category    string
+}
+
+func importProducts(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rows, err := readProductRows(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	categories := newCategoryPaths(a.categoryService)
+	var created, updated, failed int
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		product, isNew, err := saveProduct(ctx, a, categories, row, true)
+		if err != nil {
+			a.logger.Error().Err(err).Int("line", row.Line).Str("sku", row.SKU).Msg("Product not imported")
+			failed++
+			continue
+		}
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+		a.logger.Debug().Int("line", row.Line).Str("sku", product.SKU).Uint("productID", product.ID).Bool("created", isNew).Msg("Product imported")
+	}
+
+	// Back-in-stock alerts that did not go out before exiting are sent by
+	// the server's periodic run
+	a.logger.Info().Int("created", created).Int("updated", updated).Int("failed", failed).Msg("Import finished")
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", failed, len(rows))
+	}
+	return nil
+}
+
+// readProductRows reads an import file. A malformed file or cell is refused
+// as a whole; whether each row can be saved is only checked as it is.
+func readProductRows(r io.Reader) ([]productRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often save a byte order mark before the first column
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !productColumns[name] {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["sku"]; !ok {
+		return nil, errors.New("the sku column is required")
+	}
+
+	var rows []productRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row, err := parseProductRow(columns, record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+}
+
+func parseProductRow(columns map[string]int, record []string) (productRow, error) {
+	cell := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := productRow{
+		SKU:         cell("sku"),
+		Name:        cell("name"),
+		Description: cell("description"),
+		Category:    cell("category"),
+	}
+	if row.SKU == "" {
+		return row, errors.New("sku is empty")
+	}
+	if v := cell("price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || price <= 0 {
+			return row, fmt.Errorf("price must be a positive number, not %q", v)
+		}
+		row.Price = price
+	}
+	if v := cell("stock"); v != "" {
+		stock, err := strconv.Atoi(v)
+		if err != nil || stock < 0 {
+			return row, fmt.Errorf("stock must be a whole number of at least 0, not %q", v)
+		}
+		row.Stock = &stock
+	}
+	if v := cell("weight_kg"); v != "" {
+		weight, err := strconv.ParseFloat(v, 64)
+		if err != nil || weight < 0 {
+			return row, fmt.Errorf("weight_kg must be a number of at least 0, not %q", v)
+		}
+		row.WeightKg = &weight
+	}
+	return row, nil
+}
+
+// saveProduct creates the row's product through the product service, or
+// when one has its SKU updates it if overwrite is set. It reports whether
+// the product was created.
+func saveProduct(ctx context.Context, a *app, categories *categoryPaths, row productRow, overwrite bool) (*models.Product, bool, error) {
+	existing, err := a.productService.GetProductBySKU(ctx, row.SKU)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	if existing != nil && !overwrite {
+		return existing, false, nil
+	}
+
+	var categoryID uint
+	if row.Category != "" {
+		if categoryID, err = categories.resolve(ctx, row.Category); err != nil {
+			return nil, false, fmt.Errorf("category %q: %w", row.Category, err)
+		}
+	}
+
+	if existing != nil {
+		product, err := a.productService.UpdateProduct(ctx, existing.ID, &services.ProductUpdateRequest{
+			Name:        row.Name,
+			Description: row.Description,
+			Price:       row.Price,
+			WeightKg:    row.WeightKg,
+			Stock:       row.Stock,
+			CategoryID:  categoryID,
+		})
+		return product, false, err
+	}
+
+	switch {
+	case row.Name == "":
+		return nil, false, errors.New("a new product needs a name")
+	case row.Price == 0:
+		return nil, false, errors.New("a new product needs a price")
+	case categoryID == 0:
+		return nil, false, errors.New("a new product needs a category")
+	}
+	req := &services.ProductCreateRequest{
+		Name:        row.Name,
+		Description: row.Description,
+		Price:       row.Price,
+		SKU:         row.SKU,
+		Stock:       row.Stock,
+		CategoryID:  categoryID,
+	}
+	if row.WeightKg != nil {
+		req.WeightKg = *row.WeightKg
+	}
+	product, err := a.productService.CreateProduct(ctx, req)
+	return product, true, err
+}
+
+// categoryPaths finds categories by their path of names from the root, such
+// as "Electronics/Phones", ignoring case, and creates those missing.
+type categoryPaths struct {
+	service services.CategoryService
+	ids     map[string]uint
+}
+
+func newCategoryPaths(service services.CategoryService) *categoryPaths {
+	return &categoryPaths{service: service, ids: make(map[string]uint)}
+}
+
+func (c *categoryPaths) resolve(ctx context.Context, path string) (uint, error) {
+	names := strings.Split(path, "/")
+	for i, name := range names {
+		if names[i] = strings.TrimSpace(name); names[i] == "" {
+			return 0, errors.New("a category name in the path is empty")
+		}
+	}
+	key := strings.ToLower(strings.Join(names, "/"))
+	if id, ok := c.ids[key]; ok {
+		return id, nil
+	}
+
+	siblings, err := c.service.GetCategories()
+	if err != nil {
+		return 0, err
+	}
+	var parentID *uint
+	for _, name := range names {
+		var category *models.Category
+		for i := range siblings {
+			if strings.EqualFold(siblings[i].Name, name) {
+				category = &siblings[i]
+				break
+			}
+		}
+
+		if category == nil {
+			if category, err = c.service.CreateCategory(ctx, &services.CategoryCreateRequest{Name: name, ParentID: parentID}); err != nil {
+				return 0, err
+			}
+			siblings = nil
+		} else {
+			// The listing only goes one level deep, so each category on
+			// the path is loaded for its children
+			full, err := c.service.GetCategory(category.ID)
+			if err != nil {
+				return 0, err
+			}
+			siblings = full.Children
+		}
+		parentID = &category.ID
+	}
+
+	c.ids[key] = *parentID
+	return *parentID, nil
+}
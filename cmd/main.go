@@ -2,28 +2,72 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/Mutonya/Savanah/internal/domain/models"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
+	"github.com/Mutonya/Savanah/internal/audit"
 	"github.com/Mutonya/Savanah/internal/config"
 	"github.com/Mutonya/Savanah/internal/controllers"
-	"github.com/Mutonya/Savanah/internal/domain/repositories"
+	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/services"
 	"github.com/Mutonya/Savanah/internal/middleware"
 	"github.com/Mutonya/Savanah/internal/routes"
 	"github.com/Mutonya/Savanah/internal/utils/logging"
-	"github.com/Mutonya/Savanah/pkg/database"
 	"github.com/Mutonya/Savanah/pkg/oauth2"
 )
 
+const usage = `usage: main [command] [arguments]
+
+Commands:
+  serve                   run the API server (the default)
+  migrate                 apply, roll back or list the schema migrations
+  seed                    add a demo catalog and customers
+  create-admin            make a customer an admin, signing them up if needed
+  reindex-search          rebuild the indexes the staff searches use
+  resend-notification     send an order's confirmation or status update again
+  import-products         create or update products from a CSV file
+
+Run "main COMMAND -h" for a command's arguments.`
+
+// errUsage is returned by a command whose arguments are wrong; its usage is
+// printed.
+var errUsage = errors.New("invalid arguments")
+
+// command is a subcommand of the binary. run gets the arguments after the
+// command's name.
+type command struct {
+	usage string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+// newFlagSet returns the flag set for a command's arguments. A bad flag is
+// reported and then errUsage returned, so it is the command's usage that is
+// printed.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {}
+	return fs
+}
+
+var commands = map[string]command{
+	"serve":               {"usage: main serve", serve},
+	"migrate":             {migrateUsage, runMigrateCommand},
+	"seed":                {seedUsage, seed},
+	"create-admin":        {createAdminUsage, createAdmin},
+	"reindex-search":      {reindexSearchUsage, reindexSearch},
+	"resend-notification": {resendNotificationUsage, resendNotification},
+	"import-products":     {importProductsUsage, importProducts},
+}
+
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
@@ -31,59 +75,129 @@ func main() {
 	// Initialize logger
 	logger := logging.NewLogger(os.Stdout, cfg.Environment)
 
-	// Initialize database
-	db, err := database.NewPostgresDB(&database.DBConfig{
-		Host:     cfg.DBHost,
-		Port:     cfg.DBPort,
-		User:     cfg.DBUser,
-		Password: cfg.DBPassword,
-		DBName:   cfg.DBName,
-		SSLMode:  cfg.SSLMode,
-	})
-	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to connect to database")
+	// Without a command the binary runs the server, as it always has
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
 	}
 
-	// Run migrations
-	if err := runMigrations(db); err != nil {
-		logger.Fatal().Err(err).Msg("Failed to run migrations")
+	ctx := context.Background()
+	if name != "serve" {
+		// A command stops at Ctrl-C, and its changes can be told apart in
+		// the audit trail
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		ctx = audit.WithRequestID(audit.WithActor(ctx, audit.System), fmt.Sprintf("cli-%s-%d", name, time.Now().Unix()))
 	}
 
-	// Initialize OAuth provider
-	fmt.Println("OIDC Provider URL:", cfg.OAuthProviderURL)
-	oauthProvider, err := oauth2.NewOIDCProvider(
-		context.Background(),
-		cfg.OAuthClientID,
-		cfg.OAuthClientSecret,
-		cfg.OAuthRedirectURL,
-		cfg.OAuthProviderURL,
-	)
+	err := cmd.run(ctx, newApp(cfg, logger), args)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, cmd.usage)
+		os.Exit(2)
+	}
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to initialize OAuth provider")
+		logger.Fatal().Err(err).Str("command", name).Msg("Command failed")
+	}
+}
+
+// serve runs the API server and the background jobs until it is stopped.
+func serve(ctx context.Context, a *app, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+	cfg, logger := a.cfg, a.logger
+
+	if cfg.MigrateOnStart {
+		if err := runMigrations(ctx, a.db, cfg.MigrationsDir, logger); err != nil {
+			logger.Fatal().Err(err).Msg("Failed to run migrations")
+		}
+	}
+
+	// Dev mode signs in against a mock OIDC issuer served by this API
+	var devIssuer *oauth2.MockIssuer
+	var err error
+	if cfg.DevMode {
+		if cfg.Environment == "production" {
+			logger.Fatal().Msg("DEV_MODE cannot be enabled in production")
+		}
+		users := make([]oauth2.MockUser, 0, len(cfg.DevOIDCUsers))
+		for _, email := range cfg.DevOIDCUsers {
+			users = append(users, oauth2.NewMockUser(email))
+		}
+		devIssuer, err = oauth2.NewMockIssuer(cfg.DevOAuthProviderConfig().IssuerURL, users...)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to initialize the dev OIDC issuer")
+		}
+		logger.Warn().Str("issuer", devIssuer.URL()).Strs("users", cfg.DevOIDCUsers).Msg("DEV_MODE is on: anyone can sign in as the dev users")
+	}
+
+	// Initialize OAuth providers
+	oauthProviders := make(map[string]oauth2.OAuthProvider, len(cfg.OAuthProviders))
+	for _, p := range cfg.OAuthProviders {
+		if devIssuer != nil && p.Name == config.DevOAuthProvider {
+			oauthProviders[p.Name] = devIssuer.Provider(context.Background(), p.ClientID, p.RedirectURL)
+			continue
+		}
+		logger.Info().Str("provider", p.Name).Str("issuer", p.IssuerURL).Msg("Initializing OIDC provider")
+		provider, err := oauth2.NewOIDCProvider(context.Background(), p.ClientID, p.ClientSecret, p.RedirectURL, p.IssuerURL)
+		if err != nil {
+			logger.Fatal().Err(err).Str("provider", p.Name).Msg("Failed to initialize OAuth provider")
+		}
+		oauthProviders[p.Name] = provider
 	}
 
-	// Initialize repositories
-	customerRepo := repositories.NewCustomerRepository(db)
-	productRepo := repositories.NewProductRepository(db)
-	categoryRepo := repositories.NewCategoryRepository(db)
-	orderRepo := repositories.NewOrderRepository(db)
+	// Login state cookies; without a secret they only work on this instance
+	// until it restarts
+	if cfg.AuthCookieSecret == "" {
+		logger.Warn().Msg("AUTH_COOKIE_SECRET is not set, using a random key")
+	}
+	loginStates, err := oauth2.NewStateCodec(cfg.AuthCookieSecret, cfg.LoginStateTTL)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to initialize login state cookies")
+	}
 
-	// Initialize services
-	authService := services.NewAuthService(oauthProvider, customerRepo, cfg)
-	productService := services.NewProductService(productRepo)
-	categoryService := services.NewCategoryService(categoryRepo)
-	notificationService := services.NewNotificationService(cfg)
-	orderService := services.NewOrderService(orderRepo, productRepo, customerRepo, notificationService)
+	authService := services.NewAuthService(oauthProviders, a.customerRepo, a.identityRepo, a.sessionRepo, a.apiKeyService, a.authAuditService, cfg)
 
 	// Initialize controllers
-	authController := controllers.NewAuthController(authService)
-	productController := controllers.NewProductController(productService)
-	categoryController := controllers.NewCategoryController(categoryService)
-	orderController := controllers.NewOrderController(orderService, notificationService)
+	authController := controllers.NewAuthController(authService, a.authAuditService, loginStates, controllers.LoginCookie{
+		Secure:   cfg.AuthCookieSecure,
+		SameSite: cfg.AuthCookieSameSite,
+	})
+	productController := controllers.NewProductController(a.productService)
+	categoryController := controllers.NewCategoryController(a.categoryService)
+	orderController := controllers.NewOrderController(a.orderService, a.notificationService)
+	cartController := controllers.NewCartController(a.cartService)
+	paymentController := controllers.NewPaymentController(a.paymentService)
+	promotionController := controllers.NewPromotionController(a.promotionService)
+	taxController := controllers.NewTaxController(a.taxService)
+	addressController := controllers.NewAddressController(a.addressService)
+	deliveryController := controllers.NewDeliveryController(a.deliveryService)
+	returnController := controllers.NewReturnController(a.returnService)
+	invoiceController := controllers.NewInvoiceController(a.invoiceService)
+	wishlistController := controllers.NewWishlistController(a.wishlistService)
+	reviewController := controllers.NewReviewController(a.reviewService)
+	profileController := controllers.NewProfileController(a.profileService)
+	otpController := controllers.NewOTPController(a.otpService, map[models.OTPPurpose]services.OTPVerifier{
+		models.OTPPurposePhoneVerification: a.profileService,
+	})
+	customerController := controllers.NewCustomerController(a.customerService)
+	apiKeyController := controllers.NewAPIKeyController(a.apiKeyService)
+	authAuditController := controllers.NewAuthAuditController(a.authAuditService)
+	auditController := controllers.NewAuditController(a.auditService)
 
 	// Create Gin router
 	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(logging.LoggingMiddleware(logger))
 	router.Use(middleware.CORSMiddleware())
 
@@ -91,6 +205,75 @@ func main() {
 	routes.SetupHealthRoute(router)
 	routes.SetupAuthRoutes(router, authController)
 	routes.SetupAPIRoutes(router, authService, productController, categoryController, orderController, authController)
+	routes.SetupCartRoutes(router, authService, cartController)
+	routes.SetupPaymentRoutes(router, authService, paymentController,
+		middleware.CallbackGuard(cfg.MpesaCallbackAllowedIPs, cfg.MpesaCallbackToken))
+	routes.SetupPromotionRoutes(router, authService, promotionController)
+	routes.SetupTaxRoutes(router, authService, taxController)
+	routes.SetupAddressRoutes(router, authService, addressController)
+	routes.SetupDeliveryRoutes(router, authService, deliveryController)
+	routes.SetupReturnRoutes(router, authService, orderController, returnController, paymentController)
+	routes.SetupInvoiceRoutes(router, authService, invoiceController)
+	routes.SetupAdminOrderRoutes(router, authService, orderController)
+	routes.SetupWishlistRoutes(router, authService, wishlistController)
+	routes.SetupReviewRoutes(router, authService, reviewController)
+	routes.SetupProfileRoutes(router, authService, profileController)
+	routes.SetupOTPRoutes(router, authService, otpController)
+	routes.SetupAdminCustomerRoutes(router, authService, customerController)
+	routes.SetupAdminAPIKeyRoutes(router, authService, apiKeyController)
+	routes.SetupAdminAuthEventRoutes(router, authService, authAuditController)
+	routes.SetupAdminAuditRoutes(router, authService, auditController)
+	if devIssuer != nil {
+		routes.SetupDevOIDCRoutes(router, devIssuer)
+	}
+
+	// Background jobs stop together with the server
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	go runPeriodically(jobsCtx, time.Hour, func(ctx context.Context) {
+		removed, err := a.cartService.PurgeExpired(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to purge idle carts")
+			return
+		}
+		if removed > 0 {
+			logger.Info().Int64("removed", removed).Msg("Purged idle carts")
+		}
+	})
+
+	go runPeriodically(jobsCtx, cfg.PaymentReconcileInterval, func(ctx context.Context) {
+		settled, err := a.paymentService.Reconcile(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to reconcile pending payments")
+			return
+		}
+		if settled > 0 {
+			logger.Info().Int("settled", settled).Msg("Reconciled pending payments")
+		}
+	})
+
+	go runPeriodically(jobsCtx, cfg.StockAlertInterval, func(ctx context.Context) {
+		sent, err := a.wishlistService.NotifyBackInStock(ctx, 0)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to send back-in-stock alerts")
+			return
+		}
+		if sent > 0 {
+			logger.Info().Int("sent", sent).Msg("Sent back-in-stock alerts")
+		}
+	})
+
+	go runPeriodically(jobsCtx, time.Hour, func(ctx context.Context) {
+		removed, err := a.otpService.PurgeStale(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to purge stale one-time codes")
+			return
+		}
+		if removed > 0 {
+			logger.Info().Int64("removed", removed).Msg("Purged stale one-time codes")
+		}
+	})
 
 	// Start server
 	srv := &http.Server{
@@ -120,22 +303,36 @@ func main() {
 	}
 
 	logger.Info().Msg("Server exited properly")
+	return nil
 }
 
-func runMigrations(db *gorm.DB) error {
-	// Automatic migrations for simple cases
-	err := db.AutoMigrate(
-		&models.Customer{},
-		&models.Category{},
-		&models.Product{},
-		&models.Order{},
-		&models.OrderItem{},
-	)
-	if err != nil {
-		return err
+// runPeriodically calls job every interval until ctx is cancelled.
+func runPeriodically(ctx context.Context, interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job(ctx)
+		}
 	}
+}
 
-	// Manual SQL migrations for complex changes
-	// (Would use a migration tool like golang-migrate in production)
-	return nil
+// withCallbackToken appends the shared callback token, if any, to a callback
+// URL registered with a provider so CallbackGuard can recognise genuine calls.
+func withCallbackToken(callbackURL, token string) string {
+	if token == "" || callbackURL == "" {
+		return callbackURL
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return callbackURL
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"github.com/Mutonya/Savanah/internal/domain/models"
 	"net/http"
 	"os"
@@ -49,34 +48,48 @@ func main() {
 		logger.Fatal().Err(err).Msg("Failed to run migrations")
 	}
 
-	// Initialize OAuth provider
-	fmt.Println("OIDC Provider URL:", cfg.OAuthProviderURL)
-	oauthProvider, err := oauth2.NewOIDCProvider(
-		context.Background(),
-		cfg.OAuthClientID,
-		cfg.OAuthClientSecret,
-		cfg.OAuthRedirectURL,
-		cfg.OAuthProviderURL,
-	)
+	// Initialize OAuth providers
+	oauthProviders := make(map[string]oauth2.OAuthProvider, len(cfg.OAuthProviders))
+	for _, p := range cfg.OAuthProviders {
+		logger.Info().Str("provider", p.Name).Str("issuer", p.IssuerURL).Msg("Initializing OIDC provider")
+		provider, err := oauth2.NewOIDCProvider(context.Background(), p.ClientID, p.ClientSecret, p.RedirectURL, p.IssuerURL)
+		if err != nil {
+			logger.Fatal().Err(err).Str("provider", p.Name).Msg("Failed to initialize OAuth provider")
+		}
+		oauthProviders[p.Name] = provider
+	}
+
+	// Login state cookies; without a secret they only work on this instance
+	// until it restarts
+	if cfg.AuthCookieSecret == "" {
+		logger.Warn().Msg("AUTH_COOKIE_SECRET is not set, using a random key")
+	}
+	loginStates, err := oauth2.NewStateCodec(cfg.AuthCookieSecret, cfg.LoginStateTTL)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to initialize OAuth provider")
+		logger.Fatal().Err(err).Msg("Failed to initialize login state cookies")
 	}
 
 	// Initialize repositories
 	customerRepo := repositories.NewCustomerRepository(db)
+	identityRepo := repositories.NewIdentityRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
 	productRepo := repositories.NewProductRepository(db)
 	categoryRepo := repositories.NewCategoryRepository(db)
 	orderRepo := repositories.NewOrderRepository(db)
 
 	// Initialize services
-	authService := services.NewAuthService(oauthProvider, customerRepo, cfg)
-	productService := services.NewProductService(productRepo)
+	authService := services.NewAuthService(oauthProviders, customerRepo, identityRepo, repositories.NewSessionRepository(db), services.NewAPIKeyService(apiKeyRepo), nil, cfg)
 	categoryService := services.NewCategoryService(categoryRepo)
-	notificationService := services.NewNotificationService(cfg)
+	notificationService := services.NewNotificationService(cfg, nil, nil)
+	wishlistService := services.NewWishlistService(repositories.NewWishlistRepository(db), productRepo, notificationService)
+	productService := services.NewProductService(productRepo, wishlistService)
 	orderService := services.NewOrderService(orderRepo, productRepo, customerRepo, notificationService)
 
 	// Initialize controllers
-	authController := controllers.NewAuthController(authService)
+	authController := controllers.NewAuthController(authService, nil, loginStates, controllers.LoginCookie{
+		Secure:   cfg.AuthCookieSecure,
+		SameSite: cfg.AuthCookieSameSite,
+	})
 	productController := controllers.NewProductController(productService)
 	categoryController := controllers.NewCategoryController(categoryService)
 	orderController := controllers.NewOrderController(orderService, notificationService)
@@ -125,6 +138,7 @@ func TestrunMigrations(db *gorm.DB) error {
 	// Automatic migrations for simple cases
 	err := db.AutoMigrate(
 		&models.Customer{},
+		&models.Identity{},
 		&models.Category{},
 		&models.Product{},
 		&models.Order{},
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/pkg/migrate"
+)
+
+const migrateUsage = `usage: migrate up | down [N] | status | baseline VERSION
+
+  up                apply every pending migration
+  down [N]          roll back the latest N migrations (default 1)
+  status            list the migrations and when each was applied
+  baseline VERSION  record the migrations up to VERSION as applied without
+                    running them, for a database created before versioning`
+
+// runMigrateCommand runs the migrate subcommand.
+func runMigrateCommand(ctx context.Context, a *app, args []string) error {
+	migrator, err := newMigrator(a.db, a.cfg.MigrationsDir)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	var done []migrate.Migration
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		done, err = migrator.Up(ctx)
+
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("down takes a number of migrations, not %q", args[1])
+			}
+		}
+		done, err = migrator.Down(ctx, steps)
+
+	case command == "baseline" && len(args) == 2:
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("baseline takes a version number, not %q", args[1])
+		}
+		done, err = migrator.Baseline(ctx, version)
+
+	case command == "status" && len(args) == 1:
+		return printMigrationStatus(ctx, migrator)
+
+	default:
+		return errUsage
+	}
+
+	for _, migration := range done {
+		a.logger.Info().Str("migration", migration.String()).Msgf("Migration %s", args[0])
+	}
+	if err == nil && len(done) == 0 {
+		a.logger.Info().Msg("Nothing to migrate")
+	}
+	return err
+}
+
+// runMigrations applies the pending migrations in dir, as the server starts.
+func runMigrations(ctx context.Context, db *gorm.DB, dir string, logger zerolog.Logger) error {
+	migrator, err := newMigrator(db, dir)
+	if err != nil {
+		return err
+	}
+	done, err := migrator.Up(ctx)
+	for _, migration := range done {
+		logger.Info().Str("migration", migration.String()).Msg("Migration applied")
+	}
+	return err
+}
+
+func newMigrator(db *gorm.DB, dir string) (*migrate.Migrator, error) {
+	migrations, err := migrate.Load(os.DirFS(dir))
+	if err != nil {
+		return nil, fmt.Errorf("loading migrations from %s: %w", dir, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB, migrations), nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if status.Missing {
+			applied += " (no migration file)"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", status.Migration, applied)
+	}
+	return w.Flush()
+}
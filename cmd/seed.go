@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+const seedUsage = `usage: main seed
+
+Adds a demo catalog and customers: a few categories and products, the
+customer customer@example.com and the staff member staff@example.com, who
+are the users dev mode signs in as. What is there already is left alone,
+so it can be run again.`
+
+// demoProducts are the demo catalog; the SKUs tell seed which are there
+// already.
+var demoProducts = []productRow{
+	{SKU: "DEMO-PHN-001", Name: "Tecno Spark 20", Description: "6.6\" display, 128 GB storage, dual SIM.",
+		Price: 18999, Stock: intPtr(25), WeightKg: floatPtr(0.19), Category: "Electronics/Phones"},
+	{SKU: "DEMO-PHN-002", Name: "Samsung Galaxy A15", Description: "6.5\" AMOLED display, 128 GB storage.",
+		Price: 24999, Stock: intPtr(15), WeightKg: floatPtr(0.2), Category: "Electronics/Phones"},
+	{SKU: "DEMO-ACC-001", Name: "USB-C Charger 20W", Description: "Fast charger with a 1 m cable.",
+		Price: 1499, Stock: intPtr(100), WeightKg: floatPtr(0.1), Category: "Electronics/Accessories"},
+	{SKU: "DEMO-BEV-001", Name: "Kenyan AA Coffee 500g", Description: "Whole beans from the slopes of Mount Kenya.",
+		Price: 1150, Stock: intPtr(60), WeightKg: floatPtr(0.5), Category: "Groceries/Beverages"},
+	{SKU: "DEMO-BEV-002", Name: "Kericho Gold Tea, 100 Bags", Description: "Black tea from Kericho.",
+		Price: 420, Stock: intPtr(120), WeightKg: floatPtr(0.25), Category: "Groceries/Beverages"},
+	{SKU: "DEMO-PAN-001", Name: "Pishori Rice 2kg", Description: "Aromatic rice from Mwea.",
+		Price: 560, Stock: intPtr(80), WeightKg: floatPtr(2), Category: "Groceries/Pantry"},
+	{SKU: "DEMO-KIT-001", Name: "Energy-Saving Jiko", Description: "Charcoal stove with a ceramic liner.",
+		Price: 3200, Stock: intPtr(0), WeightKg: floatPtr(4.5), Category: "Home/Kitchen"},
+}
+
+// demoCustomers have the emails of the default dev mode users, so signing
+// in as one links to its customer.
+var demoCustomers = []models.Customer{
+	{FirstName: "Amina", LastName: "Wanjiku", Email: "customer@example.com", Phone: "+254712345678", Role: models.RoleCustomer},
+	{FirstName: "Brian", LastName: "Otieno", Email: "staff@example.com", Phone: "+254722000111", Role: models.RoleStaff},
+}
+
+func seed(ctx context.Context, a *app, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	categories := newCategoryPaths(a.categoryService)
+	for _, row := range demoProducts {
+		product, created, err := saveProduct(ctx, a, categories, row, false)
+		if err != nil {
+			return err
+		}
+		if created {
+			a.logger.Info().Str("sku", product.SKU).Uint("productID", product.ID).Msg("Demo product added")
+		}
+	}
+
+	for _, customer := range demoCustomers {
+		_, err := a.customerRepo.GetByEmail(ctx, customer.Email)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := a.customerRepo.Create(ctx, &customer); err != nil {
+			return err
+		}
+		a.logger.Info().Str("email", customer.Email).Uint("customerID", customer.ID).Msg("Demo customer added")
+	}
+
+	a.logger.Info().Msg("Demo data is in place")
+	return nil
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
@@ -0,0 +1,65 @@
+// Package audit keeps the audit trail: every row created, updated or deleted
+// through GORM is recorded in audit_events with who changed it, what changed
+// and the request it came from. It hooks into GORM's callbacks, so no
+// repository can leave a change out.
+package audit
+
+import (
+	"context"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// Actor is who changes are attributed to.
+type Actor struct {
+	Type string
+	ID   uint
+}
+
+// System is the actor for changes made without a user, such as background
+// jobs and the command-line tools.
+var System = Actor{Type: models.AuditActorSystem}
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+)
+
+// WithActor attributes the changes made with ctx to actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// WithRequestID tags the changes made with ctx with a request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// ActorFrom returns who the changes made with ctx are attributed to. Besides
+// WithActor, it reads the customerID and apiKeyID keys AuthMiddleware puts
+// on a *gin.Context, as controllers pass theirs down to the repositories.
+// Anything else is the system.
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey).(Actor); ok {
+		return actor
+	}
+	if id, ok := ctx.Value("apiKeyID").(uint); ok {
+		return Actor{Type: models.AuditActorAPIKey, ID: id}
+	}
+	if id, ok := ctx.Value("customerID").(uint); ok {
+		return Actor{Type: models.AuditActorCustomer, ID: id}
+	}
+	return System
+}
+
+// RequestIDFrom returns the request ID set by WithRequestID or the
+// middleware.RequestID key on a *gin.Context, or "".
+func RequestIDFrom(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		return id
+	}
+	id, _ := ctx.Value("requestID").(string)
+	return id
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestActorFrom(t *testing.T) {
+	assert.Equal(t, System, ActorFrom(context.Background()))
+
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Set("customerID", uint(7))
+	ctx.Set("requestID", "req-1")
+	assert.Equal(t, Actor{Type: models.AuditActorCustomer, ID: 7}, ActorFrom(ctx))
+	assert.Equal(t, "req-1", RequestIDFrom(ctx))
+
+	ctx.Set("apiKeyID", uint(3))
+	assert.Equal(t, Actor{Type: models.AuditActorAPIKey, ID: 3}, ActorFrom(ctx))
+
+	tagged := WithRequestID(WithActor(context.Background(), Actor{Type: models.AuditActorCustomer, ID: 1}), "cli")
+	assert.Equal(t, Actor{Type: models.AuditActorCustomer, ID: 1}, ActorFrom(tagged))
+	assert.Equal(t, "cli", RequestIDFrom(tagged))
+}
+
+func TestDiff(t *testing.T) {
+	s, err := schema.Parse(&models.APIKey{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	before := map[string]interface{}{
+		"id":         int64(4),
+		"name":       "ERP",
+		"key_hash":   "9f86d081884c7d65",
+		"scopes":     []byte(`["products:read"]`),
+		"revoked_at": nil,
+		"created_at": created,
+	}
+	after := map[string]interface{}{
+		"id":         int64(4),
+		"name":       "ERP",
+		"key_hash":   "60303ae22b998861",
+		"scopes":     `["products:read"]`,
+		"revoked_at": created.Add(time.Hour),
+		"created_at": created,
+	}
+
+	assert.Equal(t, map[string]models.AuditChange{
+		"key_hash":   {From: Redacted, To: Redacted},
+		"revoked_at": {From: nil, To: created.Add(time.Hour)},
+	}, Diff(s, before, after), "only changed columns, with secrets hidden")
+
+	changes := Diff(s, nil, before)
+	assert.Equal(t, models.AuditChange{From: nil, To: "ERP"}, changes["name"])
+	assert.Equal(t, models.AuditChange{From: nil, To: `["products:read"]`}, changes["scopes"])
+	assert.NotContains(t, changes, "revoked_at", "columns that stay null are left out")
+	assert.NotContains(t, changes, "created_at")
+
+	changes = Diff(s, before, nil)
+	assert.Equal(t, models.AuditChange{From: int64(4), To: nil}, changes["id"])
+	assert.Equal(t, models.AuditChange{From: Redacted, To: nil}, changes["key_hash"])
+}
+
+func TestDiffHidesPersonalData(t *testing.T) {
+	s, err := schema.Parse(&models.Order{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	before := map[string]interface{}{
+		"id":                      int64(9),
+		"status":                  "pending",
+		"shipping_recipient_name": "Amina Wanjiku",
+		"shipping_phone":          "+254712345678",
+		"shipping_town":           "Nakuru",
+	}
+	after := map[string]interface{}{
+		"id":                      int64(9),
+		"status":                  "paid",
+		"shipping_recipient_name": "Amina W.",
+		"shipping_phone":          "+254712345678",
+		"shipping_town":           "Nakuru",
+	}
+
+	assert.Equal(t, map[string]models.AuditChange{
+		"status":                  {From: "pending", To: "paid"},
+		"shipping_recipient_name": {From: Redacted, To: Redacted},
+	}, Diff(s, before, after))
+
+	changes := Diff(s, nil, before)
+	assert.Equal(t, models.AuditChange{From: nil, To: Redacted}, changes["shipping_phone"])
+	assert.Equal(t, models.AuditChange{From: nil, To: "Nakuru"}, changes["shipping_town"], "the town is not personal")
+}
+
+func TestScrubChanges(t *testing.T) {
+	s, err := schema.Parse(&models.Customer{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	// An entry written before the columns were tagged
+	changes := map[string]models.AuditChange{
+		"email": {From: "amina@example.com", To: "amina.w@example.com"},
+		"phone": {From: nil, To: "+254712345678"},
+		"role":  {From: "customer", To: "staff"},
+	}
+	assert.True(t, scrubChanges(s, changes))
+	assert.Equal(t, map[string]models.AuditChange{
+		"email": {From: Redacted, To: Redacted},
+		"phone": {From: nil, To: Redacted},
+		"role":  {From: "customer", To: "staff"},
+	}, changes)
+
+	assert.False(t, scrubChanges(s, changes), "nothing is left to scrub")
+}
@@ -0,0 +1,298 @@
+package audit
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// Redacted stands in for the value of a column the audit trail does not
+// keep: those the API never shows, whose field is tagged json:"-" such as
+// key hashes, and personal data, whose field is tagged audit:"pii" such as
+// customers' names and phone numbers.
+const Redacted = "[redacted]"
+
+// skipTables are not audited: the audit logs themselves.
+var skipTables = map[string]bool{
+	"audit_events": true,
+	"auth_events":  true,
+}
+
+// ignoredColumns change with every write and say nothing the entry's own
+// time does not.
+var ignoredColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+const beforeKey = "audit:before"
+
+// Plugin records the audit trail; register it with db.Use. The rows a write
+// touches are read before and after it, and the entries are saved in the
+// same transaction, so a change is never kept without its entry: if the
+// entry cannot be saved the write fails. Raw SQL is not audited.
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "audit"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	// The entries go in before GORM commits the write's transaction
+	const commit = "gorm:commit_or_rollback_transaction"
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Before(commit).Register("audit:record_create", recordCreate); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("audit:capture_update", captureBefore); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Before(commit).Register("audit:record_update", recordUpdate); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("audit:capture_delete", captureBefore); err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Before(commit).Register("audit:record_delete", recordDelete)
+}
+
+func audited(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && !db.DryRun &&
+		stmt.Schema != nil && len(stmt.Schema.PrimaryFields) > 0 &&
+		!skipTables[stmt.Table]
+}
+
+func recordCreate(db *gorm.DB) {
+	if !audited(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+	cond := primaryKeysIn(db.Statement, identityValues(db.Statement, db.Statement.ReflectValue))
+	if cond == nil {
+		return
+	}
+	rows, err := snapshot(db, true, cond)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+
+	events := make([]models.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, newEvent(db, models.AuditActionCreate, row, Diff(db.Statement.Schema, nil, row)))
+	}
+	save(db, events)
+}
+
+// captureBefore keeps the rows an update or delete is about to change.
+func captureBefore(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	stmt := db.Statement
+
+	var conds []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			conds = append(conds, where.Exprs...)
+		}
+	}
+	// GORM adds the primary key of the value being saved or deleted to the
+	// conditions itself, later on
+	if cond := primaryKeysIn(stmt, identityValues(stmt, stmt.ReflectValue)); cond != nil {
+		conds = append(conds, cond)
+	}
+	if stmt.Model != nil && stmt.Model != stmt.Dest {
+		if cond := primaryKeysIn(stmt, identityValues(stmt, reflect.ValueOf(stmt.Model))); cond != nil {
+			conds = append(conds, cond)
+		}
+	}
+	// Without conditions GORM refuses the write, unless global updates are
+	// allowed; those are not audited
+	if len(conds) == 0 {
+		return
+	}
+
+	rows, err := snapshot(db, stmt.Unscoped, conds...)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	db.InstanceSet(beforeKey, rows)
+}
+
+func recordUpdate(db *gorm.DB) {
+	before := capturedRows(db)
+	if len(before) == 0 {
+		return
+	}
+	stmt := db.Statement
+
+	after, err := snapshot(db, true, primaryKeysIn(stmt, rowKeys(stmt, before)))
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	afterByID := make(map[string]map[string]interface{}, len(after))
+	for _, row := range after {
+		afterByID[entityID(stmt, row)] = row
+	}
+
+	var events []models.AuditEvent
+	for _, row := range before {
+		changes := Diff(stmt.Schema, row, afterByID[entityID(stmt, row)])
+		if len(changes) > 0 {
+			events = append(events, newEvent(db, models.AuditActionUpdate, row, changes))
+		}
+	}
+	save(db, events)
+}
+
+func recordDelete(db *gorm.DB) {
+	before := capturedRows(db)
+	if len(before) == 0 {
+		return
+	}
+
+	events := make([]models.AuditEvent, 0, len(before))
+	for _, row := range before {
+		events = append(events, newEvent(db, models.AuditActionDelete, row, Diff(db.Statement.Schema, row, nil)))
+	}
+	save(db, events)
+}
+
+func capturedRows(db *gorm.DB) []map[string]interface{} {
+	if !audited(db) || db.Statement.RowsAffected == 0 {
+		return nil
+	}
+	value, _ := db.InstanceGet(beforeKey)
+	rows, _ := value.([]map[string]interface{})
+	return rows
+}
+
+// snapshot reads the rows of the statement's table matching conds, in the
+// statement's transaction.
+func snapshot(db *gorm.DB, unscoped bool, conds ...clause.Expression) ([]map[string]interface{}, error) {
+	tx := db.Session(&gorm.Session{NewDB: true}).
+		Model(reflect.New(db.Statement.Schema.ModelType).Interface())
+	if unscoped {
+		tx = tx.Unscoped()
+	}
+
+	var rows []map[string]interface{}
+	err := tx.Clauses(clause.Where{Exprs: conds}).Find(&rows).Error
+	return rows, err
+}
+
+// identityValues returns the primary keys of the value or values being
+// written, skipping those not set yet.
+func identityValues(stmt *gorm.Statement, value reflect.Value) [][]interface{} {
+	_, values := schema.GetIdentityFieldValuesMap(stmt.Context, value, stmt.Schema.PrimaryFields)
+	return values
+}
+
+// rowKeys returns the primary keys of rows read by snapshot.
+func rowKeys(stmt *gorm.Statement, rows []map[string]interface{}) [][]interface{} {
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = make([]interface{}, len(stmt.Schema.PrimaryFieldDBNames))
+		for j, name := range stmt.Schema.PrimaryFieldDBNames {
+			values[i][j] = row[name]
+		}
+	}
+	return values
+}
+
+func primaryKeysIn(stmt *gorm.Statement, values [][]interface{}) clause.Expression {
+	if len(values) == 0 {
+		return nil
+	}
+	column, queryValues := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, values)
+	return clause.IN{Column: column, Values: queryValues}
+}
+
+func entityID(stmt *gorm.Statement, row map[string]interface{}) string {
+	parts := make([]string, len(stmt.Schema.PrimaryFieldDBNames))
+	for i, name := range stmt.Schema.PrimaryFieldDBNames {
+		parts[i] = fmt.Sprint(row[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+func newEvent(db *gorm.DB, action string, row map[string]interface{}, changes map[string]models.AuditChange) models.AuditEvent {
+	actor := ActorFrom(db.Statement.Context)
+	event := models.AuditEvent{
+		ActorType: actor.Type,
+		Action:    action,
+		Entity:    db.Statement.Table,
+		EntityID:  entityID(db.Statement, row),
+		Changes:   changes,
+		RequestID: RequestIDFrom(db.Statement.Context),
+	}
+	if actor.Type != models.AuditActorSystem {
+		event.ActorID = &actor.ID
+	}
+	return event
+}
+
+func save(db *gorm.DB, events []models.AuditEvent) {
+	if len(events) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).CreateInBatches(&events, 500).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// Diff compares a row before and after a write, as read into maps by
+// column; before is nil for a created row and after for a deleted one. It
+// returns the columns whose value changed, leaving out ignoredColumns and
+// hiding the values of the columns the trail does not keep.
+func Diff(s *schema.Schema, before, after map[string]interface{}) map[string]models.AuditChange {
+	changes := make(map[string]models.AuditChange)
+	for _, row := range []map[string]interface{}{before, after} {
+		for column := range row {
+			if _, seen := changes[column]; seen || ignoredColumns[column] {
+				continue
+			}
+			from, to := normalize(before[column]), normalize(after[column])
+			if reflect.DeepEqual(from, to) {
+				continue
+			}
+			if hidden(s, column) {
+				from, to = redact(from), redact(to)
+			}
+			changes[column] = models.AuditChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+// hidden reports whether the trail keeps Redacted instead of the column's
+// values.
+func hidden(s *schema.Schema, column string) bool {
+	field := s.LookUpField(column)
+	return field != nil && (field.Tag.Get("json") == "-" || field.Tag.Get("audit") == "pii")
+}
+
+// normalize turns the text and JSON columns the driver reads as bytes into
+// strings, so they compare and encode as text.
+func normalize(value interface{}) interface{} {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return value
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return Redacted
+}
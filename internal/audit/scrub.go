@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// Scrub hides the values of the columns the trail does not keep in the
+// entries for the model's rows with the given IDs, as Diff does for new
+// entries. Erasing a person's data calls it, in the same transaction, for
+// entries written before a column was tagged. The entries themselves stay:
+// the trail still shows what changed and when.
+func Scrub(tx *gorm.DB, model interface{}, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	entityIDs := make([]string, len(ids))
+	for i, id := range ids {
+		entityIDs[i] = fmt.Sprint(id)
+	}
+	var events []models.AuditEvent
+	if err := tx.Where("entity = ? AND entity_id IN ?", stmt.Table, entityIDs).Find(&events).Error; err != nil {
+		return err
+	}
+
+	for i := range events {
+		if !scrubChanges(stmt.Schema, events[i].Changes) {
+			continue
+		}
+		if err := tx.Save(&events[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scrubChanges redacts the hidden columns of an entry's changes in place,
+// and reports whether any value was still there to redact.
+func scrubChanges(s *schema.Schema, changes map[string]models.AuditChange) bool {
+	scrubbed := false
+	for column, change := range changes {
+		if !hidden(s, column) {
+			continue
+		}
+		redacted := models.AuditChange{From: redact(change.From), To: redact(change.To)}
+		if !reflect.DeepEqual(redacted, change) {
+			changes[column] = redacted
+			scrubbed = true
+		}
+	}
+	return scrubbed
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -13,10 +16,41 @@ type Config struct {
 	DBName     string
 	SSLMode    string
 
-	OAuthClientID     string
-	OAuthClientSecret string
-	OAuthRedirectURL  string
-	OAuthProviderURL  string
+	// MigrationsDir holds the numbered SQL migrations. With MigrateOnStart
+	// the server applies any pending ones before it starts; otherwise they
+	// are applied with the migrate command.
+	MigrationsDir  string
+	MigrateOnStart bool
+
+	// Identity providers customers can sign in with, in the order they are
+	// offered; the first is used by /auth/login without a provider
+	OAuthProviders []OAuthProviderConfig
+
+	// The login state (state, nonce and PKCE verifier) is kept between
+	// /auth/login and the callback in a cookie encrypted with
+	// AuthCookieSecret, valid for LoginStateTTL
+	AuthCookieSecret   string
+	AuthCookieSecure   bool
+	AuthCookieSameSite http.SameSite
+	LoginStateTTL      time.Duration
+
+	// A sign-in gives the customer a bearer token that lasts SessionTTL
+	SessionTTL time.Duration
+
+	// A sign-in failing for the AuthFailedAttemptLimit-th time from one IP
+	// within AuthFailedAttemptWindow alerts the admin. GeoIPDatabase is a
+	// CSV file of IP ranges and countries (see pkg/geoip); without it
+	// countries are not recorded and new-country sign-ins go unnoticed.
+	AuthFailedAttemptLimit  int
+	AuthFailedAttemptWindow time.Duration
+	GeoIPDatabase           string
+
+	// DevMode adds the "dev" sign-in provider, a built-in mock OIDC issuer
+	// served at DevBaseURL/dev/oidc that signs in as any of DevOIDCUsers
+	// (emails). For local runs and CI only; it is refused in production.
+	DevMode      bool
+	DevBaseURL   string
+	DevOIDCUsers []string
 
 	ServerPort  string
 	Environment string
@@ -30,6 +64,64 @@ type Config struct {
 	AdminEmail            string
 	Currency              string
 	SMSSenderID           string
+
+	// Seller details printed on tax invoices
+	MerchantName    string
+	MerchantKRAPIN  string
+	MerchantAddress string
+
+	CartIdleTTL time.Duration
+
+	// Back-in-stock alerts not sent when a product was restocked, e.g. after
+	// a cancellation put items back, are sent every StockAlertInterval
+	StockAlertInterval time.Duration
+
+	// One-time codes texted to customers. OTPSecret keys the code hashes.
+	// At most OTPMaxSends codes go out per purpose and subject within
+	// OTPSendWindow, at least OTPResendInterval apart.
+	OTPSecret         string
+	OTPTTL            time.Duration
+	OTPMaxAttempts    int
+	OTPResendInterval time.Duration
+	OTPMaxSends       int
+	OTPSendWindow     time.Duration
+
+	// Proxies whose X-Forwarded-For header is trusted for the client IP
+	TrustedProxies []string
+
+	MpesaBaseURL            string
+	MpesaConsumerKey        string
+	MpesaConsumerSecret     string
+	MpesaShortCode          string
+	MpesaPassKey            string
+	MpesaCallbackURL        string
+	MpesaCallbackToken      string
+	MpesaCallbackAllowedIPs []string
+	MpesaInitiatorName      string
+	MpesaSecurityCredential string
+	MpesaResultURL          string
+
+	// Payments still pending after PaymentPendingTimeout are checked with the
+	// provider every PaymentReconcileInterval
+	PaymentPendingTimeout    time.Duration
+	PaymentReconcileInterval time.Duration
+}
+
+// DefaultOAuthProvider names the provider configured by the single-provider
+// OAUTH_PROVIDER_URL variables.
+const DefaultOAuthProvider = "default"
+
+// DevOAuthProvider names the mock provider added in DevMode.
+const DevOAuthProvider = "dev"
+
+// OAuthProviderConfig is an OpenID Connect provider. Its callback is
+// /auth/callback/<Name>, which RedirectURL must point at.
+type OAuthProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
 }
 
 func LoadConfig() *Config {
@@ -40,7 +132,8 @@ func LoadConfig() *Config {
 		// Fallback to default port if conversion fails
 		smtpPort = 1025
 	}
-	return &Config{
+	serverPort := getEnv("SERVER_PORT", "8080")
+	cfg := &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgres"),
@@ -48,12 +141,26 @@ func LoadConfig() *Config {
 		DBName:     getEnv("DB_NAME", "savannah"),
 		SSLMode:    getEnv("SSL_MODE", "disable"),
 
-		OAuthClientID:     getEnv("OAUTH_CLIENT_ID", ""),
-		OAuthClientSecret: getEnv("OAUTH_CLIENT_SECRET", ""),
-		OAuthRedirectURL:  getEnv("OAUTH_REDIRECT_URL", ""),
-		OAuthProviderURL:  getEnv("OAUTH_PROVIDER_URL", ""),
+		MigrationsDir:  getEnv("MIGRATIONS_DIR", "migrations"),
+		MigrateOnStart: getEnvBool("MIGRATE_ON_START", true),
+
+		OAuthProviders: loadOAuthProviders(),
+
+		AuthCookieSecret:   getEnv("AUTH_COOKIE_SECRET", ""),
+		AuthCookieSecure:   getEnvBool("AUTH_COOKIE_SECURE", true),
+		AuthCookieSameSite: getEnvSameSite("AUTH_COOKIE_SAMESITE", http.SameSiteLaxMode),
+		LoginStateTTL:      getEnvDuration("LOGIN_STATE_TTL", 10*time.Minute),
+		SessionTTL:         getEnvDuration("SESSION_TTL", 24*time.Hour),
+
+		AuthFailedAttemptLimit:  getEnvInt("AUTH_FAILED_ATTEMPT_LIMIT", 5),
+		AuthFailedAttemptWindow: getEnvDuration("AUTH_FAILED_ATTEMPT_WINDOW", 15*time.Minute),
+		GeoIPDatabase:           getEnv("GEOIP_DATABASE", ""),
 
-		ServerPort:  getEnv("SERVER_PORT", "8080"),
+		DevMode:      getEnvBool("DEV_MODE", false),
+		DevBaseURL:   strings.TrimSuffix(getEnv("DEV_BASE_URL", "http://localhost:"+serverPort), "/"),
+		DevOIDCUsers: getEnvList("DEV_OIDC_USERS"),
+
+		ServerPort:  serverPort,
 		Environment: getEnv("ENVIRONMENT", "development"),
 
 		AfricaTalkingAPIKey:   getEnv("AFRICA_TALKING_API_KEY", ""),
@@ -67,7 +174,91 @@ func LoadConfig() *Config {
 		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
 		AdminEmail:   getEnv("ADMIN_EMAIL", ""),
 		Currency:     getEnv("CURRENCY", ""),
+
+		MerchantName:    getEnv("MERCHANT_NAME", "Savannah"),
+		MerchantKRAPIN:  getEnv("MERCHANT_KRA_PIN", ""),
+		MerchantAddress: getEnv("MERCHANT_ADDRESS", ""),
+
+		CartIdleTTL: getEnvDuration("CART_IDLE_TTL", 72*time.Hour),
+
+		StockAlertInterval: getEnvInterval("STOCK_ALERT_INTERVAL", 5*time.Minute),
+
+		OTPSecret:         getEnv("OTP_SECRET", ""),
+		OTPTTL:            getEnvDuration("OTP_TTL", 10*time.Minute),
+		OTPMaxAttempts:    getEnvInt("OTP_MAX_ATTEMPTS", 5),
+		OTPResendInterval: getEnvDuration("OTP_RESEND_INTERVAL", time.Minute),
+		OTPMaxSends:       getEnvInt("OTP_MAX_SENDS", 5),
+		OTPSendWindow:     getEnvDuration("OTP_SEND_WINDOW", time.Hour),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		MpesaBaseURL:            getEnv("MPESA_BASE_URL", "https://sandbox.safaricom.co.ke"),
+		MpesaConsumerKey:        getEnv("MPESA_CONSUMER_KEY", ""),
+		MpesaConsumerSecret:     getEnv("MPESA_CONSUMER_SECRET", ""),
+		MpesaShortCode:          getEnv("MPESA_SHORTCODE", ""),
+		MpesaPassKey:            getEnv("MPESA_PASSKEY", ""),
+		MpesaCallbackURL:        getEnv("MPESA_CALLBACK_URL", ""),
+		MpesaCallbackToken:      getEnv("MPESA_CALLBACK_TOKEN", ""),
+		MpesaCallbackAllowedIPs: getEnvList("MPESA_CALLBACK_ALLOWED_IPS"),
+		MpesaInitiatorName:      getEnv("MPESA_INITIATOR_NAME", ""),
+		MpesaSecurityCredential: getEnv("MPESA_SECURITY_CREDENTIAL", ""),
+		MpesaResultURL:          getEnv("MPESA_RESULT_URL", ""),
+
+		PaymentPendingTimeout:    getEnvDuration("PAYMENT_PENDING_TIMEOUT", 10*time.Minute),
+		PaymentReconcileInterval: getEnvInterval("PAYMENT_RECONCILE_INTERVAL", 5*time.Minute),
+	}
+	if cfg.DevMode {
+		if len(cfg.DevOIDCUsers) == 0 {
+			cfg.DevOIDCUsers = []string{"customer@example.com", "staff@example.com"}
+		}
+		cfg.OAuthProviders = append(cfg.OAuthProviders, cfg.DevOAuthProviderConfig())
 	}
+	return cfg
+}
+
+// DevOAuthProviderConfig is the provider DevMode adds. Its issuer is served
+// by the API itself.
+func (c *Config) DevOAuthProviderConfig() OAuthProviderConfig {
+	return OAuthProviderConfig{
+		Name:        DevOAuthProvider,
+		IssuerURL:   c.DevBaseURL + "/dev/oidc",
+		ClientID:    "savannah-dev",
+		RedirectURL: c.DevBaseURL + "/auth/callback/" + DevOAuthProvider,
+	}
+}
+
+// loadOAuthProviders reads the providers named in OAUTH_PROVIDERS, e.g.
+// "google,microsoft", each from OAUTH_<NAME>_ISSUER_URL, _CLIENT_ID,
+// _CLIENT_SECRET and _REDIRECT_URL. Without OAUTH_PROVIDERS the older
+// OAUTH_PROVIDER_URL, OAUTH_CLIENT_ID, OAUTH_CLIENT_SECRET and
+// OAUTH_REDIRECT_URL configure a single provider named DefaultOAuthProvider.
+func loadOAuthProviders() []OAuthProviderConfig {
+	names := getEnvList("OAUTH_PROVIDERS")
+	if len(names) == 0 {
+		if getEnv("OAUTH_PROVIDER_URL", "") == "" {
+			return nil
+		}
+		return []OAuthProviderConfig{{
+			Name:         DefaultOAuthProvider,
+			IssuerURL:    getEnv("OAUTH_PROVIDER_URL", ""),
+			ClientID:     getEnv("OAUTH_CLIENT_ID", ""),
+			ClientSecret: getEnv("OAUTH_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OAUTH_REDIRECT_URL", ""),
+		}}
+	}
+
+	providers := make([]OAuthProviderConfig, 0, len(names))
+	for _, name := range names {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OAuthProviderConfig{
+			Name:         strings.ToLower(name),
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+		})
+	}
+	return providers
 }
 
 func getEnv(key, defaultValue string) string {
@@ -76,3 +267,73 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration reads a Go duration such as "72h" or "30m", falling back to
+// the default when the variable is unset or malformed.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvInterval reads how often a background job runs, like getEnvDuration
+// but also falling back to the default for zero or less, which a ticker
+// cannot run at.
+func getEnvInterval(key string, defaultValue time.Duration) time.Duration {
+	d := getEnvDuration(key, defaultValue)
+	if d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvInt reads a positive integer, falling back to the default when the
+// variable is unset or malformed.
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool reads a boolean such as "true" or "0", falling back to the
+// default when the variable is unset or malformed.
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvSameSite reads a cookie SameSite mode: "lax", "strict" or "none".
+func getEnvSameSite(key string, defaultValue http.SameSite) http.SameSite {
+	switch strings.ToLower(os.Getenv(key)) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return defaultValue
+	}
+}
+
+// getEnvList reads a comma-separated list, dropping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
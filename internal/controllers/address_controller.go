@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type AddressController struct {
+	addressService services.AddressService
+}
+
+func NewAddressController(addressService services.AddressService) *AddressController {
+	return &AddressController{addressService: addressService}
+}
+
+// @Summary List saved addresses
+// @Description Get the customer's address book, default address first
+// @Tags addresses
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/addresses [get]
+func (c *AddressController) GetAddresses(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	addresses, err := c.addressService.GetAddresses(ctx, customerID.(uint))
+	if err != nil {
+		log.Error().Err(err).Uint("customerID", customerID.(uint)).Msg("Failed to fetch addresses")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch addresses")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, addresses)
+}
+
+// @Summary Add an address
+// @Description Add an address to the address book; the first one becomes the default
+// @Tags addresses
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param address body services.AddressRequest true "Address data"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/addresses [post]
+func (c *AddressController) CreateAddress(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	var req services.AddressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid address creation request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	address, err := c.addressService.CreateAddress(ctx, customerID.(uint), &req)
+	if err != nil {
+		c.handleAddressError(ctx, err, customerID.(uint), "failed to create address")
+		return
+	}
+
+	log.Info().Uint("addressID", address.ID).Uint("customerID", customerID.(uint)).Msg("Address created successfully")
+	responses.SuccessResponse(ctx, http.StatusCreated, address)
+}
+
+// @Summary Update an address
+// @Tags addresses
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Address ID"
+// @Param address body services.AddressRequest true "Address data"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/addresses/{id} [put]
+func (c *AddressController) UpdateAddress(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, ok := addressID(ctx)
+	if !ok {
+		return
+	}
+
+	var req services.AddressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid address update request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	address, err := c.addressService.UpdateAddress(ctx, customerID.(uint), id, &req)
+	if err != nil {
+		c.handleAddressError(ctx, err, customerID.(uint), "failed to update address")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, address)
+}
+
+// @Summary Delete an address
+// @Tags addresses
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Success 204
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/addresses/{id} [delete]
+func (c *AddressController) DeleteAddress(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, ok := addressID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.addressService.DeleteAddress(ctx, customerID.(uint), id); err != nil {
+		c.handleAddressError(ctx, err, customerID.(uint), "failed to delete address")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Make an address the default
+// @Tags addresses
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Success 204
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/addresses/{id}/default [post]
+func (c *AddressController) SetDefaultAddress(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, ok := addressID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.addressService.SetDefaultAddress(ctx, customerID.(uint), id); err != nil {
+		c.handleAddressError(ctx, err, customerID.(uint), "failed to set default address")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Quote delivery to an address
+// @Description Price delivering a basket of the given weight to a saved address
+// @Tags addresses
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Address ID"
+// @Param weight_kg query number false "Basket weight in kg" default(0)
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 422 {object} responses.ErrorResponse
+// @Router /api/v1/addresses/{id}/delivery-quote [get]
+func (c *AddressController) QuoteDelivery(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, ok := addressID(ctx)
+	if !ok {
+		return
+	}
+
+	weight, err := strconv.ParseFloat(ctx.DefaultQuery("weight_kg", "0"), 64)
+	if err != nil || weight < 0 {
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid weight")
+		return
+	}
+
+	quote, err := c.addressService.QuoteDelivery(ctx, customerID.(uint), id, weight)
+	if err != nil {
+		c.handleAddressError(ctx, err, customerID.(uint), "failed to quote delivery")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, quote)
+}
+
+func addressID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid address ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid address ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (c *AddressController) handleAddressError(ctx *gin.Context, err error, customerID uint, message string) {
+	switch {
+	case errors.Is(err, models.ErrAddressNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrUnknownCounty):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrNoDeliveryZone), errors.Is(err, models.ErrDeliveryOverweight):
+		responses.ErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error())
+	default:
+		log.Error().Err(err).Uint("customerID", customerID).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
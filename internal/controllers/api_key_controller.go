@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type APIKeyController struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+// @Summary List API keys
+// @Description Service account API keys, newest first. Revoked keys are left out unless asked for. Staff only.
+// @Tags admin-api-keys
+// @Security BearerAuth
+// @Produce  json
+// @Param include_revoked query bool false "Include revoked keys"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/api-keys [get]
+func (c *APIKeyController) ListKeys(ctx *gin.Context) {
+	includeRevoked, _ := strconv.ParseBool(ctx.Query("include_revoked"))
+
+	keys, err := c.apiKeyService.ListKeys(ctx, includeRevoked)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list API keys")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch API keys")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, keys)
+}
+
+// @Summary Create an API key
+// @Description Issue a key to a service account. The key is only shown in this response. Staff only.
+// @Tags admin-api-keys
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param key body services.APIKeyCreateRequest true "Service account name, scopes and lifetime"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/api-keys [post]
+func (c *APIKeyController) CreateKey(ctx *gin.Context) {
+	staffID, _ := ctx.Get("customerID")
+
+	var req services.APIKeyCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid API key request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	issued, err := c.apiKeyService.CreateKey(ctx, staffID.(uint), &req)
+	if err != nil {
+		c.handleAPIKeyError(ctx, err, "failed to create API key")
+		return
+	}
+
+	log.Info().Uint("apiKeyID", issued.APIKey.ID).Uint("staffID", staffID.(uint)).Msg("API key created")
+	responses.SuccessResponse(ctx, http.StatusCreated, issued)
+}
+
+// @Summary Rotate an API key
+// @Description Issue a replacement key with the same name and scopes. The old key keeps working for the grace period, if any. Staff only.
+// @Tags admin-api-keys
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "API key ID"
+// @Param rotation body services.APIKeyRotateRequest false "Lifetime of the new key and grace period of the old one"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/api-keys/{id}/rotate [post]
+func (c *APIKeyController) RotateKey(ctx *gin.Context) {
+	staffID, _ := ctx.Get("customerID")
+	id, ok := apiKeyIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req services.APIKeyRotateRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Warn().Err(err).Msg("Invalid API key rotation")
+			responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	issued, err := c.apiKeyService.RotateKey(ctx, staffID.(uint), id, &req)
+	if err != nil {
+		c.handleAPIKeyError(ctx, err, "failed to rotate API key")
+		return
+	}
+
+	log.Info().Uint("apiKeyID", id).Uint("replacementID", issued.APIKey.ID).Uint("staffID", staffID.(uint)).Msg("API key rotated")
+	responses.SuccessResponse(ctx, http.StatusCreated, issued)
+}
+
+// @Summary Revoke an API key
+// @Description Stop a key working straight away. Staff only.
+// @Tags admin-api-keys
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "API key ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/api-keys/{id}/revoke [post]
+func (c *APIKeyController) RevokeKey(ctx *gin.Context) {
+	staffID, _ := ctx.Get("customerID")
+	id, ok := apiKeyIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	key, err := c.apiKeyService.RevokeKey(ctx, id)
+	if err != nil {
+		c.handleAPIKeyError(ctx, err, "failed to revoke API key")
+		return
+	}
+
+	log.Info().Uint("apiKeyID", id).Uint("staffID", staffID.(uint)).Msg("API key revoked")
+	responses.SuccessResponse(ctx, http.StatusOK, key)
+}
+
+func (c *APIKeyController) handleAPIKeyError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrAPIKeyNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrUnknownScope):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrAPIKeyRetired):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
+
+func apiKeyIDParam(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid API key ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid API key ID")
+		return 0, false
+	}
+	return uint(id), true
+}
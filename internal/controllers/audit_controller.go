@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type AuditController struct {
+	auditService services.AuditService
+}
+
+func NewAuditController(auditService services.AuditService) *AuditController {
+	return &AuditController{auditService: auditService}
+}
+
+// @Summary Search the audit trail
+// @Description Rows created, updated and deleted, newest first, with who made each change, the columns it changed and the request it came from. Admin only.
+// @Tags admin-audit
+// @Security BearerAuth
+// @Produce  json
+// @Param entity query string false "Table, e.g. products"
+// @Param entity_id query string false "Primary key of the row"
+// @Param actor_type query string false "Who made the change" Enums(customer, api_key, system)
+// @Param actor_id query int false "Customer or API key ID"
+// @Param request_id query string false "X-Request-ID of the request that made the change"
+// @Param from query string false "On or after this date (YYYY-MM-DD)"
+// @Param to query string false "On or before this date (YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/audit [get]
+func (c *AuditController) SearchEvents(ctx *gin.Context) {
+	var req services.AuditEventSearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid audit trail search")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid search parameters")
+		return
+	}
+
+	events, total, err := c.auditService.SearchEvents(ctx, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search the audit trail")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch audit events")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, events, total, req.Page, req.Limit)
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type AuthAuditController struct {
+	auditService services.AuthAuditService
+}
+
+func NewAuthAuditController(auditService services.AuthAuditService) *AuthAuditController {
+	return &AuthAuditController{auditService: auditService}
+}
+
+// @Summary Search the auth audit log
+// @Description Sign-ins, failed sign-ins and role changes, newest first, with the anomaly rules each one tripped. Staff only.
+// @Tags admin-auth-events
+// @Security BearerAuth
+// @Produce  json
+// @Param type query string false "Event type" Enums(login, login_failed, token_refresh, logout, role_change)
+// @Param customer_id query int false "Customer ID"
+// @Param ip query string false "Client IP address"
+// @Param from query string false "On or after this date (YYYY-MM-DD)"
+// @Param to query string false "On or before this date (YYYY-MM-DD)"
+// @Param alerted query bool false "Only events that tripped an anomaly rule"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/auth-events [get]
+func (c *AuthAuditController) SearchEvents(ctx *gin.Context) {
+	var req services.AuthEventSearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid auth event search")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid search parameters")
+		return
+	}
+
+	events, total, err := c.auditService.SearchEvents(ctx, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search auth events")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch auth events")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, events, total, req.Page, req.Limit)
+}
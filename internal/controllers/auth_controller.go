@@ -1,26 +1,43 @@
 package controllers
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/services"
 	"github.com/Mutonya/Savanah/internal/utils/responses"
+	"github.com/Mutonya/Savanah/pkg/oauth2"
 )
 
+// loginCookie carries the sealed oauth2.LoginState from /auth/login to the
+// callback.
+const loginCookie = "oauthlogin"
+
+// LoginCookie sets the flags of the login state cookie. Secure should only
+// be off for local development over plain HTTP. SameSite must allow the
+// provider's redirect back to the callback: Lax, or None with Secure.
+type LoginCookie struct {
+	Secure   bool
+	SameSite http.SameSite
+}
+
 type AuthController struct {
-	authService services.AuthService //Bussiness Logic Interface
+	authService services.AuthService      //Bussiness Logic Interface
+	audit       services.AuthAuditService // records failed sign-ins
+	states      *oauth2.StateCodec        // seals the login state into the cookie
+	cookie      LoginCookie
 }
 
 // Constructor for AuthController
 // initializes the controller with an `AuthService`.
 // Dependency Injection through a constructor
-func NewAuthController(authService services.AuthService) *AuthController {
-	return &AuthController{authService: authService}
+func NewAuthController(authService services.AuthService, audit services.AuthAuditService, states *oauth2.StateCodec, cookie LoginCookie) *AuthController {
+	return &AuthController{authService: authService, audit: audit, states: states, cookie: cookie}
 }
 
 // Getter for authService (primarily for testing this getter is for test access)
@@ -28,80 +45,99 @@ func (c *AuthController) AuthService() services.AuthService {
 	return c.authService
 }
 
-// generateRandomState creates a base64-encoded random state string for OAuth2.
-// Generates a random 16-byte sequence and encodes it in base64.
-// This state is used in OAuth2 to prevent Cross Site Request Fogery attacks
-func generateRandomState() string {
-	b := make([]byte, 16)                       // Create 16-byte buffer
-	_, _ = rand.Read(b)                         // Fill with cryptographically secure random bytes
-	return base64.URLEncoding.EncodeToString(b) // Return URL-safe base64 encoded string
+// Lists the identity providers customers can sign in with, the default first,
+// so the client can offer a button for each /auth/login/:provider
+func (c *AuthController) Providers(ctx *gin.Context) {
+	responses.SuccessResponse(ctx, http.StatusOK, gin.H{"providers": c.authService.Providers()})
 }
 
 // Initiates the OAuth2 flow by redirecting the user to the OAuth provider's login page.
-// generate the Base64 token  and store it into the cookie then get the url from the service and redirect
+// The provider comes from the path; /auth/login without one uses the default provider.
+// The state, nonce and PKCE code verifier are sealed into a short-lived
+// cookie that the callback checks the provider's response against; the
+// state prevents Cross Site Request Forgery, the nonce replayed ID tokens
+// and PKCE intercepted codes.
 func (c *AuthController) Login(ctx *gin.Context) {
-	// Generate secure random state
-	state := generateRandomState()
+	authURL, login, err := c.authService.BeginLogin(ctx.Param("provider"))
+	if err != nil {
+		c.handleAuthError(ctx, err)
+		return
+	}
 
-	// Store state in a cookie
-	http.SetCookie(ctx.Writer, &http.Cookie{
-		Name:     "oauthstate", // Cookie name
-		Value:    state,
-		HttpOnly: true,  // Prevent JavaScript access
-		Secure:   false, // Set to true in production (HTTPS) Cross Site Scripting Security
-		Path:     "/",   // Accessible to all paths
-		MaxAge:   30000, // 5 minutes this is also part of security
-	})
-	// Get OAuth authorization URL from service
-	authURL := c.authService.GetAuthCodeURL(state)
-	log.Info().Str("state", state).Msg("Redirecting to OAuth provider")
+	sealed, err := c.states.Seal(login)
+	if err != nil {
+		c.handleAuthError(ctx, err)
+		return
+	}
+	c.setLoginCookie(ctx, sealed, int(c.states.TTL().Seconds()))
+
+	log.Info().Str("provider", login.Provider).Msg("Redirecting to OAuth provider")
 	// Perform HTTP redirect
 	ctx.Redirect(http.StatusTemporaryRedirect, authURL)
 }
 
 func (c *AuthController) Callback(ctx *gin.Context) {
+	// The login state is single use, whatever the outcome
+	cookie, cookieErr := ctx.Request.Cookie(loginCookie)
+	c.setLoginCookie(ctx, "", -1)
+	provider := ctx.Param("provider")
+
+	// The provider reports failures, e.g. the user declining, with an error
+	if providerError := ctx.Query("error"); providerError != "" {
+		log.Warn().Str("error", providerError).Str("description", ctx.Query("error_description")).Msg("Identity provider returned an error")
+		c.callbackFailed(ctx, provider, models.ErrAuthProviderError)
+		return
+	}
+
 	// Validate state parameter
 	stateFromQuery := ctx.Query("state")
 	if stateFromQuery == "" {
-		log.Warn().Msg("Callback attempt without state parameter")
-		responses.ErrorResponse(ctx, http.StatusBadRequest, "state parameter is required")
+		c.callbackFailed(ctx, provider, models.ErrAuthStateMissing)
 		return
 	}
 
-	// Get state from cookie
-	cookie, err := ctx.Request.Cookie("oauthstate")
-	if err != nil || cookie.Value != stateFromQuery {
-		log.Warn().
-			Str("expected", func() string {
-				if cookie != nil {
-					return cookie.Value
-				}
-				return ""
-			}()).
-			Str("received", stateFromQuery).
-			Msg("Invalid or missing state")
-		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid state parameter")
+	// Get the login state from the cookie
+	if cookieErr != nil || cookie.Value == "" {
+		c.callbackFailed(ctx, provider, models.ErrAuthSessionMissing)
+		return
+	}
+	login, err := c.states.Open(cookie.Value)
+	switch {
+	case errors.Is(err, oauth2.ErrStateExpired):
+		c.callbackFailed(ctx, provider, models.ErrAuthSessionExpired)
+		return
+	case err != nil:
+		c.callbackFailed(ctx, provider, models.ErrAuthSessionInvalid)
+		return
+	}
+
+	// The callback must be for the provider and login that were started
+	if provider != "" && provider != login.Provider {
+		c.callbackFailed(ctx, provider, models.ErrAuthStateMismatch)
+		return
+	}
+	provider = login.Provider
+	if subtle.ConstantTimeCompare([]byte(stateFromQuery), []byte(login.State)) != 1 {
+		c.callbackFailed(ctx, provider, models.ErrAuthStateMismatch)
 		return
 	}
 
 	// Validate code
 	code := ctx.Query("code")
 	if code == "" {
-		log.Warn().Msg("Callback attempt without code parameter")
-		responses.ErrorResponse(ctx, http.StatusBadRequest, "code parameter is required")
+		c.callbackFailed(ctx, provider, models.ErrAuthCodeMissing)
 		return
 	}
 
 	// Exchange code for tokens and authenticate user
 	// Authenticate user with service
-	customer, accessToken, err := c.authService.Authenticate(ctx.Request.Context(), code)
+	customer, accessToken, err := c.authService.Authenticate(ctx.Request.Context(), login, code, clientInfo(ctx))
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to authenticate user")
-		responses.ErrorResponse(ctx, http.StatusInternalServerError, "authentication failed")
+		c.callbackFailed(ctx, provider, err)
 		return
 	}
 
-	log.Info().Str("email", customer.Email).Msg("User authenticated successfully")
+	log.Info().Str("email", customer.Email).Str("provider", login.Provider).Msg("User authenticated successfully")
 	// Return success response with user data and token
 	responses.SuccessResponse(ctx, http.StatusOK, gin.H{
 		"customer":    customer,
@@ -109,6 +145,90 @@ func (c *AuthController) Callback(ctx *gin.Context) {
 	})
 }
 
+// callbackFailed records a failed sign-in in the audit log and responds
+// with the error.
+func (c *AuthController) callbackFailed(ctx *gin.Context, provider string, err error) {
+	if c.audit != nil {
+		client := clientInfo(ctx)
+		c.audit.Record(ctx.Request.Context(), &models.AuthEvent{
+			Type:      models.AuthEventLoginFailed,
+			Provider:  provider,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+			Reason:    authFailureReason(err),
+		})
+	}
+	c.handleAuthError(ctx, err)
+}
+
+// authFailureReason is the reason a failed sign-in is logged with: the
+// callback's reason code where it has one.
+func authFailureReason(err error) string {
+	var authErr *models.AuthError
+	switch {
+	case errors.As(err, &authErr):
+		return authErr.Code
+	case errors.Is(err, models.ErrUnknownProvider):
+		return "unknown_provider"
+	case errors.Is(err, models.ErrUnverifiedEmail):
+		return "email_unverified"
+	case errors.Is(err, models.ErrEmailNotVerified):
+		return "email_not_verified"
+	default:
+		return "internal_error"
+	}
+}
+
+// clientInfo is where the request came from, for the audit log.
+func clientInfo(ctx *gin.Context) services.ClientInfo {
+	return services.ClientInfo{IP: ctx.ClientIP(), UserAgent: ctx.Request.UserAgent()}
+}
+
+// setLoginCookie stores the sealed login state, or clears it when maxAge is
+// negative.
+func (c *AuthController) setLoginCookie(ctx *gin.Context, value string, maxAge int) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     loginCookie,
+		Value:    value,
+		Path:     "/auth",
+		MaxAge:   maxAge,
+		HttpOnly: true, // Prevent JavaScript access
+		Secure:   c.cookie.Secure,
+		SameSite: c.cookie.SameSite,
+	})
+}
+
+func (c *AuthController) handleAuthError(ctx *gin.Context, err error) {
+	var authErr *models.AuthError
+	switch {
+	case errors.As(err, &authErr):
+		log.Warn().Err(err).Str("reason", authErr.Code).Msg("Sign-in failed")
+		responses.ReasonErrorResponse(ctx, authErrorStatus(authErr), authErr.Code, authErr.Message)
+	case errors.Is(err, models.ErrUnknownProvider):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrUnverifiedEmail):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, models.ErrEmailNotVerified):
+		responses.ErrorResponse(ctx, http.StatusForbidden, err.Error())
+	default:
+		log.Error().Err(err).Msg("Failed to authenticate user")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "authentication failed")
+	}
+}
+
+// authErrorStatus is 401 when the provider or its tokens turned the sign-in
+// down, and 400 when the callback request itself does not match a sign-in in
+// progress.
+func authErrorStatus(err *models.AuthError) int {
+	switch err {
+	case models.ErrAuthProviderError, models.ErrAuthCodeExchange,
+		models.ErrAuthInvalidIDToken, models.ErrAuthNonceMismatch:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadRequest
+	}
+}
+
 // User Profile Handler
 func (c *AuthController) Profile(ctx *gin.Context) {
 	// Get customer ID from context (set by auth middleware)
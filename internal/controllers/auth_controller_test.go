@@ -10,14 +10,18 @@ import (
 
 	"github.com/Mutonya/Savanah/internal/domain/services"
 	"github.com/Mutonya/Savanah/internal/utils/responses"
+	"github.com/Mutonya/Savanah/pkg/oauth2"
 )
 
+// TestAuthController keeps login states in memory, keyed by state, instead
+// of in a sealed cookie.
 type TestAuthController struct {
 	authService services.AuthService
+	logins      map[string]*oauth2.LoginState
 }
 
 func NewAuthControllerTest(authService services.AuthService) *TestAuthController {
-	return &TestAuthController{authService: authService}
+	return &TestAuthController{authService: authService, logins: map[string]*oauth2.LoginState{}}
 }
 
 // AuthService getter
@@ -40,10 +44,15 @@ func generateTestRandomState() string {
 // @Param state query string true "State parameter for CSRF protection"
 // @Success 302 {string} string "Redirect to OAuth provider"
 // @Failure 400 {object} responses.ErrorResponse
-// @Router /auth/login [get]
+// @Router /auth/login/{provider} [get]
 func (c *TestAuthController) Login(ctx *gin.Context) {
-	// Generate secure random state
-	state := generateTestRandomState()
+	authURL, login, err := c.authService.BeginLogin(ctx.Param("provider"))
+	if err != nil {
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		return
+	}
+	state := login.State
+	c.logins[state] = login
 
 	// Store state in a cookie
 	http.SetCookie(ctx.Writer, &http.Cookie{
@@ -55,7 +64,6 @@ func (c *TestAuthController) Login(ctx *gin.Context) {
 		MaxAge:   300, // 5 minutes
 	})
 
-	authURL := c.authService.GetAuthCodeURL(state)
 	log.Info().Str("state", state).Msg("Redirecting to OAuth provider")
 	ctx.Redirect(http.StatusTemporaryRedirect, authURL)
 }
@@ -69,7 +77,7 @@ func (c *TestAuthController) Login(ctx *gin.Context) {
 // @Success 200 {object} responses.SuccessResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /auth/callback [get]
+// @Router /auth/callback/{provider} [get]
 func (c *TestAuthController) Callback(ctx *gin.Context) {
 	// Validate state parameter
 	stateFromQuery := ctx.Query("state")
@@ -104,7 +112,7 @@ func (c *TestAuthController) Callback(ctx *gin.Context) {
 	}
 
 	// Authenticate user with service
-	customer, accessToken, err := c.authService.Authenticate(ctx.Request.Context(), code)
+	customer, accessToken, err := c.authService.Authenticate(ctx.Request.Context(), c.logins[stateFromQuery], code, services.ClientInfo{IP: ctx.ClientIP(), UserAgent: ctx.Request.UserAgent()})
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to authenticate user")
 		responses.ErrorResponse(ctx, http.StatusInternalServerError, "authentication failed")
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type CartController struct {
+	cartService services.CartService
+}
+
+func NewCartController(cartService services.CartService) *CartController {
+	return &CartController{cartService: cartService}
+}
+
+// @Summary Get the cart
+// @Description Get the current customer's cart re-priced against the catalogue
+// @Tags cart
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/cart [get]
+func (c *CartController) GetCart(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	cart, err := c.cartService.GetCart(ctx, customerID.(uint))
+	if err != nil {
+		log.Error().Err(err).Uint("customerID", customerID.(uint)).Msg("Failed to fetch cart")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch cart")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, cart)
+}
+
+// @Summary Add an item to the cart
+// @Description Add a product to the cart, or increase its quantity if already present
+// @Tags cart
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param item body services.CartItemRequest true "Cart item"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/cart/items [post]
+func (c *CartController) AddItem(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	var req services.CartItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid add to cart request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	cart, err := c.cartService.AddItem(ctx, customerID.(uint), &req)
+	if err != nil {
+		c.handleCartError(ctx, err, customerID.(uint), "failed to add item to cart")
+		return
+	}
+
+	log.Info().Uint("customerID", customerID.(uint)).Uint("productID", req.ProductID).Msg("Item added to cart")
+	responses.SuccessResponse(ctx, http.StatusOK, cart)
+}
+
+// @Summary Update a cart item quantity
+// @Tags cart
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param itemId path int true "Cart item ID"
+// @Param item body services.CartItemUpdateRequest true "New quantity"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/cart/items/{itemId} [put]
+func (c *CartController) UpdateItem(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	itemID, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		log.Warn().Str("itemId", ctx.Param("itemId")).Msg("Invalid cart item ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid cart item ID")
+		return
+	}
+
+	var req services.CartItemUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid cart item update request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	cart, err := c.cartService.UpdateItem(ctx, customerID.(uint), uint(itemID), &req)
+	if err != nil {
+		c.handleCartError(ctx, err, customerID.(uint), "failed to update cart item")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, cart)
+}
+
+// @Summary Remove a cart item
+// @Tags cart
+// @Security BearerAuth
+// @Produce  json
+// @Param itemId path int true "Cart item ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/cart/items/{itemId} [delete]
+func (c *CartController) RemoveItem(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	itemID, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		log.Warn().Str("itemId", ctx.Param("itemId")).Msg("Invalid cart item ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid cart item ID")
+		return
+	}
+
+	cart, err := c.cartService.RemoveItem(ctx, customerID.(uint), uint(itemID))
+	if err != nil {
+		c.handleCartError(ctx, err, customerID.(uint), "failed to remove cart item")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, cart)
+}
+
+// @Summary Empty the cart
+// @Tags cart
+// @Security BearerAuth
+// @Success 204
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/cart [delete]
+func (c *CartController) ClearCart(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	if err := c.cartService.ClearCart(ctx, customerID.(uint)); err != nil {
+		log.Error().Err(err).Uint("customerID", customerID.(uint)).Msg("Failed to clear cart")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to clear cart")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Check out the cart
+// @Description Create an order from the cart using current catalogue prices
+// @Tags cart
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param checkout body services.CartCheckoutRequest false "Checkout options"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 422 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/cart/checkout [post]
+func (c *CartController) Checkout(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	var req services.CartCheckoutRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Warn().Err(err).Msg("Invalid checkout request")
+			responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	order, err := c.cartService.Checkout(ctx, customerID.(uint), &req)
+	if err != nil {
+		c.handleCartError(ctx, err, customerID.(uint), "failed to check out cart")
+		return
+	}
+
+	log.Info().Uint("orderID", order.ID).Uint("customerID", customerID.(uint)).Msg("Cart checked out successfully")
+	responses.SuccessResponse(ctx, http.StatusCreated, order)
+}
+
+// @Summary Re-order a past order
+// @Description Put a past order's items in the cart, or place them as a new order, at current prices. The response flags items that are unavailable or have changed price.
+// @Tags cart
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param reorder body services.ReorderRequest false "Target and checkout options"
+// @Success 200 {object} responses.SuccessResponse
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 422 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/orders/{id}/reorder [post]
+func (c *CartController) Reorder(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+
+	var req services.ReorderRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Warn().Err(err).Msg("Invalid reorder request")
+			responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	result, err := c.cartService.Reorder(ctx, customerID.(uint), uint(id), &req)
+	if err != nil {
+		c.handleCartError(ctx, err, customerID.(uint), "failed to re-order")
+		return
+	}
+
+	status := http.StatusOK
+	if result.Order != nil {
+		status = http.StatusCreated
+	}
+	log.Info().Uint("orderID", uint(id)).Str("target", result.Target).Bool("changes", result.HasChanges).Msg("Order re-ordered")
+	responses.SuccessResponse(ctx, status, result)
+}
+
+func (c *CartController) handleCartError(ctx *gin.Context, err error, customerID uint, message string) {
+	if status, ok := checkoutErrorStatus(err); ok {
+		responses.ErrorResponse(ctx, status, err.Error())
+		return
+	}
+
+	switch {
+	case errors.Is(err, models.ErrCartItemNotFound), errors.Is(err, models.ErrCartNotFound),
+		errors.Is(err, models.ErrOrderNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, "product not found")
+	case errors.Is(err, models.ErrCartEmpty):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrCartUnavailable), errors.Is(err, models.ErrCartPricesChanged),
+		errors.Is(err, models.ErrNothingToReorder):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		log.Error().Err(err).Uint("customerID", customerID).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
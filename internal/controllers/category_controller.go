@@ -27,7 +27,7 @@ func (c *CategoryController) CreateCategory(ctx *gin.Context) {
 		return
 	}
 
-	category, err := c.categoryService.CreateCategory(&req)
+	category, err := c.categoryService.CreateCategory(ctx, &req)
 	if err != nil {
 		log.Error().Err(err).Interface("request", req).Msg("Failed to create category")
 		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to create category")
@@ -125,7 +125,7 @@ func (c *CategoryController) UpdateCategory(ctx *gin.Context) {
 		return
 	}
 
-	category, err := c.categoryService.UpdateCategory(uint(id), &req)
+	category, err := c.categoryService.UpdateCategory(ctx, uint(id), &req)
 	if err != nil {
 		log.Error().Err(err).Uint("categoryID", uint(id)).Msg("Failed to update category")
 		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to update category")
@@ -144,7 +144,7 @@ func (c *CategoryController) DeleteCategory(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.categoryService.DeleteCategory(uint(id)); err != nil {
+	if err := c.categoryService.DeleteCategory(ctx, uint(id)); err != nil {
 		log.Error().Err(err).Uint("categoryID", uint(id)).Msg("Failed to delete category")
 		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to delete category")
 		return
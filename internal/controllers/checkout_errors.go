@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// checkoutErrorStatus maps the reasons an order can be refused at checkout,
+// such as a coupon, stock or delivery problem, to an HTTP status. The second result
+// is false for other errors.
+func checkoutErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, models.ErrCouponNotFound), errors.Is(err, models.ErrAddressNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, models.ErrCouponExhausted),
+		errors.Is(err, models.ErrCouponCustomerLimit),
+		errors.Is(err, models.ErrInsufficientStock):
+		return http.StatusConflict, true
+	case errors.Is(err, models.ErrCouponInactive),
+		errors.Is(err, models.ErrCouponNotStarted),
+		errors.Is(err, models.ErrCouponExpired),
+		errors.Is(err, models.ErrCouponMinBasket),
+		errors.Is(err, models.ErrCouponNotApplicable),
+		errors.Is(err, models.ErrShippingAddressRequired),
+		errors.Is(err, models.ErrNoDeliveryZone),
+		errors.Is(err, models.ErrDeliveryOverweight):
+		return http.StatusUnprocessableEntity, true
+	}
+	return 0, false
+}
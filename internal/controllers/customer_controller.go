@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type CustomerController struct {
+	customerService services.CustomerService
+}
+
+func NewCustomerController(customerService services.CustomerService) *CustomerController {
+	return &CustomerController{customerService: customerService}
+}
+
+// @Summary Search customers
+// @Description List customers with their order count, lifetime value and last order date. Staff only.
+// @Tags admin-customers
+// @Security BearerAuth
+// @Produce  json
+// @Param q query string false "Part of the name, email or phone"
+// @Param from query string false "Signed up on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Signed up on or before this date (YYYY-MM-DD)"
+// @Param min_orders query int false "Minimum number of orders"
+// @Param max_orders query int false "Maximum number of orders"
+// @Param status query string false "Account status" Enums(active, suspended)
+// @Param sort query string false "Sort order" Enums(created_at, -created_at, orders, -orders, lifetime_value, -lifetime_value)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/customers [get]
+func (c *CustomerController) SearchCustomers(ctx *gin.Context) {
+	var req services.CustomerSearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid customer search")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid search parameters")
+		return
+	}
+
+	customers, total, err := c.customerService.SearchCustomers(ctx, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search customers")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch customers")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, customers, total, req.Page, req.Limit)
+}
+
+// @Summary Get a customer
+// @Description A customer with their order stats and latest orders. Staff only.
+// @Tags admin-customers
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/customers/{id} [get]
+func (c *CustomerController) GetCustomer(ctx *gin.Context) {
+	id, ok := customerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	customer, err := c.customerService.GetCustomer(ctx, id)
+	if err != nil {
+		c.handleCustomerError(ctx, err, "failed to fetch customer")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, customer)
+}
+
+// @Summary Suspend a customer
+// @Description Shut a customer out of the API until they are reactivated. Staff only.
+// @Tags admin-customers
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Customer ID"
+// @Param suspension body services.CustomerSuspendRequest true "Why the account is suspended"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/customers/{id}/suspend [post]
+func (c *CustomerController) SuspendCustomer(ctx *gin.Context) {
+	staffID, _ := ctx.Get("customerID")
+	id, ok := customerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req services.CustomerSuspendRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid suspension request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	customer, err := c.customerService.SuspendCustomer(ctx, staffID.(uint), id, &req)
+	if err != nil {
+		c.handleCustomerError(ctx, err, "failed to suspend customer")
+		return
+	}
+
+	log.Info().Uint("customerID", id).Uint("staffID", staffID.(uint)).Msg("Customer suspended")
+	responses.SuccessResponse(ctx, http.StatusOK, customer)
+}
+
+// @Summary Reactivate a customer
+// @Description Lift a customer's suspension. Staff only.
+// @Tags admin-customers
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/customers/{id}/reactivate [post]
+func (c *CustomerController) ReactivateCustomer(ctx *gin.Context) {
+	staffID, _ := ctx.Get("customerID")
+	id, ok := customerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	customer, err := c.customerService.ReactivateCustomer(ctx, id)
+	if err != nil {
+		c.handleCustomerError(ctx, err, "failed to reactivate customer")
+		return
+	}
+
+	log.Info().Uint("customerID", id).Uint("staffID", staffID.(uint)).Msg("Customer reactivated")
+	responses.SuccessResponse(ctx, http.StatusOK, customer)
+}
+
+// @Summary Change a customer's role
+// @Description Make a customer a customer, staff member or admin. The change is recorded in the auth audit log. Admin only.
+// @Tags admin-customers
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Customer ID"
+// @Param role body services.CustomerRoleRequest true "The new role"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/customers/{id}/role [put]
+func (c *CustomerController) ChangeRole(ctx *gin.Context) {
+	adminID, _ := ctx.Get("customerID")
+	id, ok := customerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req services.CustomerRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid role change")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	customer, err := c.customerService.ChangeRole(ctx, adminID.(uint), id, &req, clientInfo(ctx))
+	if err != nil {
+		c.handleCustomerError(ctx, err, "failed to change role")
+		return
+	}
+
+	log.Info().Uint("customerID", id).Uint("adminID", adminID.(uint)).Str("role", req.Role).Msg("Customer role changed")
+	responses.SuccessResponse(ctx, http.StatusOK, customer)
+}
+
+func (c *CustomerController) handleCustomerError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrCustomerNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrSuspendSelf),
+		errors.Is(err, models.ErrChangeOwnRole),
+		errors.Is(err, models.ErrInvalidRole):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrCustomerAlreadySuspended),
+		errors.Is(err, models.ErrCustomerNotSuspended),
+		errors.Is(err, models.ErrRoleUnchanged):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
+
+func customerIDParam(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid customer ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid customer ID")
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type DeliveryController struct {
+	deliveryService services.DeliveryService
+}
+
+func NewDeliveryController(deliveryService services.DeliveryService) *DeliveryController {
+	return &DeliveryController{deliveryService: deliveryService}
+}
+
+// @Summary Create a delivery zone
+// @Description Create a zone with the counties or towns it covers and its fee per weight band
+// @Tags delivery
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param zone body services.DeliveryZoneRequest true "Zone data"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/delivery-zones [post]
+func (c *DeliveryController) CreateZone(ctx *gin.Context) {
+	var req services.DeliveryZoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid delivery zone request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	zone, err := c.deliveryService.CreateZone(ctx, &req)
+	if err != nil {
+		c.handleZoneError(ctx, err, "failed to create delivery zone")
+		return
+	}
+
+	log.Info().Uint("zoneID", zone.ID).Str("name", zone.Name).Msg("Delivery zone created successfully")
+	responses.SuccessResponse(ctx, http.StatusCreated, zone)
+}
+
+// @Summary List delivery zones
+// @Tags delivery
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/delivery-zones [get]
+func (c *DeliveryController) GetZones(ctx *gin.Context) {
+	zones, err := c.deliveryService.GetZones(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch delivery zones")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch delivery zones")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, zones)
+}
+
+// @Summary Get a delivery zone
+// @Tags delivery
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Zone ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Router /api/v1/admin/delivery-zones/{id} [get]
+func (c *DeliveryController) GetZone(ctx *gin.Context) {
+	id, ok := zoneID(ctx)
+	if !ok {
+		return
+	}
+
+	zone, err := c.deliveryService.GetZone(ctx, id)
+	if err != nil {
+		c.handleZoneError(ctx, err, "failed to fetch delivery zone")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, zone)
+}
+
+// @Summary Update a delivery zone
+// @Description Rename a zone and replace its areas and rates
+// @Tags delivery
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Zone ID"
+// @Param zone body services.DeliveryZoneRequest true "Zone data"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/delivery-zones/{id} [put]
+func (c *DeliveryController) UpdateZone(ctx *gin.Context) {
+	id, ok := zoneID(ctx)
+	if !ok {
+		return
+	}
+
+	var req services.DeliveryZoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid delivery zone request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	zone, err := c.deliveryService.UpdateZone(ctx, id, &req)
+	if err != nil {
+		c.handleZoneError(ctx, err, "failed to update delivery zone")
+		return
+	}
+
+	log.Info().Uint("zoneID", zone.ID).Msg("Delivery zone updated successfully")
+	responses.SuccessResponse(ctx, http.StatusOK, zone)
+}
+
+// @Summary Delete a delivery zone
+// @Tags delivery
+// @Security BearerAuth
+// @Param id path int true "Zone ID"
+// @Success 204
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/delivery-zones/{id} [delete]
+func (c *DeliveryController) DeleteZone(ctx *gin.Context) {
+	id, ok := zoneID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.deliveryService.DeleteZone(ctx, id); err != nil {
+		c.handleZoneError(ctx, err, "failed to delete delivery zone")
+		return
+	}
+
+	log.Info().Uint("zoneID", id).Msg("Delivery zone deleted successfully")
+	ctx.Status(http.StatusNoContent)
+}
+
+func zoneID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid delivery zone ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid delivery zone ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (c *DeliveryController) handleZoneError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrDeliveryZoneNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrUnknownCounty):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		responses.ErrorResponse(ctx, http.StatusConflict, "zone name or area already in use")
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
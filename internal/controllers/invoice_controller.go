@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type InvoiceController struct {
+	invoiceService services.InvoiceService
+}
+
+func NewInvoiceController(invoiceService services.InvoiceService) *InvoiceController {
+	return &InvoiceController{invoiceService: invoiceService}
+}
+
+// @Summary Download an order's tax invoice
+// @Description Render the order's tax invoice as a PDF, issuing the next invoice number the first time
+// @Tags orders
+// @Security BearerAuth
+// @Produce  application/pdf
+// @Param id path int true "Order ID"
+// @Success 200 {file} file
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/orders/{id}/invoice.pdf [get]
+func (c *InvoiceController) GetOrderInvoice(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+
+	invoice, pdf, err := c.invoiceService.GetOrderInvoice(ctx, customerID.(uint), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrderNotFound):
+			responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrOrderNotInvoiceable):
+			responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Uint("orderID", uint(id)).Msg("Failed to generate invoice")
+			responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to generate invoice")
+		}
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", invoice.Number+".pdf"))
+	ctx.Data(http.StatusOK, "application/pdf", pdf)
+}
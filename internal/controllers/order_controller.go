@@ -1,12 +1,17 @@
 package controllers
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/services"
 	"github.com/Mutonya/Savanah/internal/utils/responses"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type OrderController struct {
@@ -30,6 +35,9 @@ func NewOrderController(orderService services.OrderService, notificationService
 // @Param order body services.OrderCreateRequest true "Order data"
 // @Success 201 {object} responses.SuccessResponse
 // @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 422 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/v1/orders [post]
 func (c *OrderController) CreateOrder(ctx *gin.Context) {
@@ -43,6 +51,11 @@ func (c *OrderController) CreateOrder(ctx *gin.Context) {
 	}
 
 	order, err := c.orderService.CreateOrder(ctx, customerID.(uint), &req)
+	if status, ok := checkoutErrorStatus(err); ok {
+		log.Warn().Err(err).Str("coupon", req.CouponCode).Msg("Order rejected")
+		responses.ErrorResponse(ctx, status, err.Error())
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Uint("customerID", customerID.(uint)).Msg("Failed to create order")
 		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to create order")
@@ -117,6 +130,35 @@ func (c *OrderController) GetOrder(ctx *gin.Context) {
 	responses.SuccessResponse(ctx, http.StatusOK, order)
 }
 
+// @Summary Get an order by reference
+// @Description Look up one of the customer's orders by its reference, e.g. SAV-7K3Q-92XD
+// @Tags orders
+// @Security BearerAuth
+// @Produce  json
+// @Param reference path string true "Order reference"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/orders/ref/{reference} [get]
+func (c *OrderController) GetOrderByReference(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	reference := ctx.Param("reference")
+
+	order, err := c.orderService.GetOrderByReference(ctx, customerID.(uint), reference)
+	if errors.Is(err, models.ErrOrderNotFound) {
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reference", reference).Msg("Failed to fetch order")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch order")
+		return
+	}
+
+	log.Info().Uint("orderID", order.ID).Msg("Order fetched successfully")
+	responses.SuccessResponse(ctx, http.StatusOK, order)
+}
+
 // @Summary Update order status
 // @Description Update the status of an existing order
 // @Tags orders
@@ -127,7 +169,9 @@ func (c *OrderController) GetOrder(ctx *gin.Context) {
 // @Param status body services.OrderStatusUpdateRequest true "Status data"
 // @Success 200 {object} responses.SuccessResponse
 // @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/v1/orders/{id}/status [put]
 func (c *OrderController) UpdateOrderStatus(ctx *gin.Context) {
@@ -147,8 +191,15 @@ func (c *OrderController) UpdateOrderStatus(ctx *gin.Context) {
 
 	order, err := c.orderService.UpdateOrderStatus(ctx, uint(id), req.Status)
 	if err != nil {
-		log.Error().Err(err).Uint("orderID", uint(id)).Msg("Failed to update order status")
-		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to update order status")
+		switch {
+		case errors.Is(err, models.ErrOrderNotFound):
+			responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrInvalidStatusTransition), errors.Is(err, models.ErrOrderNotCancellable):
+			responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Uint("orderID", uint(id)).Msg("Failed to update order status")
+			responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to update order status")
+		}
 		return
 	}
 
@@ -161,3 +212,195 @@ func (c *OrderController) UpdateOrderStatus(ctx *gin.Context) {
 	log.Info().Uint("orderID", uint(id)).Str("status", string(req.Status)).Msg("Order status updated successfully")
 	responses.SuccessResponse(ctx, http.StatusOK, order)
 }
+
+// @Summary Cancel an order
+// @Description Cancel an order that has not shipped yet. Items go back in stock and paid orders are refunded.
+// @Tags orders
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param cancel body services.OrderCancelRequest false "Cancellation reason"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/orders/{id}/cancel [post]
+func (c *OrderController) CancelOrder(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+
+	var req services.OrderCancelRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Warn().Err(err).Msg("Invalid order cancellation request")
+			responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	order, err := c.orderService.CancelOrder(ctx, customerID.(uint), uint(id), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrderNotFound):
+			responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrOrderNotCancellable):
+			responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Uint("orderID", uint(id)).Msg("Failed to cancel order")
+			responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to cancel order")
+		}
+		return
+	}
+
+	log.Info().Uint("orderID", order.ID).Uint("customerID", customerID.(uint)).Msg("Order cancelled")
+	responses.SuccessResponse(ctx, http.StatusOK, order)
+}
+
+// @Summary List all orders
+// @Description Search orders across all customers (staff and admin only)
+// @Tags admin-orders
+// @Security BearerAuth
+// @Produce  json
+// @Param status query string false "Filter by status" Enums(pending, paid, shipped, completed, cancelled, returned)
+// @Param from query string false "Placed on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Placed on or before this date (YYYY-MM-DD)"
+// @Param customer_id query int false "Filter by customer"
+// @Param min_total query number false "Minimum order total"
+// @Param product_id query int false "Orders containing this product"
+// @Param sort query string false "Sort order" Enums(created_at, -created_at, total, -total, status, -status)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/orders [get]
+func (c *OrderController) SearchOrders(ctx *gin.Context) {
+	var req services.OrderSearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid order search")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid search parameters")
+		return
+	}
+
+	orders, total, err := c.orderService.SearchOrders(ctx, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search orders")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch orders")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, orders, total, req.Page, req.Limit)
+}
+
+// @Summary Export orders as CSV
+// @Description Download every order matching the same filters as the order listing
+// @Tags admin-orders
+// @Security BearerAuth
+// @Produce  text/csv
+// @Param status query string false "Filter by status"
+// @Param from query string false "Placed on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Placed on or before this date (YYYY-MM-DD)"
+// @Param customer_id query int false "Filter by customer"
+// @Param min_total query number false "Minimum order total"
+// @Param product_id query int false "Orders containing this product"
+// @Param sort query string false "Sort order"
+// @Success 200 {file} file
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/orders/export [get]
+func (c *OrderController) ExportOrders(ctx *gin.Context) {
+	var req services.OrderSearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid order export")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid search parameters")
+		return
+	}
+
+	orders, err := c.orderService.ExportOrders(ctx, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to export orders")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to export orders")
+		return
+	}
+
+	filename := fmt.Sprintf("orders-%s.csv", time.Now().Format("20060102-150405"))
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Status(http.StatusOK)
+
+	w := csv.NewWriter(ctx.Writer)
+	_ = w.Write([]string{
+		"reference", "order_id", "placed_at", "status", "customer_id", "customer_name", "customer_email",
+		"items", "subtotal", "discount_total", "net_total", "tax_total", "delivery_fee", "total", "county",
+	})
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	for _, o := range orders {
+		items := 0
+		for _, item := range o.OrderItems {
+			items += item.Quantity
+		}
+		_ = w.Write([]string{
+			o.Reference,
+			strconv.FormatUint(uint64(o.ID), 10),
+			o.CreatedAt.Format(time.RFC3339),
+			string(o.Status),
+			strconv.FormatUint(uint64(o.CustomerID), 10),
+			o.Customer.FirstName + " " + o.Customer.LastName,
+			o.Customer.Email,
+			strconv.Itoa(items),
+			money(o.Subtotal),
+			money(o.DiscountTotal),
+			money(o.NetTotal),
+			money(o.TaxTotal),
+			money(o.DeliveryFee),
+			money(o.Total),
+			o.ShippingAddress.County,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Error().Err(err).Msg("Failed to write order export")
+	}
+
+	log.Info().Int("count", len(orders)).Msg("Orders exported")
+}
+
+// @Summary Update the status of several orders
+// @Description Move each order to the status under the same rules and notifications as a single update. Every order gets its own result.
+// @Tags admin-orders
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param update body services.BulkOrderStatusRequest true "Orders and their new status"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Router /api/v1/admin/orders/status [post]
+func (c *OrderController) BulkUpdateOrderStatus(ctx *gin.Context) {
+	var req services.BulkOrderStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid bulk status update request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	results := c.orderService.BulkUpdateOrderStatus(ctx, &req)
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	log.Info().Str("status", string(req.Status)).Int("updated", len(results)-failed).Int("failed", failed).Msg("Bulk order status update")
+	responses.SuccessResponse(ctx, http.StatusOK, results)
+}
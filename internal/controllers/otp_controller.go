@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+// OTPController serves the verify and resend endpoints shared by every flow
+// that texts the customer a code. Each purpose's verifier finishes its flow.
+type OTPController struct {
+	otpService services.OTPService
+	verifiers  map[models.OTPPurpose]services.OTPVerifier
+}
+
+func NewOTPController(otpService services.OTPService, verifiers map[models.OTPPurpose]services.OTPVerifier) *OTPController {
+	return &OTPController{otpService: otpService, verifiers: verifiers}
+}
+
+// @Summary Verify a one-time code
+// @Description Enter the code texted for a purpose, e.g. phone_verification. Failures carry a reason: otp_not_found, otp_expired, otp_invalid or otp_too_many_attempts.
+// @Tags otp
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param purpose path string true "What the code is for" Enums(phone_verification)
+// @Param verification body services.OTPVerifyRequest true "Six-digit code"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 410 {object} responses.ErrorResponse
+// @Failure 429 {object} responses.ErrorResponse
+// @Router /api/v1/otp/{purpose}/verify [post]
+func (c *OTPController) Verify(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	purpose := models.OTPPurpose(ctx.Param("purpose"))
+	verifier, ok := c.verifiers[purpose]
+	if !ok {
+		responses.ErrorResponse(ctx, http.StatusNotFound, "unknown code purpose")
+		return
+	}
+
+	var req services.OTPVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid code verification request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	result, err := verifier.VerifyOTP(ctx, customerID.(uint), req.Code)
+	if err != nil {
+		if status, reason, ok := otpErrorStatus(err); ok {
+			log.Warn().Uint("customerID", customerID.(uint)).Str("purpose", string(purpose)).Str("reason", reason).Msg("Code verification failed")
+			responses.ReasonErrorResponse(ctx, status, reason, err.Error())
+			return
+		}
+		log.Error().Err(err).Str("purpose", string(purpose)).Msg("Failed to verify code")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to verify code")
+		return
+	}
+
+	log.Info().Uint("customerID", customerID.(uint)).Str("purpose", string(purpose)).Msg("Code verified")
+	responses.SuccessResponse(ctx, http.StatusOK, result)
+}
+
+// @Summary Resend a one-time code
+// @Description Text a new code to the number the last one for this purpose went to. Refusals carry a reason: otp_not_found, otp_resend_too_soon or otp_resend_limit.
+// @Tags otp
+// @Security BearerAuth
+// @Produce  json
+// @Param purpose path string true "What the code is for" Enums(phone_verification)
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 429 {object} responses.ErrorResponse
+// @Router /api/v1/otp/{purpose}/resend [post]
+func (c *OTPController) Resend(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	purpose := models.OTPPurpose(ctx.Param("purpose"))
+	if _, ok := c.verifiers[purpose]; !ok {
+		responses.ErrorResponse(ctx, http.StatusNotFound, "unknown code purpose")
+		return
+	}
+
+	challenge, err := c.otpService.Resend(ctx, purpose, models.CustomerOTPSubject(customerID.(uint)))
+	if err != nil {
+		if status, reason, ok := otpErrorStatus(err); ok {
+			responses.ReasonErrorResponse(ctx, status, reason, err.Error())
+			return
+		}
+		log.Error().Err(err).Str("purpose", string(purpose)).Msg("Failed to resend code")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to resend code")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, challenge)
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// otpErrorStatus maps a one-time code failure to an HTTP status and its
+// reason code. The last result is false for other errors.
+func otpErrorStatus(err error) (int, string, bool) {
+	var otpErr *models.OTPError
+	if !errors.As(err, &otpErr) {
+		return 0, "", false
+	}
+	switch otpErr {
+	case models.ErrOTPNotFound:
+		return http.StatusNotFound, otpErr.Code, true
+	case models.ErrOTPExpired:
+		return http.StatusGone, otpErr.Code, true
+	case models.ErrOTPInvalid:
+		return http.StatusBadRequest, otpErr.Code, true
+	default:
+		// Too many attempts or codes requested
+		return http.StatusTooManyRequests, otpErr.Code, true
+	}
+}
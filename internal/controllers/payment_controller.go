@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+	"github.com/Mutonya/Savanah/pkg/mpesa"
+	"github.com/Mutonya/Savanah/pkg/payments"
+)
+
+type PaymentController struct {
+	paymentService services.PaymentService
+}
+
+func NewPaymentController(paymentService services.PaymentService) *PaymentController {
+	return &PaymentController{paymentService: paymentService}
+}
+
+// @Summary Pay for an order
+// @Description Start a payment for a pending order with the given provider, e.g. an M-Pesa STK Push prompt
+// @Tags payments
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param provider path string true "Payment provider" Enums(mpesa)
+// @Param payment body services.PaymentInitiateRequest false "Payer details"
+// @Success 202 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 502 {object} responses.ErrorResponse
+// @Router /api/v1/orders/{id}/payments/{provider} [post]
+func (c *PaymentController) InitiatePayment(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+	provider := ctx.Param("provider")
+
+	var req services.PaymentInitiateRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Warn().Err(err).Msg("Invalid payment request")
+			responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	payment, err := c.paymentService.InitiatePayment(ctx, customerID.(uint), uint(id), provider, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrderNotFound), errors.Is(err, models.ErrUnknownPaymentProvider):
+			responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		case errors.Is(err, mpesa.ErrInvalidPhone):
+			responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, models.ErrOrderNotPayable), errors.Is(err, models.ErrPaymentInProgress):
+			responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Uint("orderID", uint(id)).Str("provider", provider).Msg("Failed to start payment")
+			responses.ErrorResponse(ctx, http.StatusBadGateway, "failed to start payment")
+		}
+		return
+	}
+
+	log.Info().Uint("orderID", uint(id)).Str("provider", provider).Str("reference", payment.ProviderReference).Msg("Payment initiated")
+	responses.SuccessResponse(ctx, http.StatusAccepted, payment)
+}
+
+// @Summary List payments for an order
+// @Tags payments
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Router /api/v1/orders/{id}/payments [get]
+func (c *PaymentController) GetOrderPayments(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+
+	payments, err := c.paymentService.GetOrderPayments(ctx, customerID.(uint), uint(id))
+	if err != nil {
+		log.Error().Err(err).Uint("orderID", uint(id)).Msg("Failed to fetch order payments")
+		responses.ErrorResponse(ctx, http.StatusNotFound, "order not found")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, payments)
+}
+
+// @Summary Refund an order
+// @Description Refund a cancelled, shipped, completed or returned order in full or in part
+// @Tags payments
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param refund body services.RefundCreateRequest true "Amount (omit for everything refundable) and reason"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 502 {object} responses.ErrorResponse
+// @Router /api/v1/admin/orders/{id}/refunds [post]
+func (c *PaymentController) RefundOrder(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+
+	var req services.RefundCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid refund request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	refund, err := c.paymentService.RefundOrder(ctx, uint(id), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrderNotFound):
+			responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		case errors.Is(err, payments.ErrInvalidAmount):
+			responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, models.ErrOrderNotRefundable),
+			errors.Is(err, models.ErrNothingToRefund),
+			errors.Is(err, models.ErrRefundExceedsPayment):
+			responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Uint("orderID", uint(id)).Msg("Failed to refund order")
+			responses.ErrorResponse(ctx, http.StatusBadGateway, "failed to refund order")
+		}
+		return
+	}
+
+	log.Info().Uint("orderID", uint(id)).Float64("amount", refund.Amount).Str("status", string(refund.Status)).Msg("Refund issued")
+	responses.SuccessResponse(ctx, http.StatusCreated, refund)
+}
+
+// MpesaCallback receives the STK Push result from Daraja. The route is not
+// behind AuthMiddleware; it is guarded by middleware.CallbackGuard instead.
+// Daraja only needs an acknowledgement, so failures are logged rather than
+// returned, except for bodies that are not a callback at all.
+func (c *PaymentController) MpesaCallback(ctx *gin.Context) {
+	var callback mpesa.Callback
+	if err := ctx.ShouldBindJSON(&callback); err != nil || callback.Body.STKCallback.CheckoutRequestID == "" {
+		log.Warn().Err(err).Msg("Invalid M-Pesa callback payload")
+		ctx.JSON(http.StatusBadRequest, mpesa.CallbackAck{ResultCode: 1, ResultDesc: "Rejected"})
+		return
+	}
+
+	stk := callback.Body.STKCallback
+	if err := c.paymentService.HandlePaymentResult(ctx, payments.ProviderMpesa, payments.MpesaCallbackResult(&stk)); err != nil {
+		log.Error().Err(err).Str("checkoutRequestID", stk.CheckoutRequestID).Msg("Failed to process M-Pesa callback")
+	} else {
+		log.Info().Str("checkoutRequestID", stk.CheckoutRequestID).Int("resultCode", stk.ResultCode).Msg("M-Pesa callback processed")
+	}
+
+	ctx.JSON(http.StatusOK, mpesa.CallbackAck{ResultCode: 0, ResultDesc: "Accepted"})
+}
+
+// MpesaReversalResult receives the outcome of an M-Pesa reversal (refund).
+// Like MpesaCallback it is guarded by middleware.CallbackGuard.
+func (c *PaymentController) MpesaReversalResult(ctx *gin.Context) {
+	var callback mpesa.ResultCallback
+	if err := ctx.ShouldBindJSON(&callback); err != nil || callback.Result.ConversationID == "" {
+		log.Warn().Err(err).Msg("Invalid M-Pesa reversal result payload")
+		ctx.JSON(http.StatusBadRequest, mpesa.CallbackAck{ResultCode: 1, ResultDesc: "Rejected"})
+		return
+	}
+
+	if err := c.paymentService.HandleRefundResult(ctx, payments.MpesaReversalResult(&callback)); err != nil {
+		log.Error().Err(err).Str("conversationID", callback.Result.ConversationID).Msg("Failed to process M-Pesa reversal result")
+	} else {
+		log.Info().Str("conversationID", callback.Result.ConversationID).Int("resultCode", callback.Result.ResultCode).Msg("M-Pesa reversal result processed")
+	}
+
+	ctx.JSON(http.StatusOK, mpesa.CallbackAck{ResultCode: 0, ResultDesc: "Accepted"})
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type ProfileController struct {
+	profileService services.ProfileService
+}
+
+func NewProfileController(profileService services.ProfileService) *ProfileController {
+	return &ProfileController{profileService: profileService}
+}
+
+// @Summary Update my profile
+// @Description Change name, address or phone. A new phone number is texted a code and replaces the current one once verified through /api/v1/otp/phone_verification/verify.
+// @Tags profile
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param profile body services.ProfileUpdateRequest true "Fields to change"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 429 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/profile [patch]
+func (c *ProfileController) UpdateProfile(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	var req services.ProfileUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid profile update request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	result, err := c.profileService.UpdateProfile(ctx, customerID.(uint), &req)
+	if err != nil {
+		c.handleProfileError(ctx, err, "failed to update profile")
+		return
+	}
+
+	log.Info().Uint("customerID", customerID.(uint)).Bool("phonePending", result.PhoneVerification != nil).Msg("Profile updated")
+	responses.SuccessResponse(ctx, http.StatusOK, result)
+}
+
+// @Summary List my sign-in identities
+// @Description The external accounts, e.g. Google or Microsoft, the signed-in customer can sign in with
+// @Tags profile
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/profile/identities [get]
+func (c *ProfileController) GetIdentities(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	identities, err := c.profileService.GetIdentities(ctx, customerID.(uint))
+	if err != nil {
+		c.handleProfileError(ctx, err, "failed to fetch identities")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, identities)
+}
+
+// @Summary Export my data
+// @Description Download everything held about the signed-in customer as a JSON file: profile, sign-in identities, address book, orders and the log of SMS and email sent to them.
+// @Tags profile
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} services.DataExport
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/profile/export [get]
+func (c *ProfileController) ExportData(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	export, err := c.profileService.ExportData(ctx, customerID.(uint))
+	if err != nil {
+		c.handleProfileError(ctx, err, "failed to export data")
+		return
+	}
+
+	body, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		c.handleProfileError(ctx, err, "failed to export data")
+		return
+	}
+
+	log.Info().Uint("customerID", customerID.(uint)).Msg("Customer data exported")
+	filename := fmt.Sprintf("savannah-data-%d-%s.json", customerID.(uint), export.ExportedAt.Format("20060102"))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// @Summary Erase my account
+// @Description Permanently anonymise the signed-in customer's personal data and sign them out everywhere. Orders are kept, without personal details, for accounting; the account cannot be erased while an order is still pending, paid or shipped.
+// @Tags profile
+// @Security BearerAuth
+// @Success 204
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/profile [delete]
+func (c *ProfileController) EraseAccount(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	if err := c.profileService.EraseAccount(ctx, customerID.(uint)); err != nil {
+		c.handleProfileError(ctx, err, "failed to erase account")
+		return
+	}
+
+	log.Info().Uint("customerID", customerID.(uint)).Msg("Customer data erased")
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *ProfileController) handleProfileError(ctx *gin.Context, err error, message string) {
+	if status, reason, ok := otpErrorStatus(err); ok {
+		responses.ReasonErrorResponse(ctx, status, reason, err.Error())
+		return
+	}
+
+	switch {
+	case errors.Is(err, models.ErrInvalidPhone):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrErasureBlocked):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type PromotionController struct {
+	promotionService services.PromotionService
+}
+
+func NewPromotionController(promotionService services.PromotionService) *PromotionController {
+	return &PromotionController{promotionService: promotionService}
+}
+
+// @Summary Create a coupon
+// @Description Create a discount code, optionally restricted to categories or products
+// @Tags promotions
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param coupon body services.CouponRequest true "Coupon data"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/coupons [post]
+func (c *PromotionController) CreateCoupon(ctx *gin.Context) {
+	var req services.CouponRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid coupon creation request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	coupon, err := c.promotionService.CreateCoupon(ctx, &req)
+	if err != nil {
+		c.handleCouponError(ctx, err, "failed to create coupon")
+		return
+	}
+
+	log.Info().Uint("couponID", coupon.ID).Str("code", coupon.Code).Msg("Coupon created successfully")
+	responses.SuccessResponse(ctx, http.StatusCreated, coupon)
+}
+
+// @Summary List coupons
+// @Tags promotions
+// @Security BearerAuth
+// @Produce  json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/coupons [get]
+func (c *PromotionController) GetCoupons(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	coupons, total, err := c.promotionService.GetCoupons(ctx, page, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch coupons")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch coupons")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, coupons, total, page, limit)
+}
+
+// @Summary Get a coupon
+// @Tags promotions
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Coupon ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Router /api/v1/admin/coupons/{id} [get]
+func (c *PromotionController) GetCoupon(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid coupon ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid coupon ID")
+		return
+	}
+
+	coupon, err := c.promotionService.GetCoupon(ctx, uint(id))
+	if err != nil {
+		log.Error().Err(err).Uint("couponID", uint(id)).Msg("Failed to fetch coupon")
+		responses.ErrorResponse(ctx, http.StatusNotFound, "coupon not found")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, coupon)
+}
+
+// @Summary Update a coupon
+// @Description Replace a coupon's settings and restrictions; its usage count is kept
+// @Tags promotions
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Coupon ID"
+// @Param coupon body services.CouponRequest true "Coupon data"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/coupons/{id} [put]
+func (c *PromotionController) UpdateCoupon(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid coupon ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid coupon ID")
+		return
+	}
+
+	var req services.CouponRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid coupon update request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	coupon, err := c.promotionService.UpdateCoupon(ctx, uint(id), &req)
+	if err != nil {
+		c.handleCouponError(ctx, err, "failed to update coupon")
+		return
+	}
+
+	log.Info().Uint("couponID", coupon.ID).Msg("Coupon updated successfully")
+	responses.SuccessResponse(ctx, http.StatusOK, coupon)
+}
+
+// @Summary Delete a coupon
+// @Tags promotions
+// @Security BearerAuth
+// @Param id path int true "Coupon ID"
+// @Success 204
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/coupons/{id} [delete]
+func (c *PromotionController) DeleteCoupon(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid coupon ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid coupon ID")
+		return
+	}
+
+	if err := c.promotionService.DeleteCoupon(ctx, uint(id)); err != nil {
+		log.Error().Err(err).Uint("couponID", uint(id)).Msg("Failed to delete coupon")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to delete coupon")
+		return
+	}
+
+	log.Info().Uint("couponID", uint(id)).Msg("Coupon deleted successfully")
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *PromotionController) handleCouponError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, "coupon, category or product not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		responses.ErrorResponse(ctx, http.StatusConflict, "coupon code already exists")
+	case errors.Is(err, models.ErrCouponInvalidValue):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type ReturnController struct {
+	returnService services.ReturnService
+}
+
+func NewReturnController(returnService services.ReturnService) *ReturnController {
+	return &ReturnController{returnService: returnService}
+}
+
+// @Summary Request a return
+// @Description Ask to send back some or all items of a shipped or completed order
+// @Tags returns
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param return body services.ReturnCreateRequest true "Items and reasons"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/orders/{id}/returns [post]
+func (c *ReturnController) RequestReturn(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+
+	var req services.ReturnCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid return request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	rma, err := c.returnService.RequestReturn(ctx, customerID.(uint), uint(id), &req)
+	if err != nil {
+		c.handleReturnError(ctx, err, "failed to request return")
+		return
+	}
+
+	log.Info().Uint("returnID", rma.ID).Uint("orderID", rma.OrderID).Msg("Return requested")
+	responses.SuccessResponse(ctx, http.StatusCreated, rma)
+}
+
+// @Summary List returns for an order
+// @Tags returns
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Router /api/v1/orders/{id}/returns [get]
+func (c *ReturnController) GetOrderReturns(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid order ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid order ID")
+		return
+	}
+
+	rmas, err := c.returnService.GetOrderReturns(ctx, customerID.(uint), uint(id))
+	if err != nil {
+		c.handleReturnError(ctx, err, "failed to fetch returns")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, rmas)
+}
+
+// @Summary List returns
+// @Description List return requests for staff, oldest first
+// @Tags returns
+// @Security BearerAuth
+// @Produce  json
+// @Param status query string false "Filter by status" Enums(requested, approved, rejected, received)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/returns [get]
+func (c *ReturnController) GetReturns(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	status := models.ReturnStatus(ctx.Query("status"))
+
+	rmas, total, err := c.returnService.GetReturns(ctx, status, page, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch returns")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch returns")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, rmas, total, page, limit)
+}
+
+// @Summary Approve a return
+// @Tags returns
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Return ID"
+// @Param review body services.ReturnReviewRequest false "Note for the customer"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Router /api/v1/admin/returns/{id}/approve [post]
+func (c *ReturnController) ApproveReturn(ctx *gin.Context) {
+	c.review(ctx, c.returnService.ApproveReturn, "approve")
+}
+
+// @Summary Reject a return
+// @Tags returns
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Return ID"
+// @Param review body services.ReturnReviewRequest false "Note for the customer"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Router /api/v1/admin/returns/{id}/reject [post]
+func (c *ReturnController) RejectReturn(ctx *gin.Context) {
+	c.review(ctx, c.returnService.RejectReturn, "reject")
+}
+
+// @Summary Confirm a return was received
+// @Description Restock the returned items and refund them
+// @Tags returns
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Return ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Router /api/v1/admin/returns/{id}/receive [post]
+func (c *ReturnController) ReceiveReturn(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid return ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid return ID")
+		return
+	}
+
+	rma, err := c.returnService.ReceiveReturn(ctx, uint(id))
+	if err != nil {
+		c.handleReturnError(ctx, err, "failed to receive return")
+		return
+	}
+
+	log.Info().Uint("returnID", rma.ID).Float64("refund", rma.RefundAmount).Msg("Return received")
+	responses.SuccessResponse(ctx, http.StatusOK, rma)
+}
+
+func (c *ReturnController) review(
+	ctx *gin.Context,
+	action func(ctx context.Context, id uint, req *services.ReturnReviewRequest) (*models.ReturnRequest, error),
+	verb string,
+) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid return ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid return ID")
+		return
+	}
+
+	var req services.ReturnReviewRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Warn().Err(err).Msg("Invalid return review request")
+			responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	rma, err := action(ctx, uint(id), &req)
+	if err != nil {
+		c.handleReturnError(ctx, err, "failed to "+verb+" return")
+		return
+	}
+
+	log.Info().Uint("returnID", rma.ID).Str("status", string(rma.Status)).Msg("Return reviewed")
+	responses.SuccessResponse(ctx, http.StatusOK, rma)
+}
+
+func (c *ReturnController) handleReturnError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrOrderNotFound), errors.Is(err, models.ErrReturnNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrReturnItemInvalid):
+		responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrOrderNotReturnable),
+		errors.Is(err, models.ErrReturnQuantityExceeded),
+		errors.Is(err, models.ErrReturnStatus):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type ReviewController struct {
+	reviewService services.ReviewService
+}
+
+func NewReviewController(reviewService services.ReviewService) *ReviewController {
+	return &ReviewController{reviewService: reviewService}
+}
+
+// @Summary Review a product
+// @Description Rate and review a product from a completed order; the review is published once staff approve it
+// @Tags reviews
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Param review body services.ReviewCreateRequest true "Rating and review"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/products/{id}/reviews [post]
+func (c *ReviewController) CreateReview(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid product ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid product ID")
+		return
+	}
+
+	var req services.ReviewCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid review request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	review, err := c.reviewService.CreateReview(ctx, customerID.(uint), uint(id), &req)
+	if err != nil {
+		c.handleReviewError(ctx, err, "failed to create review")
+		return
+	}
+
+	log.Info().Uint("reviewID", review.ID).Uint("productID", review.ProductID).Msg("Review submitted")
+	responses.SuccessResponse(ctx, http.StatusCreated, review)
+}
+
+// @Summary List a product's reviews
+// @Description List approved reviews, most helpful first
+// @Tags reviews
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/products/{id}/reviews [get]
+func (c *ReviewController) GetProductReviews(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid product ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid product ID")
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	reviews, total, err := c.reviewService.GetProductReviews(ctx, uint(id), page, limit)
+	if err != nil {
+		c.handleReviewError(ctx, err, "failed to fetch reviews")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, reviews, total, page, limit)
+}
+
+// @Summary List my reviews
+// @Description List the customer's own reviews in every status
+// @Tags reviews
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/reviews [get]
+func (c *ReviewController) GetMyReviews(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	reviews, err := c.reviewService.GetCustomerReviews(ctx, customerID.(uint))
+	if err != nil {
+		log.Error().Err(err).Uint("customerID", customerID.(uint)).Msg("Failed to fetch reviews")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch reviews")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, reviews)
+}
+
+// @Summary Delete my review
+// @Tags reviews
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Success 204
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/reviews/{id} [delete]
+func (c *ReviewController) DeleteReview(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, ok := reviewID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.reviewService.DeleteReview(ctx, customerID.(uint), id); err != nil {
+		c.handleReviewError(ctx, err, "failed to delete review")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Mark a review helpful
+// @Tags reviews
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Review ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/reviews/{id}/helpful [post]
+func (c *ReviewController) MarkHelpful(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	id, ok := reviewID(ctx)
+	if !ok {
+		return
+	}
+
+	review, err := c.reviewService.MarkHelpful(ctx, customerID.(uint), id)
+	if err != nil {
+		c.handleReviewError(ctx, err, "failed to vote on review")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, review)
+}
+
+// @Summary List reviews for moderation
+// @Description List reviews for staff, oldest first
+// @Tags reviews
+// @Security BearerAuth
+// @Produce  json
+// @Param status query string false "Filter by status" Enums(pending, approved, rejected)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responses.PaginatedResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/reviews [get]
+func (c *ReviewController) GetReviews(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	status := models.ReviewStatus(ctx.Query("status"))
+
+	reviews, total, err := c.reviewService.GetReviews(ctx, status, page, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch reviews")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch reviews")
+		return
+	}
+
+	responses.PaginatedResponse(ctx, http.StatusOK, reviews, total, page, limit)
+}
+
+// @Summary Approve a review
+// @Tags reviews
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Review ID"
+// @Param moderation body services.ReviewModerationRequest false "Moderation note"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Router /api/v1/admin/reviews/{id}/approve [post]
+func (c *ReviewController) ApproveReview(ctx *gin.Context) {
+	c.moderate(ctx, c.reviewService.ApproveReview, "approve")
+}
+
+// @Summary Reject a review
+// @Description Reject a pending review or take down an approved one
+// @Tags reviews
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Review ID"
+// @Param moderation body services.ReviewModerationRequest false "Moderation note"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Router /api/v1/admin/reviews/{id}/reject [post]
+func (c *ReviewController) RejectReview(ctx *gin.Context) {
+	c.moderate(ctx, c.reviewService.RejectReview, "reject")
+}
+
+func (c *ReviewController) moderate(
+	ctx *gin.Context,
+	action func(ctx context.Context, id uint, req *services.ReviewModerationRequest) (*models.Review, error),
+	verb string,
+) {
+	id, ok := reviewID(ctx)
+	if !ok {
+		return
+	}
+
+	var req services.ReviewModerationRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Warn().Err(err).Msg("Invalid review moderation request")
+			responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	review, err := action(ctx, id, &req)
+	if err != nil {
+		c.handleReviewError(ctx, err, "failed to "+verb+" review")
+		return
+	}
+
+	log.Info().Uint("reviewID", review.ID).Str("status", string(review.Status)).Msg("Review moderated")
+	responses.SuccessResponse(ctx, http.StatusOK, review)
+}
+
+func (c *ReviewController) handleReviewError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, "product not found")
+	case errors.Is(err, models.ErrReviewNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrNotVerifiedPurchaser):
+		responses.ErrorResponse(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, models.ErrReviewExists),
+		errors.Is(err, models.ErrReviewStatus),
+		errors.Is(err, models.ErrOwnReview),
+		errors.Is(err, models.ErrAlreadyVoted):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
+
+func reviewID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid review ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid review ID")
+		return 0, false
+	}
+	return uint(id), true
+}
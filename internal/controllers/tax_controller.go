@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type TaxController struct {
+	taxService services.TaxService
+}
+
+func NewTaxController(taxService services.TaxService) *TaxController {
+	return &TaxController{taxService: taxService}
+}
+
+// @Summary Add a VAT rate version
+// @Description Assign a VAT treatment and rate to a category (or the store default) from a given date
+// @Tags tax
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param rate body services.TaxRateRequest true "Rate data"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/tax-rates [post]
+func (c *TaxController) CreateTaxRate(ctx *gin.Context) {
+	var req services.TaxRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid tax rate request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	rate, err := c.taxService.CreateTaxRate(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTaxRateTreatment):
+			responses.ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			responses.ErrorResponse(ctx, http.StatusNotFound, "category not found")
+		default:
+			log.Error().Err(err).Msg("Failed to create tax rate")
+			responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to create tax rate")
+		}
+		return
+	}
+
+	log.Info().Uint("taxRateID", rate.ID).Str("treatment", string(rate.Treatment)).Float64("rate", rate.Rate).Msg("Tax rate created successfully")
+	responses.SuccessResponse(ctx, http.StatusCreated, rate)
+}
+
+// @Summary List VAT rate versions
+// @Tags tax
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/tax-rates [get]
+func (c *TaxController) GetTaxRates(ctx *gin.Context) {
+	rates, err := c.taxService.GetTaxRates(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch tax rates")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch tax rates")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, rates)
+}
+
+// @Summary Withdraw a scheduled VAT rate
+// @Description Only rates that have not taken effect yet can be deleted
+// @Tags tax
+// @Security BearerAuth
+// @Param id path int true "Tax rate ID"
+// @Success 204
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/admin/tax-rates/{id} [delete]
+func (c *TaxController) DeleteTaxRate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Warn().Str("id", ctx.Param("id")).Msg("Invalid tax rate ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid tax rate ID")
+		return
+	}
+
+	if err := c.taxService.DeleteTaxRate(ctx, uint(id)); err != nil {
+		switch {
+		case errors.Is(err, models.ErrTaxRateNotFound):
+			responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrTaxRateInEffect):
+			responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Uint("taxRateID", uint(id)).Msg("Failed to delete tax rate")
+			responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to delete tax rate")
+		}
+		return
+	}
+
+	log.Info().Uint("taxRateID", uint(id)).Msg("Tax rate deleted successfully")
+	ctx.Status(http.StatusNoContent)
+}
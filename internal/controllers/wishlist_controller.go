@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+	"github.com/Mutonya/Savanah/internal/utils/responses"
+)
+
+type WishlistController struct {
+	wishlistService services.WishlistService
+}
+
+func NewWishlistController(wishlistService services.WishlistService) *WishlistController {
+	return &WishlistController{wishlistService: wishlistService}
+}
+
+// @Summary Get the wishlist
+// @Tags wishlist
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/wishlist [get]
+func (c *WishlistController) GetWishlist(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	items, err := c.wishlistService.GetWishlist(ctx, customerID.(uint))
+	if err != nil {
+		log.Error().Err(err).Uint("customerID", customerID.(uint)).Msg("Failed to fetch wishlist")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch wishlist")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, items)
+}
+
+// @Summary Add a product to the wishlist
+// @Tags wishlist
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param item body services.WishlistItemRequest true "Product"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/wishlist [post]
+func (c *WishlistController) AddToWishlist(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	var req services.WishlistItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid wishlist request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	items, err := c.wishlistService.AddToWishlist(ctx, customerID.(uint), &req)
+	if err != nil {
+		c.handleWishlistError(ctx, err, "failed to add to wishlist")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, items)
+}
+
+// @Summary Remove a product from the wishlist
+// @Tags wishlist
+// @Security BearerAuth
+// @Param productId path int true "Product ID"
+// @Success 204
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/wishlist/{productId} [delete]
+func (c *WishlistController) RemoveFromWishlist(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	productID, ok := productIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.wishlistService.RemoveFromWishlist(ctx, customerID.(uint), productID); err != nil {
+		c.handleWishlistError(ctx, err, "failed to remove from wishlist")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary List back-in-stock alerts
+// @Description List the alerts that have not been sent yet
+// @Tags wishlist
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/stock-alerts [get]
+func (c *WishlistController) GetStockAlerts(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	alerts, err := c.wishlistService.GetStockAlerts(ctx, customerID.(uint))
+	if err != nil {
+		log.Error().Err(err).Uint("customerID", customerID.(uint)).Msg("Failed to fetch stock alerts")
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, "failed to fetch stock alerts")
+		return
+	}
+
+	responses.SuccessResponse(ctx, http.StatusOK, alerts)
+}
+
+// @Summary Ask to be told when a product is back in stock
+// @Description Subscribe to one SMS or email when an out-of-stock product is restocked
+// @Tags wishlist
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param alert body services.StockAlertRequest true "Product and channel"
+// @Success 201 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/stock-alerts [post]
+func (c *WishlistController) SubscribeStockAlert(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+
+	var req services.StockAlertRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid stock alert request")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	alert, err := c.wishlistService.SubscribeStockAlert(ctx, customerID.(uint), &req)
+	if err != nil {
+		c.handleWishlistError(ctx, err, "failed to subscribe to stock alert")
+		return
+	}
+
+	log.Info().Uint("customerID", customerID.(uint)).Uint("productID", alert.ProductID).Str("channel", alert.Channel).Msg("Stock alert subscribed")
+	responses.SuccessResponse(ctx, http.StatusCreated, alert)
+}
+
+// @Summary Cancel a back-in-stock alert
+// @Tags wishlist
+// @Security BearerAuth
+// @Param productId path int true "Product ID"
+// @Success 204
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/v1/stock-alerts/{productId} [delete]
+func (c *WishlistController) UnsubscribeStockAlert(ctx *gin.Context) {
+	customerID, _ := ctx.Get("customerID")
+	productID, ok := productIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.wishlistService.UnsubscribeStockAlert(ctx, customerID.(uint), productID); err != nil {
+		c.handleWishlistError(ctx, err, "failed to cancel stock alert")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *WishlistController) handleWishlistError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, "product not found")
+	case errors.Is(err, models.ErrWishlistItemNotFound), errors.Is(err, models.ErrStockAlertNotFound):
+		responses.ErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrProductInStock):
+		responses.ErrorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		log.Error().Err(err).Msg(message)
+		responses.ErrorResponse(ctx, http.StatusInternalServerError, message)
+	}
+}
+
+func productIDParam(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("productId"))
+	if err != nil {
+		log.Warn().Str("productId", ctx.Param("productId")).Msg("Invalid product ID format")
+		responses.ErrorResponse(ctx, http.StatusBadRequest, "invalid product ID")
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAddressNotFound         = errors.New("address not found")
+	ErrShippingAddressRequired = errors.New("a shipping address is required")
+	ErrUnknownCounty           = errors.New("unknown county")
+)
+
+// Address is an entry in a customer's address book. At most one address per
+// customer is the default, used when an order does not name one.
+type Address struct {
+	gorm.Model
+	CustomerID    uint   `gorm:"not null;index"`
+	Label         string `gorm:"size:50;not null"`
+	RecipientName string `gorm:"size:200;not null" audit:"pii"`
+	Phone         string `gorm:"size:20;not null" audit:"pii"`
+	Line1         string `gorm:"size:255;not null" audit:"pii"`
+	Line2         string `gorm:"size:255" audit:"pii"`
+	Town          string `gorm:"size:100;not null"`
+	County        string `gorm:"size:50;not null"`
+	PostalCode    string `gorm:"size:20"`
+	IsDefault     bool   `gorm:"not null;default:false"`
+}
+
+// ShippingAddress is a copy of an address taken when an order is placed, so
+// later edits to the address book do not rewrite where past orders went.
+type ShippingAddress struct {
+	RecipientName string `gorm:"size:200" audit:"pii"`
+	Phone         string `gorm:"size:20" audit:"pii"`
+	Line1         string `gorm:"size:255" audit:"pii"`
+	Line2         string `gorm:"size:255" audit:"pii"`
+	Town          string `gorm:"size:100"`
+	County        string `gorm:"size:50"`
+	PostalCode    string `gorm:"size:20"`
+}
+
+func (a *Address) Snapshot() ShippingAddress {
+	return ShippingAddress{
+		RecipientName: a.RecipientName,
+		Phone:         a.Phone,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		Town:          a.Town,
+		County:        a.County,
+		PostalCode:    a.PostalCode,
+	}
+}
+
+// KenyanCounties are the 47 counties in the order of the First Schedule of
+// the Constitution.
+var KenyanCounties = []string{
+	"Mombasa", "Kwale", "Kilifi", "Tana River", "Lamu", "Taita Taveta",
+	"Garissa", "Wajir", "Mandera", "Marsabit", "Isiolo", "Meru",
+	"Tharaka-Nithi", "Embu", "Kitui", "Machakos", "Makueni", "Nyandarua",
+	"Nyeri", "Kirinyaga", "Murang'a", "Kiambu", "Turkana", "West Pokot",
+	"Samburu", "Trans Nzoia", "Uasin Gishu", "Elgeyo-Marakwet", "Nandi",
+	"Baringo", "Laikipia", "Nakuru", "Narok", "Kajiado", "Kericho", "Bomet",
+	"Kakamega", "Vihiga", "Bungoma", "Busia", "Siaya", "Kisumu", "Homa Bay",
+	"Migori", "Kisii", "Nyamira", "Nairobi",
+}
+
+// CanonicalCounty returns the official spelling of a county name, matching
+// case-insensitively and ignoring a trailing "County".
+func CanonicalCounty(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if n := len(name) - len(" county"); n > 0 && strings.EqualFold(name[n:], " county") {
+		name = strings.TrimSpace(name[:n])
+	}
+	for _, county := range KenyanCounties {
+		if strings.EqualFold(county, name) {
+			return county, nil
+		}
+	}
+	return "", ErrUnknownCounty
+}
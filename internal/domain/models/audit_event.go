@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// What an audit trail entry did to its entity.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Who made a change recorded in the audit trail.
+const (
+	// AuditActorCustomer: a signed-in customer, staff member or admin
+	AuditActorCustomer = "customer"
+	// AuditActorAPIKey: a service account calling with an API key
+	AuditActorAPIKey = "api_key"
+	// AuditActorSystem: a background job, or anything else without a user
+	AuditActorSystem = "system"
+)
+
+// AuditEvent is an entry in the audit trail: one row created, updated or
+// deleted through the repositories. Entity is the table and EntityID the
+// row's primary key. Changes holds the columns that changed, by name; a
+// created row has every column with a nil From, a deleted row every column
+// with a nil To. ActorID is the customer or API key ID, and nil for the
+// system.
+type AuditEvent struct {
+	ID        uint                   `gorm:"primarykey" json:"id"`
+	ActorType string                 `gorm:"size:20;not null;index:idx_audit_events_actor" json:"actor_type"`
+	ActorID   *uint                  `gorm:"index:idx_audit_events_actor" json:"actor_id"`
+	Action    string                 `gorm:"size:10;not null" json:"action"`
+	Entity    string                 `gorm:"size:50;not null;index:idx_audit_events_entity" json:"entity"`
+	EntityID  string                 `gorm:"size:50;not null;index:idx_audit_events_entity" json:"entity_id"`
+	Changes   map[string]AuditChange `gorm:"type:text;serializer:json" json:"changes"`
+	RequestID string                 `gorm:"size:64;index" json:"request_id,omitempty"`
+	CreatedAt time.Time              `gorm:"index" json:"created_at"`
+}
+
+// AuditChange is a column's value before and after a change.
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Kinds of authentication event kept in the audit log.
+const (
+	AuthEventLogin        = "login"
+	AuthEventLoginFailed  = "login_failed"
+	AuthEventTokenRefresh = "token_refresh"
+	AuthEventLogout       = "logout"
+	AuthEventRoleChange   = "role_change"
+)
+
+// Anomaly rules an event can trip.
+const (
+	// AuthAlertFailedAttempts: too many failed sign-ins from one IP address
+	AuthAlertFailedAttempts = "failed_attempts"
+	// AuthAlertNewCountry: a sign-in from a country the customer has not
+	// signed in from before
+	AuthAlertNewCountry = "new_country"
+)
+
+var (
+	ErrInvalidRole   = errors.New("role must be customer, staff or admin")
+	ErrChangeOwnRole = errors.New("you cannot change your own role")
+	ErrRoleUnchanged = errors.New("customer already has this role")
+)
+
+// AuthEvent is an entry in the authentication audit log. CustomerID is nil
+// when the sign-in failed before the customer was known. Reason is the
+// failure's models.AuthError code, or for a role change the old and new
+// role; ActorID is the staff member who changed it. Country comes from the
+// GeoIP database, when one is configured. Alerts lists the anomaly rules the
+// event tripped.
+type AuthEvent struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	Type       string    `gorm:"size:20;not null;index" json:"type"`
+	CustomerID *uint     `gorm:"index" json:"customer_id"`
+	ActorID    *uint     `json:"actor_id,omitempty"`
+	Provider   string    `gorm:"size:50" json:"provider,omitempty"`
+	IP         string    `gorm:"size:45;index" json:"ip"`
+	UserAgent  string    `gorm:"size:255" json:"user_agent"`
+	Country    string    `gorm:"size:2" json:"country,omitempty"`
+	Reason     string    `gorm:"size:100" json:"reason,omitempty"`
+	Alerts     []string  `gorm:"type:text;serializer:json" json:"alerts,omitempty"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
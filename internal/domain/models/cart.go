@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCartNotFound      = errors.New("cart not found")
+	ErrCartItemNotFound  = errors.New("cart item not found")
+	ErrCartEmpty         = errors.New("cart is empty")
+	ErrCartUnavailable   = errors.New("cart contains unavailable products")
+	ErrCartPricesChanged = errors.New("cart prices have changed")
+)
+
+// Cart is the server-side basket of a customer. There is at most one cart per
+// customer; it is dropped on checkout or once it has been idle for too long.
+type Cart struct {
+	gorm.Model
+	CustomerID     uint       `gorm:"not null;uniqueIndex"`
+	Customer       Customer   `gorm:"foreignkey:CustomerID" json:"-"`
+	LastActivityAt time.Time  `gorm:"not null;index"`
+	Items          []CartItem `gorm:"foreignkey:CartID"`
+}
+
+// CartItem remembers the price the product had when it was put in the cart,
+// so a later re-price can be reported to the customer.
+type CartItem struct {
+	gorm.Model
+	CartID    uint    `gorm:"not null;uniqueIndex:idx_cart_items_cart_product"`
+	ProductID uint    `gorm:"not null;uniqueIndex:idx_cart_items_cart_product"`
+	Quantity  int     `gorm:"not null"`
+	UnitPrice float64 `gorm:"type:decimal(10,2);not null"`
+}
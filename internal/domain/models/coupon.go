@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "percentage"
+	DiscountTypeFixed      DiscountType = "fixed"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponInvalidValue  = errors.New("percentage discount cannot exceed 100")
+	ErrCouponInactive      = errors.New("coupon is not active")
+	ErrCouponNotStarted    = errors.New("coupon is not valid yet")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrCouponMinBasket     = errors.New("basket is below the coupon minimum")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to any item in the basket")
+	ErrCouponExhausted     = errors.New("coupon usage limit reached")
+	ErrCouponCustomerLimit = errors.New("coupon already used the maximum number of times")
+)
+
+// Coupon is a discount code. When Categories or Products are set the discount
+// only applies to matching lines; a category also covers its subcategories.
+// Zero limits mean unlimited.
+type Coupon struct {
+	gorm.Model
+	Code             string       `gorm:"size:50;not null;uniqueIndex"`
+	Description      string       `gorm:"size:255"`
+	DiscountType     DiscountType `gorm:"type:varchar(20);not null"`
+	Value            float64      `gorm:"type:decimal(10,2);not null"`
+	MaxDiscount      float64      `gorm:"type:decimal(10,2);not null;default:0"`
+	MinBasket        float64      `gorm:"type:decimal(10,2);not null;default:0"`
+	StartsAt         *time.Time
+	ExpiresAt        *time.Time
+	UsageLimit       int        `gorm:"not null;default:0"`
+	PerCustomerLimit int        `gorm:"not null;default:0"`
+	UsedCount        int        `gorm:"not null;default:0"`
+	Active           bool       `gorm:"not null"`
+	Categories       []Category `gorm:"many2many:coupon_categories"`
+	Products         []Product  `gorm:"many2many:coupon_products"`
+}
+
+// OrderDiscount is one entry of an order's discount breakdown. A discount
+// from a coupon doubles as the record of the coupon's redemption.
+type OrderDiscount struct {
+	gorm.Model
+	OrderID     uint    `gorm:"not null;index"`
+	CouponID    *uint   `gorm:"index"`
+	Code        string  `gorm:"size:50"`
+	Description string  `gorm:"size:255"`
+	Amount      float64 `gorm:"type:decimal(10,2);not null"`
+}
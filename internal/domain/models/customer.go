@@ -1,15 +1,116 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Customer roles. Staff and admins reach the /api/v1/admin routes.
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff"
+	RoleAdmin    = "admin"
+)
+
+// DefaultPhoneCountryCode is assumed for phone numbers given without one.
+const DefaultPhoneCountryCode = "254"
+
+var (
+	ErrInvalidPhone             = errors.New("invalid phone number")
+	ErrErasureBlocked           = errors.New("account has orders that are still in progress")
+	ErrCustomerNotFound         = errors.New("customer not found")
+	ErrCustomerAlreadySuspended = errors.New("customer is already suspended")
+	ErrCustomerNotSuspended     = errors.New("customer is not suspended")
+	ErrSuspendSelf              = errors.New("you cannot suspend your own account")
+)
+
+// ErasedCustomerName replaces the name of a customer whose personal data has
+// been erased.
+const ErasedCustomerName = "Erased customer"
 
 type Customer struct {
 	gorm.Model
-	FirstName string `gorm:"size:100;not null"`
-	LastName  string `gorm:"size:100;not null"`
-	Email     string `gorm:"size:255;not null;unique"`
-	Phone     string `gorm:"size:20;not null"`
-	Address   string `gorm:"size:255"`
-	OAuthID   string `gorm:"column:oauth_id;size:255;unique"`
+	FirstName string `gorm:"size:100;not null" audit:"pii"`
+	LastName  string `gorm:"size:100;not null" audit:"pii"`
+	Email     string `gorm:"size:255;not null;unique" audit:"pii"`
+	Phone     string `gorm:"size:20;not null" audit:"pii"`
+	Address   string `gorm:"size:255" audit:"pii"`
+	Role      string `gorm:"size:20;not null;default:'customer'"`
+
+	// PhoneVerifiedAt is set once the customer has confirmed Phone with a
+	// code sent to it. SMS only goes to verified numbers.
+	PhoneVerifiedAt *time.Time
+
+	// ErasedAt is set when the customer's personal data was erased at their
+	// request. The row is kept, anonymised and soft deleted, so their orders
+	// still add up for accounting.
+	ErasedAt *time.Time
+
+	// SuspendedAt is set while staff have suspended the account; a suspended
+	// customer cannot use the API until reactivated.
+	SuspendedAt      *time.Time
+	SuspensionReason string `gorm:"size:255"`
+}
+
+// Suspended reports whether staff have shut the customer out.
+func (c *Customer) Suspended() bool {
+	return c.SuspendedAt != nil
+}
+
+// ErasedCustomerEmail is the placeholder email of an erased customer. It is
+// unique per customer and, under the reserved .invalid domain, never
+// deliverable.
+func ErasedCustomerEmail(id uint) string {
+	return fmt.Sprintf("erased-%d@erased.invalid", id)
+}
+
+// HasVerifiedPhone reports whether SMS can be sent to the customer.
+func (c *Customer) HasVerifiedPhone() bool {
+	return c.Phone != "" && c.PhoneVerifiedAt != nil
+}
+
+// NormalizePhone returns a phone number in E.164 form, e.g. +254712345678.
+// Spaces, dashes, dots and brackets are ignored, and local numbers starting
+// with 0 get the default country code.
+func NormalizePhone(raw string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9', r == '+' && i == 0:
+			b.WriteRune(r)
+		case strings.ContainsRune(" -.()", r):
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	number := b.String()
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(number, "00"):
+		number = "+" + number[2:]
+	case strings.HasPrefix(number, "0"):
+		number = "+" + DefaultPhoneCountryCode + number[1:]
+	case strings.HasPrefix(number, DefaultPhoneCountryCode):
+		number = "+" + number
+	default:
+		return "", ErrInvalidPhone
+	}
+
+	// E.164 allows at most 15 digits, and country codes never start with 0.
+	// Numbers in the default country have nine digits after its code.
+	digits := number[1:]
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	if strings.HasPrefix(digits, DefaultPhoneCountryCode) && len(digits) != len(DefaultPhoneCountryCode)+9 {
+		return "", ErrInvalidPhone
+	}
+	return number, nil
 }
 
 // 	gorm.Model This is an embedded struct provided by GORM. It includes the following fields automatically:
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDeliveryZoneNotFound = errors.New("delivery zone not found")
+	ErrNoDeliveryZone       = errors.New("we do not deliver to this address")
+	ErrDeliveryOverweight   = errors.New("basket is too heavy to deliver to this zone")
+)
+
+// DeliveryZone groups the places that share delivery rates. An area naming a
+// town takes precedence over one covering its whole county.
+type DeliveryZone struct {
+	gorm.Model
+	Name  string         `gorm:"size:100;not null;uniqueIndex"`
+	Areas []DeliveryArea `gorm:"foreignkey:ZoneID;constraint:OnDelete:CASCADE"`
+	Rates []DeliveryRate `gorm:"foreignkey:ZoneID;constraint:OnDelete:CASCADE"`
+}
+
+// DeliveryArea places a county, or one town in it when Town is set, in a zone.
+// A place is in one zone at most, ignoring case: idx_delivery_areas_place is
+// on LOWER(county), LOWER(town), which a struct tag cannot express.
+type DeliveryArea struct {
+	ID     uint   `gorm:"primarykey"`
+	ZoneID uint   `gorm:"not null;index"`
+	County string `gorm:"size:50;not null"`
+	Town   string `gorm:"size:100;not null;default:''"`
+}
+
+// DeliveryRate is the fee for baskets up to MaxWeightKg in a zone. The lowest
+// band that fits the basket applies. Fees are VAT-inclusive.
+type DeliveryRate struct {
+	ID          uint    `gorm:"primarykey"`
+	ZoneID      uint    `gorm:"not null;index"`
+	MaxWeightKg float64 `gorm:"type:decimal(10,3);not null"`
+	Fee         float64 `gorm:"type:decimal(10,2);not null"`
+}
+
+// DeliveryQuote is the outcome of pricing a delivery.
+type DeliveryQuote struct {
+	ZoneID   uint    `json:"zone_id"`
+	Zone     string  `json:"zone"`
+	WeightKg float64 `json:"weight_kg"`
+	Fee      float64 `json:"fee"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	// ErrUnverifiedEmail is returned for a new identity whose email belongs
+	// to an existing customer but which the provider has not verified, so it
+	// cannot be linked to that customer.
+	ErrUnverifiedEmail = errors.New("email is already registered; sign in with your existing account")
+	// ErrEmailNotVerified is returned for a new identity with an email the
+	// provider has not verified. It would become the new customer's email,
+	// and a later sign-in with it verified would be linked to that customer.
+	ErrEmailNotVerified = errors.New("your email is not verified with this provider; verify it there and sign in again")
+)
+
+// AuthError is a sign-in callback failure. Code is a stable,
+// machine-readable reason that clients can switch on.
+type AuthError struct {
+	Code    string
+	Message string
+}
+
+func (e *AuthError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrAuthProviderError  = &AuthError{"provider_error", "the identity provider did not sign you in"}
+	ErrAuthStateMissing   = &AuthError{"state_missing", "state parameter is required"}
+	ErrAuthSessionMissing = &AuthError{"login_session_missing", "no sign-in in progress; start again"}
+	ErrAuthSessionInvalid = &AuthError{"login_session_invalid", "sign-in session is invalid; start again"}
+	ErrAuthSessionExpired = &AuthError{"login_session_expired", "sign-in took too long; start again"}
+	ErrAuthStateMismatch  = &AuthError{"state_mismatch", "state does not match the sign-in in progress"}
+	ErrAuthCodeMissing    = &AuthError{"code_missing", "code parameter is required"}
+	ErrAuthCodeExchange   = &AuthError{"code_exchange_failed", "the authorization code was rejected by the identity provider"}
+	ErrAuthInvalidIDToken = &AuthError{"id_token_invalid", "the identity token could not be verified"}
+	ErrAuthNonceMismatch  = &AuthError{"nonce_mismatch", "the identity token was not issued for this sign-in"}
+)
+
+// Identity is an account at an external identity provider that a customer
+// signs in with. A customer can have several, e.g. Google and Microsoft;
+// Subject is the provider's ID for the account.
+type Identity struct {
+	ID            uint     `gorm:"primarykey"`
+	CustomerID    uint     `gorm:"not null;index"`
+	Customer      Customer `gorm:"foreignkey:CustomerID" json:"-"`
+	Provider      string   `gorm:"size:50;not null;uniqueIndex:idx_identities_provider_subject"`
+	Subject       string   `gorm:"size:255;not null;uniqueIndex:idx_identities_provider_subject"`
+	Email         string   `gorm:"size:255" audit:"pii"`
+	EmailVerified bool     `gorm:"not null;default:false"`
+	LastLoginAt   *time.Time
+	CreatedAt     time.Time
+}
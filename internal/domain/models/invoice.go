@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvoiceNotFound     = errors.New("invoice not found")
+	ErrOrderNotInvoiceable = errors.New("order was cancelled before it was invoiced")
+)
+
+// Invoice is the tax invoice issued for an order. Numbers run without gaps
+// within a calendar year (INV-2026-000001, INV-2026-000002, ...). The seller
+// details are copied when the invoice is issued, so a reprint always shows
+// what was issued.
+type Invoice struct {
+	gorm.Model
+	OrderID       uint      `gorm:"not null;uniqueIndex"`
+	Number        string    `gorm:"size:30;not null;uniqueIndex"`
+	Year          int       `gorm:"not null"`
+	Sequence      uint      `gorm:"not null"`
+	IssuedAt      time.Time `gorm:"not null"`
+	SellerName    string    `gorm:"size:255;not null"`
+	SellerKRAPIN  string    `gorm:"column:seller_kra_pin;size:20;not null"`
+	SellerAddress string    `gorm:"size:255"`
+}
+
+// InvoiceCounter holds the last number issued in a series, one series per
+// year. The counter row is bumped in the same transaction that saves the
+// invoice, so a failed save hands its number back.
+type InvoiceCounter struct {
+	Series     string `gorm:"primaryKey;size:20"`
+	LastNumber uint   `gorm:"not null;default:0"`
+}
+
+func InvoiceSeries(year int) string {
+	return fmt.Sprintf("INV-%d", year)
+}
+
+func InvoiceNumber(year int, sequence uint) string {
+	return fmt.Sprintf("%s-%06d", InvoiceSeries(year), sequence)
+}
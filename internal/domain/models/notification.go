@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Channels notifications are sent through.
+const (
+	NotificationChannelSMS   = "sms"
+	NotificationChannelEmail = "email"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationStatusSent    NotificationStatus = "sent"
+	NotificationStatusFailed  NotificationStatus = "failed"
+	NotificationStatusSkipped NotificationStatus = "skipped"
+)
+
+// Notification is a log entry for a message sent, or not, to a customer.
+// Reason says why a message failed or was skipped, e.g. because the
+// customer has no verified phone number.
+type Notification struct {
+	ID         uint               `gorm:"primarykey"`
+	CustomerID uint               `gorm:"not null;index"`
+	OrderID    *uint              `gorm:"index"`
+	Channel    string             `gorm:"size:10;not null"`
+	Kind       string             `gorm:"size:50;not null"`
+	Recipient  string             `gorm:"size:255" audit:"pii"`
+	Status     NotificationStatus `gorm:"type:varchar(20);not null"`
+	Reason     string             `gorm:"size:255"`
+	CreatedAt  time.Time
+}
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"crypto/rand"
 	"errors"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -9,26 +13,142 @@ type OrderStatus string
 
 const (
 	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
 	OrderStatusCompleted OrderStatus = "completed"
 	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusReturned  OrderStatus = "returned"
+)
+
+var (
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrInvalidStatusTransition = errors.New("order cannot move to that status")
+	ErrOrderNotCancellable     = errors.New("order can no longer be cancelled")
+	ErrInsufficientStock       = errors.New("not enough stock")
+	ErrNothingToReorder        = errors.New("none of the order's items are available")
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Cancelled and returned are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {OrderStatusCompleted, OrderStatusReturned},
+	OrderStatusCompleted: {OrderStatusReturned},
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Cancellable reports whether the order has not shipped yet.
+func (s OrderStatus) Cancellable() bool {
+	return s.CanTransitionTo(OrderStatusCancelled)
+}
+
+// InProgress reports whether the order still has to be paid for, shipped or
+// delivered.
+func (s OrderStatus) InProgress() bool {
+	return s == OrderStatusPending || s == OrderStatusPaid || s == OrderStatusShipped
+}
+
+// Returnable reports whether goods from the order can be sent back.
+func (s OrderStatus) Returnable() bool {
+	return s == OrderStatusShipped || s == OrderStatusCompleted
+}
+
+// Refundable reports whether money taken for the order may be given back.
+// Orders that are pending or paid but not shipped are cancelled instead.
+func (s OrderStatus) Refundable() bool {
+	return s == OrderStatusCancelled || s == OrderStatusReturned || s.Returnable()
+}
+
+// Order references look like SAV-7K3Q-92XD: eight random characters from an
+// alphabet without the easily confused 0/O, 1/I/L, giving about 8.5e11
+// possible references, so they can be read out over the phone and cannot be
+// guessed from one another.
+const (
+	orderReferencePrefix   = "SAV"
+	orderReferenceAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
 )
 
-var ErrOrderNotFound = errors.New("order not found")
+// NewOrderReference returns a random order reference. Uniqueness is enforced
+// by the database; callers retry on a clash.
+func NewOrderReference() string {
+	// Bytes at or above this limit are skipped so every character is equally
+	// likely
+	limit := byte(256 - 256%len(orderReferenceAlphabet))
 
+	chars := make([]byte, 0, 8)
+	var random [16]byte
+	for len(chars) < cap(chars) {
+		if _, err := rand.Read(random[:]); err != nil {
+			panic(err) // crypto/rand does not fail on supported platforms
+		}
+		for _, r := range random {
+			if r < limit && len(chars) < cap(chars) {
+				chars = append(chars, orderReferenceAlphabet[int(r)%len(orderReferenceAlphabet)])
+			}
+		}
+	}
+	return orderReferencePrefix + "-" + string(chars[:4]) + "-" + string(chars[4:])
+}
+
+// NormalizeOrderReference tidies a reference typed by a person: case and
+// surrounding space are ignored.
+func NormalizeOrderReference(reference string) string {
+	return strings.ToUpper(strings.TrimSpace(reference))
+}
+
+// Order amounts: NetTotal = Subtotal - DiscountTotal and
+// Total = NetTotal + TaxTotal + DeliveryFee, i.e. Total is the gross amount
+// payable. The delivery fee is VAT-inclusive.
 type Order struct {
 	gorm.Model
-	CustomerID uint        `gorm:"not null"`
-	Customer   Customer    `gorm:"foreignkey:CustomerID"`
-	Status     OrderStatus `gorm:"type:varchar(20);default:'pending'"`
-	Total      float64     `gorm:"type:decimal(10,2);not null"`
-	OrderItems []OrderItem
+	Reference     string      `gorm:"size:20;not null;uniqueIndex"`
+	CustomerID    uint        `gorm:"not null"`
+	Customer      Customer    `gorm:"foreignkey:CustomerID"`
+	Status        OrderStatus `gorm:"type:varchar(20);default:'pending'"`
+	Subtotal      float64     `gorm:"type:decimal(10,2);not null;default:0"`
+	DiscountTotal float64     `gorm:"type:decimal(10,2);not null;default:0"`
+	NetTotal      float64     `gorm:"type:decimal(10,2);not null;default:0"`
+	TaxTotal      float64     `gorm:"type:decimal(10,2);not null;default:0"`
+	DeliveryFee   float64     `gorm:"type:decimal(10,2);not null;default:0"`
+	Total         float64     `gorm:"type:decimal(10,2);not null"`
+	OrderItems    []OrderItem
+	Discounts     []OrderDiscount `gorm:"foreignkey:OrderID"`
+
+	// Delivery details, copied from the address book when the order is placed
+	ShippingAddressID *uint
+	ShippingAddress   ShippingAddress `gorm:"embedded;embeddedPrefix:shipping_"`
+	DeliveryZone      string          `gorm:"size:100"`
+	ShippingWeightKg  float64         `gorm:"type:decimal(10,3);not null;default:0"`
+
+	CancelledAt  *time.Time
+	CancelReason string `gorm:"size:255"`
 }
 
+// OrderItem.Discount is the line's share of the order discounts. VAT is
+// charged on the discounted line: NetAmount = Price*Quantity - Discount and
+// GrossAmount = NetAmount + TaxAmount. TaxTreatment and TaxRate record the
+// rate that applied when the order was placed.
 type OrderItem struct {
 	gorm.Model
-	OrderID   uint    `gorm:"not null"`
-	ProductID uint    `gorm:"not null"`
-	Product   Product `gorm:"foreignkey:ProductID"`
-	Quantity  int     `gorm:"not null"`
-	Price     float64 `gorm:"type:decimal(10,2);not null"`
+	OrderID      uint         `gorm:"not null"`
+	ProductID    uint         `gorm:"not null"`
+	Product      Product      `gorm:"foreignkey:ProductID"`
+	Quantity     int          `gorm:"not null"`
+	Price        float64      `gorm:"type:decimal(10,2);not null"`
+	Discount     float64      `gorm:"type:decimal(10,2);not null;default:0"`
+	TaxTreatment TaxTreatment `gorm:"type:varchar(20);not null;default:'standard'"`
+	TaxRate      float64      `gorm:"type:decimal(5,2);not null;default:0"`
+	NetAmount    float64      `gorm:"type:decimal(10,2);not null;default:0"`
+	TaxAmount    float64      `gorm:"type:decimal(10,2);not null;default:0"`
+	GrossAmount  float64      `gorm:"type:decimal(10,2);not null;default:0"`
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// OTPPurpose says what a one-time code is for. Codes for one purpose cannot
+// be used for another.
+type OTPPurpose string
+
+const (
+	// OTPPurposePhoneVerification confirms a phone number before it is
+	// saved on the customer.
+	OTPPurposePhoneVerification OTPPurpose = "phone_verification"
+)
+
+// OTPError is a one-time code failure. Code is a stable, machine-readable
+// reason that clients can switch on.
+type OTPError struct {
+	Code    string
+	Message string
+}
+
+func (e *OTPError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrOTPNotFound        = &OTPError{"otp_not_found", "no code is waiting to be verified"}
+	ErrOTPExpired         = &OTPError{"otp_expired", "code has expired, request a new one"}
+	ErrOTPInvalid         = &OTPError{"otp_invalid", "code is incorrect"}
+	ErrOTPTooManyAttempts = &OTPError{"otp_too_many_attempts", "too many incorrect codes, request a new one"}
+	ErrOTPResendTooSoon   = &OTPError{"otp_resend_too_soon", "a code was sent moments ago, wait before asking again"}
+	ErrOTPResendLimit     = &OTPError{"otp_resend_limit", "too many codes requested, try again later"}
+)
+
+// OTP is a short numeric code texted to Phone. There is at most one live
+// code per purpose and subject (who or what it is for, e.g. a customer); a
+// new code replaces the last. Only a hash of the code is kept.
+//
+// Sends counts the codes sent since WindowStartedAt, to limit resends.
+type OTP struct {
+	ID              uint       `gorm:"primarykey"`
+	Purpose         OTPPurpose `gorm:"type:varchar(30);not null;uniqueIndex:idx_otps_purpose_subject"`
+	Subject         string     `gorm:"size:100;not null;uniqueIndex:idx_otps_purpose_subject"`
+	Phone           string     `gorm:"size:20;not null" audit:"pii"`
+	CodeHash        string     `gorm:"size:64;not null"`
+	Attempts        int        `gorm:"not null;default:0"`
+	Sends           int        `gorm:"not null;default:0"`
+	WindowStartedAt time.Time  `gorm:"not null"`
+	LastSentAt      time.Time  `gorm:"not null"`
+	ExpiresAt       time.Time  `gorm:"not null;index"`
+	CreatedAt       time.Time
+}
+
+// CustomerOTPSubject is the subject of codes sent on behalf of a customer.
+func CustomerOTPSubject(customerID uint) string {
+	return fmt.Sprintf("customer:%d", customerID)
+}
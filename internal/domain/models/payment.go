@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending   PaymentStatus = "pending"
+	PaymentStatusSucceeded PaymentStatus = "succeeded"
+	PaymentStatusFailed    PaymentStatus = "failed"
+	// PaymentStatusAmountMismatch: the provider reported success for less
+	// than was asked. The order is not paid for; staff review the payment.
+	PaymentStatusAmountMismatch PaymentStatus = "amount_mismatch"
+)
+
+var (
+	ErrPaymentNotFound        = errors.New("payment not found")
+	ErrOrderNotPayable        = errors.New("order is not awaiting payment")
+	ErrPaymentInProgress      = errors.New("a payment for this order is already in progress")
+	ErrUnknownPaymentProvider = errors.New("unknown payment provider")
+	ErrOrderNotRefundable     = errors.New("only cancelled or returned orders can be refunded")
+	ErrNothingToRefund        = errors.New("order has no settled payment to refund")
+	ErrRefundExceedsPayment   = errors.New("refund exceeds the refundable amount")
+	ErrRefundNotFound         = errors.New("refund not found")
+)
+
+// Payment is one attempt at paying for an order through a PaymentProvider.
+// An order may collect several failed attempts before one succeeds.
+type Payment struct {
+	gorm.Model
+	OrderID  uint          `gorm:"not null;index"`
+	Order    Order         `gorm:"foreignkey:OrderID" json:"-"`
+	Provider string        `gorm:"size:20;not null"`
+	Amount   float64       `gorm:"type:decimal(10,2);not null"`
+	Phone    string        `gorm:"size:20" audit:"pii"`
+	Status   PaymentStatus `gorm:"type:varchar(20);not null;default:'pending';index"`
+	// ProviderReference is the provider's handle for the attempt, e.g. the
+	// M-Pesa CheckoutRequestID. Callbacks and status queries use it.
+	ProviderReference     string   `gorm:"size:100;uniqueIndex"`
+	ProviderTransactionID string   `gorm:"size:100"` // e.g. the M-Pesa receipt number
+	ResultCode            string   `gorm:"size:20"`
+	ResultDesc            string   `gorm:"size:255"`
+	RefundedAmount        float64  `gorm:"type:decimal(10,2);not null;default:0"`
+	Refunds               []Refund `gorm:"foreignkey:PaymentID"`
+}
+
+// Refundable is the part of a settled payment that has not been refunded or
+// is not already being refunded.
+func (p *Payment) Refundable() float64 {
+	if p.Status != PaymentStatusSucceeded {
+		return 0
+	}
+	return p.Amount - p.RefundedAmount
+}
+
+// Refund returns all or part of a payment to the customer. Refunds are tied to
+// cancelled or returned orders. A pending refund without a ProviderReference
+// has not reached the provider yet and is sent again by the reconciler.
+type Refund struct {
+	gorm.Model
+	PaymentID             uint          `gorm:"not null;index"`
+	Payment               Payment       `gorm:"foreignkey:PaymentID" json:"-"`
+	OrderID               uint          `gorm:"not null;index"`
+	Amount                float64       `gorm:"type:decimal(10,2);not null"`
+	Reason                string        `gorm:"size:255"`
+	Status                PaymentStatus `gorm:"type:varchar(20);not null;default:'pending';index"`
+	ProviderReference     string        `gorm:"size:100;index"`
+	ProviderTransactionID string        `gorm:"size:100"`
+	ResultDesc            string        `gorm:"size:255"`
+}
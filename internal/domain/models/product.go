@@ -8,6 +8,28 @@ type Product struct {
 	Description string   `gorm:"type:text"`
 	Price       float64  `gorm:"type:decimal(10,2);not null"`
 	SKU         string   `gorm:"size:100;unique"`
+	WeightKg    float64  `gorm:"type:decimal(10,3);not null;default:0"`
 	CategoryID  uint     `gorm:"not null"`
 	Category    Category `gorm:"foreignkey:CategoryID"`
+
+	// Stock is how many are left, taken by orders and put back by
+	// cancellations and returns. Nil means stock is not tracked and the
+	// product never runs out.
+	Stock *int `gorm:"check:stock >= 0"`
+
+	// Aggregates of the approved reviews, kept up to date as reviews are
+	// moderated. RatingTotal is the sum of their ratings.
+	RatingAverage float64 `gorm:"type:decimal(3,2);not null;default:0"`
+	RatingCount   int     `gorm:"not null;default:0"`
+	RatingTotal   int     `gorm:"not null;default:0" json:"-"`
+}
+
+// InStock reports whether quantity can be taken from the product's stock.
+func (p *Product) InStock(quantity int) bool {
+	return p.Stock == nil || *p.Stock >= quantity
+}
+
+// OutOfStock reports whether the product's stock is tracked and has run out.
+func (p *Product) OutOfStock() bool {
+	return p.Stock != nil && *p.Stock <= 0
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusReceived  ReturnStatus = "received"
+)
+
+var (
+	ErrReturnNotFound         = errors.New("return not found")
+	ErrOrderNotReturnable     = errors.New("order is not eligible for returns")
+	ErrReturnItemInvalid      = errors.New("return item is not part of the order")
+	ErrReturnQuantityExceeded = errors.New("return quantity exceeds what is left to return")
+	ErrReturnStatus           = errors.New("return is not in a state that allows this")
+)
+
+// ReturnRequest is a customer's request to send goods back (an RMA). Staff
+// approve or reject it, and confirm when the goods arrive; receiving restocks
+// the items and refunds their share of what was paid.
+type ReturnRequest struct {
+	gorm.Model
+	OrderID      uint         `gorm:"not null;index"`
+	CustomerID   uint         `gorm:"not null;index"`
+	Status       ReturnStatus `gorm:"type:varchar(20);not null;default:'requested'"`
+	Reason       string       `gorm:"type:text"`
+	StaffNote    string       `gorm:"type:text"`
+	RefundAmount float64      `gorm:"type:decimal(10,2);not null;default:0"`
+	ReviewedAt   *time.Time
+	ReceivedAt   *time.Time
+	Items        []ReturnItem `gorm:"foreignkey:ReturnRequestID"`
+}
+
+type ReturnItem struct {
+	ID              uint      `gorm:"primarykey"`
+	ReturnRequestID uint      `gorm:"not null;index"`
+	OrderItemID     uint      `gorm:"not null;index"`
+	OrderItem       OrderItem `gorm:"foreignkey:OrderItemID"`
+	Quantity        int       `gorm:"not null"`
+	Reason          string    `gorm:"size:255"`
+}
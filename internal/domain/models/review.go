@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+var (
+	ErrReviewNotFound       = errors.New("review not found")
+	ErrNotVerifiedPurchaser = errors.New("only customers who have received the product can review it")
+	ErrReviewExists         = errors.New("product already reviewed")
+	ErrReviewStatus         = errors.New("review is not in a state that allows this")
+	ErrOwnReview            = errors.New("cannot vote on your own review")
+	ErrAlreadyVoted         = errors.New("review already marked helpful")
+)
+
+// Review is a customer's rating (1 to 5) and write-up of a product they
+// bought. Reviews are held as pending until staff approve them; only approved
+// reviews are shown and counted in the product's rating. A customer reviews
+// each product at most once.
+type Review struct {
+	ID             uint         `gorm:"primarykey"`
+	ProductID      uint         `gorm:"not null;uniqueIndex:idx_reviews_product_customer"`
+	CustomerID     uint         `gorm:"not null;uniqueIndex:idx_reviews_product_customer;index"`
+	Customer       Customer     `gorm:"foreignkey:CustomerID" json:"-"`
+	Rating         int          `gorm:"not null"`
+	Title          string       `gorm:"size:120"`
+	Body           string       `gorm:"type:text"`
+	Status         ReviewStatus `gorm:"type:varchar(20);not null;default:'pending';index"`
+	HelpfulCount   int          `gorm:"not null;default:0"`
+	ModerationNote string       `gorm:"type:text"`
+	ModeratedAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ReviewVote records that a customer found a review helpful, so each
+// customer counts once.
+type ReviewVote struct {
+	ReviewID   uint `gorm:"primarykey;autoIncrement:false"`
+	CustomerID uint `gorm:"primarykey;autoIncrement:false"`
+	CreatedAt  time.Time
+}
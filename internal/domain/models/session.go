@@ -0,0 +1,22 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSessionInvalid = errors.New("invalid session token")
+	ErrSessionExpired = errors.New("session has expired")
+)
+
+// Session is a customer's sign-in. The client is given a random token to
+// send as its bearer token; only a SHA-256 hash of it is stored. Deleting
+// the row ends the session straight away.
+type Session struct {
+	ID         uint      `gorm:"primarykey"`
+	CustomerID uint      `gorm:"not null;index"`
+	TokenHash  string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	CreatedAt  time.Time
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaxTreatment is how VAT applies to a supply. Zero-rated and exempt supplies
+// both carry no tax, but they are reported differently to KRA.
+type TaxTreatment string
+
+const (
+	TaxTreatmentStandard  TaxTreatment = "standard"
+	TaxTreatmentZeroRated TaxTreatment = "zero_rated"
+	TaxTreatmentExempt    TaxTreatment = "exempt"
+)
+
+// StandardVATRate is the Kenyan standard VAT rate in percent. It only applies
+// when no rate has been configured at all; configured rates always win.
+const StandardVATRate = 16.0
+
+var (
+	ErrTaxRateNotFound  = errors.New("tax rate not found")
+	ErrTaxRateInEffect  = errors.New("tax rate already in effect and cannot be removed")
+	ErrTaxRateTreatment = errors.New("only standard-rated supplies carry a non-zero rate")
+)
+
+// TaxRate assigns a VAT treatment and rate to a category from EffectiveFrom
+// onwards. Rates are versioned: a change is a new row, never an edit, so past
+// orders keep the rate that applied when they were placed. A category without
+// a rate of its own inherits its nearest ancestor's; a row without a category
+// is the store-wide default.
+type TaxRate struct {
+	gorm.Model
+	CategoryID    *uint        `gorm:"index"`
+	Category      *Category    `gorm:"foreignkey:CategoryID"`
+	Treatment     TaxTreatment `gorm:"type:varchar(20);not null"`
+	Rate          float64      `gorm:"type:decimal(5,2);not null"`
+	EffectiveFrom time.Time    `gorm:"not null;index"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Channels a back-in-stock alert can be sent through.
+const (
+	AlertChannelSMS   = "sms"
+	AlertChannelEmail = "email"
+)
+
+var (
+	ErrWishlistItemNotFound = errors.New("product is not on the wishlist")
+	ErrStockAlertNotFound   = errors.New("no back-in-stock alert for this product")
+	ErrProductInStock       = errors.New("product is in stock")
+)
+
+// WishlistItem is a product a customer saved for later. Each product appears
+// at most once per customer.
+type WishlistItem struct {
+	ID         uint    `gorm:"primarykey"`
+	CustomerID uint    `gorm:"not null;uniqueIndex:idx_wishlist_items_customer_product"`
+	ProductID  uint    `gorm:"not null;uniqueIndex:idx_wishlist_items_customer_product"`
+	Product    Product `gorm:"foreignkey:ProductID"`
+	CreatedAt  time.Time
+}
+
+// StockAlert asks for a message when an out-of-stock product comes back.
+// NotifiedAt is set when the alert is sent; each subscription fires once, and
+// subscribing again re-arms it.
+type StockAlert struct {
+	ID         uint     `gorm:"primarykey"`
+	CustomerID uint     `gorm:"not null;uniqueIndex:idx_stock_alerts_customer_product"`
+	Customer   Customer `gorm:"foreignkey:CustomerID" json:"-"`
+	ProductID  uint     `gorm:"not null;uniqueIndex:idx_stock_alerts_customer_product"`
+	Product    Product  `gorm:"foreignkey:ProductID"`
+	Channel    string   `gorm:"size:10;not null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	NotifiedAt *time.Time `gorm:"index"`
+}
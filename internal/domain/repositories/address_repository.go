@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type AddressRepository interface {
+	GetByCustomerID(ctx context.Context, customerID uint) ([]models.Address, error)
+	GetByID(ctx context.Context, customerID, id uint) (*models.Address, error)
+	GetDefault(ctx context.Context, customerID uint) (*models.Address, error)
+	Create(ctx context.Context, address *models.Address) error
+	Update(ctx context.Context, address *models.Address) error
+	Delete(ctx context.Context, customerID, id uint) error
+	SetDefault(ctx context.Context, customerID, id uint) error
+}
+
+type addressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) AddressRepository {
+	return &addressRepository{db: db}
+}
+
+// GetByCustomerID lists the address book, default address first.
+func (r *addressRepository) GetByCustomerID(ctx context.Context, customerID uint) ([]models.Address, error) {
+	var addresses []models.Address
+	err := r.db.WithContext(ctx).Where("customer_id = ?", customerID).
+		Order("is_default DESC").
+		Order("created_at").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// GetByID only finds addresses belonging to the customer.
+func (r *addressRepository) GetByID(ctx context.Context, customerID, id uint) (*models.Address, error) {
+	var address models.Address
+	if err := r.db.WithContext(ctx).Where("customer_id = ?", customerID).
+		First(&address, id).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *addressRepository) GetDefault(ctx context.Context, customerID uint) (*models.Address, error) {
+	var address models.Address
+	if err := r.db.WithContext(ctx).Where("customer_id = ? AND is_default", customerID).
+		First(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// Create adds an address; a customer's first address becomes the default.
+func (r *addressRepository) Create(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Address{}).Where("customer_id = ?", address.CustomerID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.CustomerID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(address).Error
+	})
+}
+
+func (r *addressRepository) Update(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.CustomerID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(address).Error
+	})
+}
+
+// Delete removes an address. When it was the default, the most recently added
+// remaining address takes over.
+func (r *addressRepository) Delete(ctx context.Context, customerID, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var address models.Address
+		if err := tx.Where("customer_id = ?", customerID).First(&address, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next models.Address
+		err := tx.Where("customer_id = ?", customerID).Order("created_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+func (r *addressRepository) SetDefault(ctx context.Context, customerID, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultAddress(tx, customerID); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Address{}).
+			Where("id = ? AND customer_id = ?", id, customerID).
+			Update("is_default", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func clearDefaultAddress(tx *gorm.DB, customerID uint) error {
+	return tx.Model(&models.Address{}).
+		Where("customer_id = ? AND is_default", customerID).
+		Update("is_default", false).Error
+}
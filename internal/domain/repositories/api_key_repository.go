@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type APIKeyRepository interface {
+	List(ctx context.Context, includeRevoked bool) ([]models.APIKey, error)
+	Get(ctx context.Context, id uint) (*models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	Create(ctx context.Context, key *models.APIKey) error
+	Rotate(ctx context.Context, old *models.APIKey, replacement *models.APIKey, retireAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id uint, at time.Time) (bool, error)
+	TouchLastUsed(ctx context.Context, id uint, at, staleBefore time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) List(ctx context.Context, includeRevoked bool) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	query := r.db.WithContext(ctx)
+	if !includeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+	err := query.Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) Get(ctx context.Context, id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// Rotate creates the replacement key and retires the old one at retireAt, in
+// one transaction. It reports false, creating nothing, when the old key was
+// already revoked or rotated.
+func (r *apiKeyRepository) Rotate(ctx context.Context, old *models.APIKey, replacement *models.APIKey, retireAt time.Time) (bool, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(replacement).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.APIKey{}).
+			Where("id = ? AND revoked_at IS NULL AND replaced_by_id IS NULL", old.ID).
+			Updates(map[string]interface{}{"revoked_at": retireAt, "replaced_by_id": replacement.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Roll back the replacement
+			return errKeyRetired
+		}
+		return nil
+	})
+	if errors.Is(err, errKeyRetired) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+var errKeyRetired = errors.New("api key already retired")
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (revoked_at IS NULL OR revoked_at > ?)", id, at).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchLastUsed records a use of the key, skipping the write when the
+// recorded use is newer than staleBefore so busy keys do not write on every
+// request.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, at, staleBefore time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, staleBefore).
+		Update("last_used_at", at).Error
+}
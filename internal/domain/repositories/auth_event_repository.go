@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// AuthEventFilter narrows the audit log; zero fields match everything.
+// Alerted keeps only events that tripped an anomaly rule.
+type AuthEventFilter struct {
+	Type       string
+	CustomerID uint
+	IP         string
+	From       time.Time
+	To         time.Time
+	Alerted    bool
+}
+
+type AuthEventRepository interface {
+	Create(ctx context.Context, event *models.AuthEvent) error
+	Search(ctx context.Context, filter AuthEventFilter, page, limit int) ([]models.AuthEvent, int64, error)
+	CountFromIP(ctx context.Context, eventType, ip string, since time.Time) (int64, error)
+	LoginCountries(ctx context.Context, customerID uint) ([]string, error)
+}
+
+type authEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthEventRepository(db *gorm.DB) AuthEventRepository {
+	return &authEventRepository{db: db}
+}
+
+func (r *authEventRepository) Create(ctx context.Context, event *models.AuthEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *authEventRepository) Search(ctx context.Context, filter AuthEventFilter, page, limit int) ([]models.AuthEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuthEvent{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.CustomerID != 0 {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.Alerted {
+		query = query.Where("alerts IS NOT NULL AND alerts <> '' AND alerts <> 'null'")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuthEvent
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&events).Error
+	return events, total, err
+}
+
+// CountFromIP counts events of one type from ip since the given time.
+func (r *authEventRepository) CountFromIP(ctx context.Context, eventType, ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.AuthEvent{}).
+		Where("type = ? AND ip = ? AND created_at >= ?", eventType, ip, since).
+		Count(&count).Error
+	return count, err
+}
+
+// LoginCountries lists the countries a customer has signed in from.
+func (r *authEventRepository) LoginCountries(ctx context.Context, customerID uint) ([]string, error) {
+	var countries []string
+	err := r.db.WithContext(ctx).Model(&models.AuthEvent{}).
+		Where("type = ? AND customer_id = ? AND country <> ''", models.AuthEventLogin, customerID).
+		Distinct().
+		Pluck("country", &countries).Error
+	return countries, err
+}
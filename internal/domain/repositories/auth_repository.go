@@ -1,17 +1,69 @@
 package repositories
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/Mutonya/Savanah/internal/audit"
 	"github.com/Mutonya/Savanah/internal/domain/models"
 )
 
 type CustomerRepository interface {
-	GetByOAuthID(oauthID string) (*models.Customer, error) // get customer by OAuthId
-	GetByID(id uint) (*models.Customer, error)             // get user by ID
-	Create(customer *models.Customer) error                // create customer
-	Update(customer *models.Customer) error                // update the customer
-	Delete(id uint) error                                  // delete user with ID
+	GetByEmail(ctx context.Context, email string) (*models.Customer, error)
+	GetByID(id uint) (*models.Customer, error)                   // get user by ID
+	Create(ctx context.Context, customer *models.Customer) error // create customer
+	Update(ctx context.Context, customer *models.Customer) error // update the customer
+	Delete(ctx context.Context, id uint) error                   // delete user with ID
+	Erase(ctx context.Context, id uint, at time.Time) error
+
+	// Customer directory for support staff
+	Search(ctx context.Context, filter CustomerFilter, page, limit int) ([]CustomerSummary, int64, error)
+	GetSummary(ctx context.Context, id uint) (*CustomerSummary, error)
+	Suspend(ctx context.Context, id uint, at time.Time, reason string) (bool, error)
+	Reactivate(ctx context.Context, id uint) (bool, error)
+	SetRole(ctx context.Context, id uint, role string) (bool, error)
+}
+
+// CustomerFilter narrows the customer directory; zero fields are ignored.
+// Query matches part of the name, email or phone. Customers who signed up
+// from From up to (not including) To match. MaxOrders is a pointer because
+// zero, customers who never ordered, is a useful bound. Sort is one of the
+// keys of customerSortColumns, newest first by default.
+type CustomerFilter struct {
+	Query      string
+	Phone      string // an E.164 number that must match exactly
+	From       time.Time
+	To         time.Time
+	MinOrders  int
+	MaxOrders  *int
+	Suspended  *bool
+	Sort       string
+	Descending bool
+}
+
+var customerSortColumns = map[string]string{
+	"created_at":     "customers.created_at",
+	"orders":         "COALESCE(order_stats.order_count, 0)",
+	"lifetime_value": "COALESCE(order_stats.lifetime_value, 0)",
+}
+
+// CustomerOrderStats sums up a customer's orders. LifetimeValue and
+// PaidOrderCount only count orders that were paid for and have not been
+// cancelled or returned.
+type CustomerOrderStats struct {
+	OrderCount     int64      `json:"order_count"`
+	PaidOrderCount int64      `json:"paid_order_count"`
+	LifetimeValue  float64    `json:"lifetime_value"`
+	LastOrderAt    *time.Time `json:"last_order_at"`
+}
+
+// CustomerSummary is a customer as listed in the directory.
+type CustomerSummary struct {
+	models.Customer
+	CustomerOrderStats
 }
 
 type customerRepository struct {
@@ -26,13 +78,11 @@ func NewCustomerRepository(db *gorm.DB) CustomerRepository {
 	return &customerRepository{db: db}
 }
 
-// Primary lookup during authentication
-func (r *customerRepository) GetByOAuthID(oauthID string) (*models.Customer, error) {
-	var customer models.Customer // Initialize empty customer struct
-
-	// Query: SELECT * FROM customers WHERE oauth_id = ? LIMIT 1
-
-	if err := r.db.Where("oauth_id = ?", oauthID).First(&customer).Error; err != nil {
+// GetByEmail finds a customer by email, ignoring case. Used to link a new
+// identity to the customer who already has its email.
+func (r *customerRepository) GetByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&customer).Error; err != nil {
 		return nil, err
 	}
 	return &customer, nil
@@ -53,23 +103,243 @@ func (r *customerRepository) GetByID(id uint) (*models.Customer, error) {
 // Create inserts value, returning the inserted data's primary key in value's id
 // Auto-populates ID, CreatedAt, UpdatedAt
 // Validates struct tags (e.g., gorm:"not null")
-func (r *customerRepository) Create(customer *models.Customer) error {
+func (r *customerRepository) Create(ctx context.Context, customer *models.Customer) error {
 	// Query: INSERT INTO customers (...) VALUES (...)
-	return r.db.Create(customer).Error
+	return r.db.WithContext(ctx).Create(customer).Error
 }
 
-func (r *customerRepository) Update(customer *models.Customer) error {
+func (r *customerRepository) Update(ctx context.Context, customer *models.Customer) error {
 	// Save updates value in database. If value doesn't contain a matching primary key, value is inserted.
 	// Query: UPDATE customers SET ... WHERE id = ?
-	return r.db.Save(customer).Error
+	return r.db.WithContext(ctx).Save(customer).Error
 }
 
-func (r *customerRepository) Delete(id uint) error {
+func (r *customerRepository) Delete(ctx context.Context, id uint) error {
 	// Delete deletes value matching given conditions.
 	//If value contains primary key it is included in the conditions. If
 	// value includes a deleted_at field, then
 	//Delete performs a soft delete instead by setting deleted_at with the current
 	// time if null.
 	// Query: DELETE FROM customers WHERE id = ?
-	return r.db.Delete(&models.Customer{}, id).Error
+	return r.db.WithContext(ctx).Delete(&models.Customer{}, id).Error
+}
+
+// Erase removes the customer's personal data for good, as a soft delete alone
+// leaves it in place. The customer row is anonymised and soft deleted, and
+// their orders keep their amounts but lose the recipient, phone and street
+// of the delivery address. Linked identities, sessions, address book, cart,
+// wishlist, stock alerts and one-time codes are deleted outright. Without the
+// sessions the customer's bearer tokens stop working, and without the
+// identities a later sign-in starts a new account. The audit trail of those
+// rows loses the personal data it still holds, and the auth audit log the
+// IP addresses and browsers the customer signed in from.
+func (r *customerRepository) Erase(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The rows are looked up first, as some of them are deleted below
+		trails := erasedTrails(id)
+		for i := range trails {
+			if err := tx.Unscoped().Model(trails[i].model).Where(trails[i].query, trails[i].args...).
+				Pluck("id", &trails[i].ids).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.Customer{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"first_name":        models.ErasedCustomerName,
+			"last_name":         "",
+			"email":             models.ErasedCustomerEmail(id),
+			"phone":             "",
+			"address":           "",
+			"phone_verified_at": nil,
+			"erased_at":         at,
+			"deleted_at":        at,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Town, county and postal code stay; they say nothing about the
+		// person and delivery fees and tax reports are worked out from them
+		if err := tx.Unscoped().Model(&models.Order{}).Where("customer_id = ?", id).Updates(map[string]interface{}{
+			"shipping_recipient_name": "",
+			"shipping_phone":          "",
+			"shipping_line1":          "",
+			"shipping_line2":          "",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Payment{}).
+			Where("order_id IN (SELECT id FROM orders WHERE customer_id = ?)", id).
+			Update("phone", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Notification{}).Where("customer_id = ?", id).Update("recipient", "").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("cart_id IN (SELECT id FROM carts WHERE customer_id = ?)", id).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.Identity{}, &models.Session{}, &models.Cart{}, &models.Address{}, &models.WishlistItem{}, &models.StockAlert{}} {
+			if err := tx.Unscoped().Where("customer_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("subject = ?", models.CustomerOTPSubject(id)).Delete(&models.OTP{}).Error; err != nil {
+			return err
+		}
+
+		for _, trail := range trails {
+			if err := audit.Scrub(tx, trail.model, trail.ids); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.AuthEvent{}).Where("customer_id = ? OR actor_id = ?", id, id).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error
+	})
+}
+
+// erasedTrail is a kind of row holding a customer's personal data, whose
+// audit trail Erase scrubs.
+type erasedTrail struct {
+	model interface{}
+	query string
+	args  []interface{}
+	ids   []uint
+}
+
+func erasedTrails(customerID uint) []erasedTrail {
+	return []erasedTrail{
+		{model: &models.Customer{}, query: "id = ?", args: []interface{}{customerID}},
+		{model: &models.Identity{}, query: "customer_id = ?", args: []interface{}{customerID}},
+		{model: &models.Address{}, query: "customer_id = ?", args: []interface{}{customerID}},
+		{model: &models.Order{}, query: "customer_id = ?", args: []interface{}{customerID}},
+		{model: &models.Payment{}, query: "order_id IN (SELECT id FROM orders WHERE customer_id = ?)", args: []interface{}{customerID}},
+		{model: &models.Notification{}, query: "customer_id = ?", args: []interface{}{customerID}},
+		{model: &models.OTP{}, query: "subject = ?", args: []interface{}{models.CustomerOTPSubject(customerID)}},
+	}
+}
+
+// customerSummaryColumns selects a CustomerSummary from customers joined
+// with joinOrderStats.
+const customerSummaryColumns = `customers.*,
+	COALESCE(order_stats.order_count, 0) AS order_count,
+	COALESCE(order_stats.paid_order_count, 0) AS paid_order_count,
+	COALESCE(order_stats.lifetime_value, 0) AS lifetime_value,
+	order_stats.last_order_at`
+
+// joinOrderStats joins each customer's CustomerOrderStats as order_stats.
+func joinOrderStats(db *gorm.DB) *gorm.DB {
+	paid := []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusShipped, models.OrderStatusCompleted}
+	return db.Joins(`LEFT JOIN (
+		SELECT customer_id,
+			COUNT(*) AS order_count,
+			COUNT(*) FILTER (WHERE status IN ?) AS paid_order_count,
+			COALESCE(SUM(total) FILTER (WHERE status IN ?), 0) AS lifetime_value,
+			MAX(created_at) AS last_order_at
+		FROM orders
+		WHERE deleted_at IS NULL
+		GROUP BY customer_id
+	) AS order_stats ON order_stats.customer_id = customers.id`, paid, paid)
+}
+
+func (r *customerRepository) Search(ctx context.Context, filter CustomerFilter, page, limit int) ([]CustomerSummary, int64, error) {
+	var customers []CustomerSummary
+	var count int64
+
+	query := joinOrderStats(r.db.WithContext(ctx).Model(&models.Customer{}))
+	if filter.Query != "" || filter.Phone != "" {
+		like := "%" + filter.Query + "%"
+		match := r.db.Where("customers.first_name || ' ' || customers.last_name ILIKE ?", like).
+			Or("customers.email ILIKE ?", like).
+			Or("customers.phone LIKE ?", like)
+		if filter.Phone != "" {
+			match = match.Or("customers.phone = ?", filter.Phone)
+		}
+		query = query.Where(match)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("customers.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("customers.created_at < ?", filter.To)
+	}
+	if filter.MinOrders > 0 {
+		query = query.Where("COALESCE(order_stats.order_count, 0) >= ?", filter.MinOrders)
+	}
+	if filter.MaxOrders != nil {
+		query = query.Where("COALESCE(order_stats.order_count, 0) <= ?", *filter.MaxOrders)
+	}
+	if filter.Suspended != nil {
+		if *filter.Suspended {
+			query = query.Where("customers.suspended_at IS NOT NULL")
+		} else {
+			query = query.Where("customers.suspended_at IS NULL")
+		}
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := customerSortColumns[filter.Sort]
+	if !ok {
+		column, filter.Descending = customerSortColumns["created_at"], true
+	}
+	query = query.Select(customerSummaryColumns).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column, Raw: true}, Desc: filter.Descending}).
+		Order("customers.id")
+	if limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+	if err := query.Scan(&customers).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return customers, count, nil
+}
+
+func (r *customerRepository) GetSummary(ctx context.Context, id uint) (*CustomerSummary, error) {
+	var customers []CustomerSummary
+	err := joinOrderStats(r.db.WithContext(ctx).Model(&models.Customer{})).
+		Select(customerSummaryColumns).
+		Where("customers.id = ?", id).
+		Limit(1).
+		Scan(&customers).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(customers) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &customers[0], nil
+}
+
+// Suspend shuts the customer out. It reports false when the customer does
+// not exist or is already suspended.
+func (r *customerRepository) Suspend(ctx context.Context, id uint, at time.Time, reason string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Customer{}).
+		Where("id = ? AND suspended_at IS NULL", id).
+		Updates(map[string]interface{}{"suspended_at": at, "suspension_reason": reason})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Reactivate lifts a suspension. It reports false when the customer does not
+// exist or is not suspended.
+func (r *customerRepository) Reactivate(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Customer{}).
+		Where("id = ? AND suspended_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"suspended_at": nil, "suspension_reason": ""})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetRole reports false when the customer already has the role.
+func (r *customerRepository) SetRole(ctx context.Context, id uint, role string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Customer{}).
+		Where("id = ? AND role <> ?", id, role).
+		Update("role", role)
+	return result.RowsAffected > 0, result.Error
 }
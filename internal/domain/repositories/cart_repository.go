@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type CartRepository interface {
+	GetByCustomerID(ctx context.Context, customerID uint) (*models.Cart, error)
+	Create(ctx context.Context, cart *models.Cart) error
+	Touch(ctx context.Context, cartID uint, at time.Time) error
+	AddItem(ctx context.Context, item *models.CartItem) error
+	UpdateItem(ctx context.Context, item *models.CartItem) error
+	DeleteItem(ctx context.Context, cartID, itemID uint) error
+	Delete(ctx context.Context, cartID uint) error
+	DeleteIdleSince(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type cartRepository struct {
+	db *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) GetByCustomerID(ctx context.Context, customerID uint) (*models.Cart, error) {
+	var cart models.Cart
+	if err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("customer_id = ?", customerID).
+		First(&cart).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// Create inserts the cart unless the customer already has one, in which case
+// the existing cart is loaded into cart. Two concurrent first adds therefore
+// end up sharing the same cart.
+func (r *cartRepository) Create(ctx context.Context, cart *models.Cart) error {
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "customer_id"}}, DoNothing: true}).
+		Create(cart).Error; err != nil {
+		return err
+	}
+	if cart.ID != 0 {
+		return nil
+	}
+	existing, err := r.GetByCustomerID(ctx, cart.CustomerID)
+	if err != nil {
+		return err
+	}
+	*cart = *existing
+	return nil
+}
+
+func (r *cartRepository) Touch(ctx context.Context, cartID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Cart{}).
+		Where("id = ?", cartID).
+		Update("last_activity_at", at).Error
+}
+
+// AddItem inserts the line, or adds its quantity to the line already holding
+// the same product.
+func (r *cartRepository) AddItem(ctx context.Context, item *models.CartItem) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "cart_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"quantity":   gorm.Expr("cart_items.quantity + excluded.quantity"),
+				"unit_price": gorm.Expr("excluded.unit_price"),
+				"updated_at": gorm.Expr("excluded.updated_at"),
+			}),
+		}).
+		Create(item).Error
+}
+
+func (r *cartRepository) UpdateItem(ctx context.Context, item *models.CartItem) error {
+	return r.db.WithContext(ctx).Save(item).Error
+}
+
+// Cart rows are scratch data, so they are removed for good rather than soft
+// deleted; a soft-deleted line would also block the (cart, product) index.
+func (r *cartRepository) DeleteItem(ctx context.Context, cartID, itemID uint) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("cart_id = ?", cartID).
+		Delete(&models.CartItem{}, itemID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (r *cartRepository) Delete(ctx context.Context, cartID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("cart_id = ?", cartID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Cart{}, cartID).Error
+	})
+}
+
+// DeleteIdleSince removes every cart whose last activity is older than cutoff
+// and reports how many carts were dropped.
+func (r *cartRepository) DeleteIdleSince(ctx context.Context, cutoff time.Time) (int64, error) {
+	var removed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		idle := tx.Unscoped().Model(&models.Cart{}).Select("id").Where("last_activity_at < ?", cutoff)
+		if err := tx.Unscoped().Where("cart_id IN (?)", idle).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("last_activity_at < ?", cutoff).Delete(&models.Cart{})
+		removed = result.RowsAffected
+		return result.Error
+	})
+	return removed, err
+}
@@ -1,20 +1,24 @@
 package repositories
 
 import (
+	"context"
+
 	"gorm.io/gorm"
 
 	"github.com/Mutonya/Savanah/internal/domain/models"
 )
 
 type CategoryRepository interface {
-	Create(category *models.Category) error
+	Create(ctx context.Context, category *models.Category) error
 	GetByID(id uint) (*models.Category, error)
 	GetAll() ([]models.Category, error)
-	Update(category *models.Category) error
-	Delete(id uint) error
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id uint) error
 	GetProducts(categoryID uint, page, limit int) ([]models.Product, int64, error)
 	GetAveragePrice(categoryID uint) (float64, error)
 	GetSubcategories(parentID uint) ([]models.Category, error)
+	GetDescendantIDs(categoryID uint) ([]uint, error)
+	GetAncestorIDs(categoryID uint) ([]uint, error)
 }
 
 // db: Holds the database connection
@@ -28,9 +32,9 @@ func NewCategoryRepository(db *gorm.DB) CategoryRepository {
 	return &categoryRepository{db: db}
 }
 
-func (r *categoryRepository) Create(category *models.Category) error {
+func (r *categoryRepository) Create(ctx context.Context, category *models.Category) error {
 	//WithContext(ctx) propagate ctx ensures the DB operation can be cancelled or timed out from upstream
-	return r.db.Create(category).Error
+	return r.db.WithContext(ctx).Create(category).Error
 }
 
 // Preload("Children"): Eager loads subcategories
@@ -62,12 +66,12 @@ func (r *categoryRepository) GetAll() ([]models.Category, error) {
 	return categories, nil
 }
 
-func (r *categoryRepository) Update(category *models.Category) error {
-	return r.db.Save(category).Error
+func (r *categoryRepository) Update(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
 }
 
-func (r *categoryRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Category{}, id).Error
+func (r *categoryRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Category{}, id).Error
 }
 
 //Gets products from category + all subcategories
@@ -158,3 +162,38 @@ func (r *categoryRepository) GetSubcategories(parentID uint) ([]models.Category,
 	}
 	return categories, nil
 }
+
+// GetDescendantIDs returns the IDs of every category below categoryID, at any
+// depth, using a recursive CTE.
+func (r *categoryRepository) GetDescendantIDs(categoryID uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE parent_id = ? AND deleted_at IS NULL
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM tree`, categoryID).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetAncestorIDs returns categoryID followed by its parent, grandparent and so
+// on up to the root, nearest first.
+func (r *categoryRepository) GetAncestorIDs(categoryID uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id = ? AND deleted_at IS NULL
+			UNION
+			SELECT c.id, c.parent_id, chain.depth + 1 FROM categories c
+			JOIN chain ON c.id = chain.parent_id WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM chain ORDER BY depth`, categoryID).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
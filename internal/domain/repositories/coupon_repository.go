@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type CouponRepository interface {
+	Create(ctx context.Context, coupon *models.Coupon) error
+	GetByID(ctx context.Context, id uint) (*models.Coupon, error)
+	GetByCode(ctx context.Context, code string) (*models.Coupon, error)
+	GetAll(ctx context.Context, page, limit int) ([]models.Coupon, int64, error)
+	Update(ctx context.Context, coupon *models.Coupon) error
+	Delete(ctx context.Context, id uint) error
+	CountCustomerRedemptions(ctx context.Context, couponID, customerID uint) (int64, error)
+}
+
+type couponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+// Create stores the coupon and links it to its (existing) categories and
+// products without touching those rows.
+func (r *couponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Omit("Categories.*", "Products.*").Create(coupon).Error
+}
+
+func (r *couponRepository) GetByID(ctx context.Context, id uint) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := r.db.WithContext(ctx).Preload("Categories").Preload("Products").
+		First(&coupon, id).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// Codes are matched case-insensitively; they are stored upper-case.
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := r.db.WithContext(ctx).Preload("Categories").Preload("Products").
+		Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).
+		First(&coupon).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) GetAll(ctx context.Context, page, limit int) ([]models.Coupon, int64, error) {
+	var coupons []models.Coupon
+	var count int64
+
+	offset := (page - 1) * limit
+
+	if err := r.db.WithContext(ctx).Model(&models.Coupon{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := r.db.WithContext(ctx).Preload("Categories").Preload("Products").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&coupons).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return coupons, count, nil
+}
+
+// Update saves the coupon and replaces its category and product restrictions.
+// UsedCount is left alone; only redemptions move it.
+func (r *couponRepository) Update(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Categories", "Products", "UsedCount").Save(coupon).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Categories.*").Model(coupon).Association("Categories").Replace(coupon.Categories); err != nil {
+			return err
+		}
+		return tx.Omit("Products.*").Model(coupon).Association("Products").Replace(coupon.Products)
+	})
+}
+
+func (r *couponRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Coupon{}, id).Error
+}
+
+func (r *couponRepository) CountCustomerRedemptions(ctx context.Context, couponID, customerID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.OrderDiscount{}).
+		Joins("JOIN orders ON orders.id = order_discounts.order_id AND orders.deleted_at IS NULL AND orders.status <> ?", models.OrderStatusCancelled).
+		Where("order_discounts.coupon_id = ? AND orders.customer_id = ?", couponID, customerID).
+		Count(&count).Error
+	return count, err
+}
+
+// redeemCoupon counts one use of a coupon inside the order's transaction.
+// The coupon row is locked first, so concurrent checkouts redeeming the same
+// code queue up here and the limits below are checked against settled counts.
+func redeemCoupon(tx *gorm.DB, couponID, customerID uint) error {
+	var coupon models.Coupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, couponID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrCouponNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return models.ErrCouponExhausted
+	}
+
+	if coupon.PerCustomerLimit > 0 {
+		var used int64
+		if err := tx.Model(&models.OrderDiscount{}).
+			Joins("JOIN orders ON orders.id = order_discounts.order_id AND orders.deleted_at IS NULL AND orders.status <> ?", models.OrderStatusCancelled).
+			Where("order_discounts.coupon_id = ? AND orders.customer_id = ?", couponID, customerID).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(coupon.PerCustomerLimit) {
+			return models.ErrCouponCustomerLimit
+		}
+	}
+
+	return tx.Model(&models.Coupon{}).Where("id = ?", couponID).
+		Update("used_count", gorm.Expr("used_count + 1")).Error
+}
@@ -1,6 +1,7 @@
 package repositories_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,7 +49,6 @@ func (suite *CustomerRepositoryTestSuite) SetupTest() {
 		LastName:  "Doe",
 		Email:     "john@example.com",
 		Phone:     "+1234567890",
-		OAuthID:   "oauth123",
 	}
 }
 
@@ -60,28 +60,28 @@ func TestCustomerRepositorySuite(t *testing.T) {
 	suite.Run(t, new(CustomerRepositoryTestSuite))
 }
 
-func (suite *CustomerRepositoryTestSuite) TestGetByOAuthID_Success() {
-	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "phone", "oauth_id"}).
+func (suite *CustomerRepositoryTestSuite) TestGetByEmail_Success() {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "phone"}).
 		AddRow(suite.testCust.ID, suite.testCust.FirstName, suite.testCust.LastName,
-			suite.testCust.Email, suite.testCust.Phone, suite.testCust.OAuthID)
+			suite.testCust.Email, suite.testCust.Phone)
 
-	suite.mock.ExpectQuery(`SELECT \* FROM "customers" WHERE oauth_id = \$1`).
-		WithArgs(suite.testCust.OAuthID).
+	suite.mock.ExpectQuery(`SELECT \* FROM "customers" WHERE LOWER\(email\) = LOWER\(\$1\)`).
+		WithArgs("John@Example.com").
 		WillReturnRows(rows)
 
-	customer, err := suite.repo.GetByOAuthID(suite.testCust.OAuthID)
+	customer, err := suite.repo.GetByEmail(context.Background(), "John@Example.com")
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), suite.testCust.ID, customer.ID)
 	assert.Equal(suite.T(), suite.testCust.Email, customer.Email)
 }
 
-func (suite *CustomerRepositoryTestSuite) TestGetByOAuthID_NotFound() {
-	suite.mock.ExpectQuery(`SELECT \* FROM "customers" WHERE oauth_id = \$1`).
-		WithArgs("nonexistent").
+func (suite *CustomerRepositoryTestSuite) TestGetByEmail_NotFound() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "customers" WHERE LOWER\(email\) = LOWER\(\$1\)`).
+		WithArgs("nobody@example.com").
 		WillReturnError(gorm.ErrRecordNotFound)
 
-	_, err := suite.repo.GetByOAuthID("nonexistent")
+	_, err := suite.repo.GetByEmail(context.Background(), "nobody@example.com")
 
 	assert.ErrorIs(suite.T(), err, gorm.ErrRecordNotFound)
 }
@@ -112,7 +112,6 @@ func (suite *CustomerRepositoryTestSuite) TestCreate_Success() {
 			suite.testCust.Email,
 			suite.testCust.Phone,
 			"", // address
-			suite.testCust.OAuthID,
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	suite.mock.ExpectCommit()
@@ -132,7 +131,6 @@ func (suite *CustomerRepositoryTestSuite) TestUpdate_Success() {
 			suite.testCust.Email,
 			suite.testCust.Phone,
 			"", // address
-			suite.testCust.OAuthID,
 			suite.testCust.ID,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
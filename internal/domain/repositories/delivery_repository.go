@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type DeliveryRepository interface {
+	CreateZone(ctx context.Context, zone *models.DeliveryZone) error
+	GetZone(ctx context.Context, id uint) (*models.DeliveryZone, error)
+	GetZones(ctx context.Context) ([]models.DeliveryZone, error)
+	UpdateZone(ctx context.Context, zone *models.DeliveryZone) error
+	DeleteZone(ctx context.Context, id uint) error
+	FindZone(ctx context.Context, county, town string) (*models.DeliveryZone, error)
+}
+
+type deliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewDeliveryRepository(db *gorm.DB) DeliveryRepository {
+	return &deliveryRepository{db: db}
+}
+
+func (r *deliveryRepository) CreateZone(ctx context.Context, zone *models.DeliveryZone) error {
+	return r.db.WithContext(ctx).Create(zone).Error
+}
+
+func (r *deliveryRepository) GetZone(ctx context.Context, id uint) (*models.DeliveryZone, error) {
+	var zone models.DeliveryZone
+	if err := r.preloaded(ctx).First(&zone, id).Error; err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (r *deliveryRepository) GetZones(ctx context.Context) ([]models.DeliveryZone, error) {
+	var zones []models.DeliveryZone
+	err := r.preloaded(ctx).Order("name").Find(&zones).Error
+	return zones, err
+}
+
+// UpdateZone renames the zone and replaces its areas and rates.
+func (r *deliveryRepository) UpdateZone(ctx context.Context, zone *models.DeliveryZone) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteZoneChildren(tx, zone.ID); err != nil {
+			return err
+		}
+		for i := range zone.Areas {
+			zone.Areas[i].ID = 0
+		}
+		for i := range zone.Rates {
+			zone.Rates[i].ID = 0
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(zone).Error
+	})
+}
+
+// DeleteZone removes the zone's areas outright, so the places can be assigned
+// to another zone, and soft-deletes the zone itself.
+func (r *deliveryRepository) DeleteZone(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteZoneChildren(tx, id); err != nil {
+			return err
+		}
+		result := tx.Delete(&models.DeliveryZone{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// FindZone returns the zone covering the town, falling back to the zone
+// covering its whole county. Matching is case-insensitive.
+func (r *deliveryRepository) FindZone(ctx context.Context, county, town string) (*models.DeliveryZone, error) {
+	var area models.DeliveryArea
+	if err := r.db.WithContext(ctx).
+		Where("LOWER(county) = LOWER(?) AND (LOWER(town) = LOWER(?) OR town = '')", county, town).
+		Order("town DESC").
+		First(&area).Error; err != nil {
+		return nil, err
+	}
+	return r.GetZone(ctx, area.ZoneID)
+}
+
+func (r *deliveryRepository) preloaded(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Preload("Areas", func(db *gorm.DB) *gorm.DB { return db.Order("county, town") }).
+		Preload("Rates", func(db *gorm.DB) *gorm.DB { return db.Order("max_weight_kg") })
+}
+
+func deleteZoneChildren(tx *gorm.DB, zoneID uint) error {
+	if err := tx.Where("zone_id = ?", zoneID).Delete(&models.DeliveryArea{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("zone_id = ?", zoneID).Delete(&models.DeliveryRate{}).Error
+}
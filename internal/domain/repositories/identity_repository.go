@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type IdentityRepository interface {
+	Get(ctx context.Context, provider, subject string) (*models.Identity, error)
+	GetByCustomer(ctx context.Context, customerID uint) ([]models.Identity, error)
+	Create(ctx context.Context, identity *models.Identity) error
+	Register(ctx context.Context, customer *models.Customer, identity *models.Identity) error
+	TouchLogin(ctx context.Context, id uint, at time.Time) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Get(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) GetByCustomer(ctx context.Context, customerID uint) ([]models.Identity, error) {
+	var identities []models.Identity
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at").
+		Find(&identities).Error
+	return identities, err
+}
+
+// Create links an identity to an existing customer. It returns
+// gorm.ErrDuplicatedKey when the identity is already linked.
+func (r *identityRepository) Create(ctx context.Context, identity *models.Identity) error {
+	return r.db.WithContext(ctx).Omit("Customer").Create(identity).Error
+}
+
+// Register creates a customer together with the identity they signed up
+// with, so there is never a customer nobody can sign in as.
+func (r *identityRepository) Register(ctx context.Context, customer *models.Customer, identity *models.Identity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(customer).Error; err != nil {
+			return err
+		}
+		identity.CustomerID = customer.ID
+		return tx.Omit("Customer").Create(identity).Error
+	})
+}
+
+func (r *identityRepository) TouchLogin(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Identity{}).Where("id = ?", id).Update("last_login_at", at).Error
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type InvoiceRepository interface {
+	GetByOrderID(ctx context.Context, orderID uint) (*models.Invoice, error)
+	Issue(ctx context.Context, invoice *models.Invoice) error
+}
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) GetByOrderID(ctx context.Context, orderID uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// Issue numbers the invoice and saves it. The year's counter is bumped with
+// an upsert that holds its row lock until the transaction ends, so concurrent
+// invoices queue for their numbers, and a rolled-back save (for example a
+// second invoice for the same order, rejected by the unique index) returns
+// its number to the series instead of leaving a gap.
+func (r *invoiceRepository) Issue(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		series := models.InvoiceSeries(invoice.Year)
+
+		var next uint
+		if err := tx.Raw(`INSERT INTO invoice_counters (series, last_number) VALUES (?, 1)
+			ON CONFLICT (series) DO UPDATE SET last_number = invoice_counters.last_number + 1
+			RETURNING last_number`, series).Scan(&next).Error; err != nil {
+			return err
+		}
+
+		invoice.Sequence = next
+		invoice.Number = models.InvoiceNumber(invoice.Year, next)
+		return tx.Create(invoice).Error
+	})
+}
@@ -2,7 +2,12 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Mutonya/Savanah/internal/domain/models"
 )
@@ -10,9 +15,34 @@ import (
 type OrderRepository interface {
 	Create(ctx context.Context, order *models.Order) error
 	GetByID(ctx context.Context, id uint) (*models.Order, error)
+	GetByReference(ctx context.Context, reference string) (*models.Order, error)
 	GetByCustomerID(ctx context.Context, customerID uint, page, limit int) ([]models.Order, int64, error)
+	Search(ctx context.Context, filter OrderFilter, page, limit int) ([]models.Order, int64, error)
 	Update(ctx context.Context, order *models.Order) error
 	UpdateStatus(ctx context.Context, orderID uint, status models.OrderStatus) error
+	TransitionStatus(ctx context.Context, orderID uint, from, to models.OrderStatus) (bool, error)
+	Cancel(ctx context.Context, orderID uint, reason string) (models.OrderStatus, error)
+	HasPurchased(ctx context.Context, customerID, productID uint) (bool, error)
+}
+
+// OrderFilter narrows a search over all orders; zero fields are ignored.
+// Orders placed from From up to (not including) To match. Sort is one of the
+// keys of orderSortColumns, newest first by default.
+type OrderFilter struct {
+	Status     models.OrderStatus
+	From       time.Time
+	To         time.Time
+	CustomerID uint
+	MinTotal   float64
+	ProductID  uint
+	Sort       string
+	Descending bool
+}
+
+var orderSortColumns = map[string]string{
+	"created_at": "orders.created_at",
+	"total":      "orders.total",
+	"status":     "orders.status",
 }
 
 type orderRepository struct {
@@ -23,8 +53,43 @@ func NewOrderRepository(db *gorm.DB) OrderRepository {
 	return &orderRepository{db: db}
 }
 
+// orderReferenceAttempts bounds the retries when a new order's random
+// reference is already taken.
+const orderReferenceAttempts = 5
+
+// Create gives the order a fresh reference and saves it, drawing another
+// reference if the unique index rejects the first.
 func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
-	return r.db.WithContext(ctx).Create(order).Error
+	for attempt := 1; ; attempt++ {
+		order.Reference = models.NewOrderReference()
+		err := r.create(ctx, order)
+		if errors.Is(err, gorm.ErrDuplicatedKey) && attempt < orderReferenceAttempts {
+			continue
+		}
+		return err
+	}
+}
+
+// create saves the order with its items and discounts in one transaction,
+// taking the items out of stock and redeeming any coupon it uses, so a
+// shortage or rejected redemption leaves no order behind.
+func (r *orderRepository) create(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range order.OrderItems {
+			if err := takeStock(tx, item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+		for _, discount := range order.Discounts {
+			if discount.CouponID == nil {
+				continue
+			}
+			if err := redeemCoupon(tx, *discount.CouponID, order.CustomerID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(order).Error
+	})
 }
 
 func (r *orderRepository) GetByID(ctx context.Context, id uint) (*models.Order, error) {
@@ -32,12 +97,26 @@ func (r *orderRepository) GetByID(ctx context.Context, id uint) (*models.Order,
 	if err := r.db.WithContext(ctx).Preload("Customer").
 		Preload("OrderItems").
 		Preload("OrderItems.Product").
+		Preload("Discounts").
 		First(&order, id).Error; err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
 
+func (r *orderRepository) GetByReference(ctx context.Context, reference string) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.WithContext(ctx).Preload("Customer").
+		Preload("OrderItems").
+		Preload("OrderItems.Product").
+		Preload("Discounts").
+		Where("reference = ?", reference).
+		First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
 func (r *orderRepository) GetByCustomerID(ctx context.Context, customerID uint, page, limit int) ([]models.Order, int64, error) {
 	var orders []models.Order
 	var count int64
@@ -57,6 +136,54 @@ func (r *orderRepository) GetByCustomerID(ctx context.Context, customerID uint,
 	return orders, count, nil
 }
 
+// Search lists orders across all customers. A limit of zero returns every
+// match, for exports.
+func (r *orderRepository) Search(ctx context.Context, filter OrderFilter, page, limit int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	var count int64
+
+	query := r.db.WithContext(ctx).Model(&models.Order{})
+	if filter.Status != "" {
+		query = query.Where("orders.status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("orders.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("orders.created_at < ?", filter.To)
+	}
+	if filter.CustomerID != 0 {
+		query = query.Where("orders.customer_id = ?", filter.CustomerID)
+	}
+	if filter.MinTotal > 0 {
+		query = query.Where("orders.total >= ?", filter.MinTotal)
+	}
+	if filter.ProductID != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.product_id = ? AND order_items.deleted_at IS NULL)", filter.ProductID)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := orderSortColumns[filter.Sort]
+	if !ok {
+		column, filter.Descending = orderSortColumns["created_at"], true
+	}
+	query = query.Preload("Customer").
+		Preload("OrderItems").
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column, Raw: true}, Desc: filter.Descending}).
+		Order("orders.id")
+	if limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+	if err := query.Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return orders, count, nil
+}
+
 func (r *orderRepository) Update(ctx context.Context, order *models.Order) error {
 	return r.db.WithContext(ctx).Save(order).Error
 }
@@ -66,3 +193,91 @@ func (r *orderRepository) UpdateStatus(ctx context.Context, orderID uint, status
 		Where("id = ?", orderID).
 		Update("status", status).Error
 }
+
+// TransitionStatus moves the order from one status to another, and reports
+// false when the order was no longer in the from status.
+func (r *orderRepository) TransitionStatus(ctx context.Context, orderID uint, from, to models.OrderStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("id = ? AND status = ?", orderID, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Cancel cancels an order that has not shipped, puts its items back in stock
+// and gives back its coupon redemptions, all in one transaction. The order row
+// is locked so a concurrent payment or shipment cannot slip in between. It
+// returns the status the order had under that lock, e.g. to tell whether it
+// was paid for.
+func (r *orderRepository) Cancel(ctx context.Context, orderID uint, reason string) (models.OrderStatus, error) {
+	var previous models.OrderStatus
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("OrderItems").Preload("Discounts").
+			First(&order, orderID).Error; err != nil {
+			return err
+		}
+		if !order.Status.Cancellable() {
+			return models.ErrOrderNotCancellable
+		}
+		previous = order.Status
+
+		now := time.Now()
+		if err := tx.Model(&order).Updates(map[string]interface{}{
+			"status":        models.OrderStatusCancelled,
+			"cancelled_at":  &now,
+			"cancel_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+
+		for _, item := range order.OrderItems {
+			if err := restock(tx, item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+		for _, discount := range order.Discounts {
+			if discount.CouponID == nil {
+				continue
+			}
+			if err := tx.Model(&models.Coupon{}).Where("id = ? AND used_count > 0", *discount.CouponID).
+				Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return previous, err
+}
+
+// HasPurchased reports whether the customer has a completed order containing
+// the product.
+func (r *orderRepository) HasPurchased(ctx context.Context, customerID, productID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Joins("JOIN order_items ON order_items.order_id = orders.id AND order_items.deleted_at IS NULL").
+		Where("orders.customer_id = ? AND orders.status = ? AND order_items.product_id = ?",
+			customerID, models.OrderStatusCompleted, productID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// takeStock decrements a product's stock, failing rather than going negative.
+func takeStock(tx *gorm.DB, productID uint, quantity int) error {
+	result := tx.Model(&models.Product{}).
+		Where("id = ? AND (stock IS NULL OR stock >= ?)", productID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w for product %d", models.ErrInsufficientStock, productID)
+	}
+	return nil
+}
+
+func restock(tx *gorm.DB, productID uint, quantity int) error {
+	return tx.Unscoped().Model(&models.Product{}).
+		Where("id = ?", productID).
+		Update("stock", gorm.Expr("stock + ?", quantity)).Error
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type OTPRepository interface {
+	Get(ctx context.Context, purpose models.OTPPurpose, subject string) (*models.OTP, error)
+	Save(ctx context.Context, otp *models.OTP) error
+	RecordAttempt(ctx context.Context, id uint, maxAttempts int) (bool, error)
+	Delete(ctx context.Context, id uint) (bool, error)
+	DeleteFor(ctx context.Context, purpose models.OTPPurpose, subject string) error
+	DeleteStale(ctx context.Context, sentBefore time.Time) (int64, error)
+}
+
+type otpRepository struct {
+	db *gorm.DB
+}
+
+func NewOTPRepository(db *gorm.DB) OTPRepository {
+	return &otpRepository{db: db}
+}
+
+func (r *otpRepository) Get(ctx context.Context, purpose models.OTPPurpose, subject string) (*models.OTP, error) {
+	var otp models.OTP
+	if err := r.db.WithContext(ctx).
+		Where("purpose = ? AND subject = ?", purpose, subject).
+		First(&otp).Error; err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// Save stores a freshly sent code, replacing the purpose and subject's
+// previous one.
+func (r *otpRepository) Save(ctx context.Context, otp *models.OTP) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "purpose"}, {Name: "subject"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"phone", "code_hash", "attempts", "sends", "window_started_at", "last_sent_at", "expires_at",
+		}),
+	}).Create(otp).Error
+}
+
+// RecordAttempt counts a verification attempt, and reports false when the
+// code has already used up its attempts. Counting before comparing keeps
+// concurrent guesses within the limit.
+func (r *otpRepository) RecordAttempt(ctx context.Context, id uint, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.OTP{}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete uses up a code. It reports false when the code was already gone.
+func (r *otpRepository) Delete(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&models.OTP{}, id)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *otpRepository) DeleteFor(ctx context.Context, purpose models.OTPPurpose, subject string) error {
+	return r.db.WithContext(ctx).
+		Where("purpose = ? AND subject = ?", purpose, subject).
+		Delete(&models.OTP{}).Error
+}
+
+// DeleteStale removes codes last sent before the given time.
+func (r *otpRepository) DeleteStale(ctx context.Context, sentBefore time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("last_sent_at < ?", sentBefore).Delete(&models.OTP{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+	GetByProviderReference(ctx context.Context, provider, reference string) (*models.Payment, error)
+	GetByOrderID(ctx context.Context, orderID uint) ([]models.Payment, error)
+	GetPendingBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Payment, error)
+	Settle(ctx context.Context, payment *models.Payment) (bool, error)
+
+	ReserveRefund(ctx context.Context, paymentID uint, amount float64) (bool, error)
+	ReleaseRefund(ctx context.Context, paymentID uint, amount float64) error
+	CreateRefund(ctx context.Context, refund *models.Refund) error
+	GetUnsentRefundsBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Refund, error)
+	GetRefundByProviderReference(ctx context.Context, reference string) (*models.Refund, error)
+	SettleRefund(ctx context.Context, refund *models.Refund) (bool, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) GetByProviderReference(ctx context.Context, provider, reference string) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.WithContext(ctx).
+		Where("provider = ? AND provider_reference = ?", provider, reference).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetByOrderID(ctx context.Context, orderID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := r.db.WithContext(ctx).
+		Preload("Refunds").
+		Where("order_id = ?", orderID).
+		Order("created_at DESC").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// GetPendingBefore returns the oldest attempts still pending at cutoff, for
+// reconciliation against the provider.
+func (r *paymentRepository) GetPendingBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", models.PaymentStatusPending, cutoff).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// Settle writes the outcome of a payment, but only while it is still pending.
+// It reports false when a callback or the reconciler got there first, which
+// makes duplicate provider notifications harmless.
+func (r *paymentRepository) Settle(ctx context.Context, payment *models.Payment) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, models.PaymentStatusPending).
+		Updates(map[string]interface{}{
+			"status":                  payment.Status,
+			"provider_transaction_id": payment.ProviderTransactionID,
+			"result_code":             payment.ResultCode,
+			"result_desc":             payment.ResultDesc,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReserveRefund earmarks amount of a settled payment for a refund. The check
+// and the increment are one statement, so concurrent refunds can never add up
+// to more than was paid.
+func (r *paymentRepository) ReserveRefund(ctx context.Context, paymentID uint, amount float64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ? AND status = ? AND refunded_amount + ? <= amount",
+			paymentID, models.PaymentStatusSucceeded, amount).
+		Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseRefund gives back an amount reserved for a refund that failed.
+func (r *paymentRepository) ReleaseRefund(ctx context.Context, paymentID uint, amount float64) error {
+	return r.db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ?", paymentID).
+		Update("refunded_amount", gorm.Expr("GREATEST(refunded_amount - ?, 0)", amount)).Error
+}
+
+func (r *paymentRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Create(refund).Error
+}
+
+// GetUnsentRefundsBefore returns the oldest refunds created before cutoff
+// that the provider never acknowledged, with the payments they pay back.
+func (r *paymentRepository) GetUnsentRefundsBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Refund, error) {
+	var refunds []models.Refund
+	if err := r.db.WithContext(ctx).
+		Preload("Payment").
+		Where("status = ? AND COALESCE(provider_reference, '') = '' AND created_at < ?", models.PaymentStatusPending, cutoff).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *paymentRepository) GetRefundByProviderReference(ctx context.Context, reference string) (*models.Refund, error) {
+	var refund models.Refund
+	if err := r.db.WithContext(ctx).
+		Where("provider_reference = ?", reference).
+		First(&refund).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// SettleRefund records the outcome of a pending refund, like Settle does for
+// payments.
+func (r *paymentRepository) SettleRefund(ctx context.Context, refund *models.Refund) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Where("id = ? AND status = ?", refund.ID, models.PaymentStatusPending).
+		Updates(map[string]interface{}{
+			"status":                  refund.Status,
+			"provider_reference":      refund.ProviderReference,
+			"provider_transaction_id": refund.ProviderTransactionID,
+			"result_desc":             refund.ResultDesc,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -10,6 +10,7 @@ import (
 type ProductRepository interface {
 	Create(ctx context.Context, product *models.Product) error
 	GetByID(ctx context.Context, id uint) (*models.Product, error)
+	GetBySKU(ctx context.Context, sku string) (*models.Product, error)
 	GetAll(ctx context.Context, page, limit int) ([]models.Product, int64, error)
 	Update(ctx context.Context, product *models.Product) error
 	Delete(ctx context.Context, id uint) error
@@ -46,6 +47,14 @@ func (r *productRepository) GetByID(ctx context.Context, id uint) (*models.Produ
 	return &product, nil
 }
 
+func (r *productRepository) GetBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.WithContext(ctx).Preload("Category").Where("sku = ?", sku).First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
 func (r *productRepository) GetAll(ctx context.Context, page, limit int) ([]models.Product, int64, error) {
 	var products []models.Product
 	var count int64
@@ -66,8 +75,10 @@ func (r *productRepository) GetAll(ctx context.Context, page, limit int) ([]mode
 	return products, count, nil
 }
 
+// Update saves the product's own columns. The Category loaded with it is left
+// out, as saving it would put back the category it was loaded with.
 func (r *productRepository) Update(ctx context.Context, product *models.Product) error {
-	return r.db.WithContext(ctx).Save(product).Error
+	return r.db.WithContext(ctx).Omit("Category").Save(product).Error
 }
 
 func (r *productRepository) Delete(ctx context.Context, id uint) error {
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type ReturnRepository interface {
+	Create(ctx context.Context, rma *models.ReturnRequest) error
+	GetByID(ctx context.Context, id uint) (*models.ReturnRequest, error)
+	GetByOrderID(ctx context.Context, orderID uint) ([]models.ReturnRequest, error)
+	GetAll(ctx context.Context, status models.ReturnStatus, page, limit int) ([]models.ReturnRequest, int64, error)
+	Review(ctx context.Context, id uint, status models.ReturnStatus, note string) (bool, error)
+	MarkReceived(ctx context.Context, id uint, refundAmount float64) (bool, error)
+}
+
+type returnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) ReturnRepository {
+	return &returnRepository{db: db}
+}
+
+// Create stores a return after checking, with the order locked, that no line
+// is returned more times than it was bought across all live returns. Locking
+// the order serialises concurrent requests against the same order.
+func (r *returnRepository) Create(ctx context.Context, rma *models.ReturnRequest) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("OrderItems").First(&order, rma.OrderID).Error; err != nil {
+			return err
+		}
+		if !order.Status.Returnable() {
+			return models.ErrOrderNotReturnable
+		}
+
+		var pending []struct {
+			OrderItemID uint
+			Quantity    int
+		}
+		if err := tx.Model(&models.ReturnItem{}).
+			Select("return_items.order_item_id, SUM(return_items.quantity) AS quantity").
+			Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id").
+			Where("return_requests.order_id = ? AND return_requests.status <> ? AND return_requests.deleted_at IS NULL",
+				rma.OrderID, models.ReturnStatusRejected).
+			Group("return_items.order_item_id").
+			Scan(&pending).Error; err != nil {
+			return err
+		}
+		returned := make(map[uint]int, len(pending))
+		for _, p := range pending {
+			returned[p.OrderItemID] = p.Quantity
+		}
+
+		ordered := make(map[uint]int, len(order.OrderItems))
+		for _, item := range order.OrderItems {
+			ordered[item.ID] = item.Quantity
+		}
+		for _, item := range rma.Items {
+			quantity, ok := ordered[item.OrderItemID]
+			if !ok {
+				return models.ErrReturnItemInvalid
+			}
+			returned[item.OrderItemID] += item.Quantity
+			if returned[item.OrderItemID] > quantity {
+				return models.ErrReturnQuantityExceeded
+			}
+		}
+
+		return tx.Omit("Items.OrderItem").Create(rma).Error
+	})
+}
+
+func (r *returnRepository) GetByID(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	var rma models.ReturnRequest
+	if err := r.db.WithContext(ctx).Preload("Items.OrderItem.Product").First(&rma, id).Error; err != nil {
+		return nil, err
+	}
+	return &rma, nil
+}
+
+func (r *returnRepository) GetByOrderID(ctx context.Context, orderID uint) ([]models.ReturnRequest, error) {
+	var rmas []models.ReturnRequest
+	err := r.db.WithContext(ctx).Preload("Items.OrderItem.Product").
+		Where("order_id = ?", orderID).
+		Order("created_at DESC").
+		Find(&rmas).Error
+	return rmas, err
+}
+
+// GetAll lists returns for staff, oldest first so the queue is worked in
+// order. An empty status lists every return.
+func (r *returnRepository) GetAll(ctx context.Context, status models.ReturnStatus, page, limit int) ([]models.ReturnRequest, int64, error) {
+	var rmas []models.ReturnRequest
+	var count int64
+
+	query := r.db.WithContext(ctx).Model(&models.ReturnRequest{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("Items.OrderItem.Product").
+		Order("created_at").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&rmas).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return rmas, count, nil
+}
+
+// Review approves or rejects a requested return. It reports false when the
+// return had already been reviewed.
+func (r *returnRepository) Review(ctx context.Context, id uint, status models.ReturnStatus, note string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.ReturnRequest{}).
+		Where("id = ? AND status = ?", id, models.ReturnStatusRequested).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"staff_note":  note,
+			"reviewed_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkReceived records that the goods of an approved return arrived and puts
+// them back in stock. Once every unit of the order has come back the order
+// itself becomes returned. It reports false when the return was not approved.
+func (r *returnRepository) MarkReceived(ctx context.Context, id uint, refundAmount float64) (bool, error) {
+	received := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rma models.ReturnRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items.OrderItem").First(&rma, id).Error; err != nil {
+			return err
+		}
+		if rma.Status != models.ReturnStatusApproved {
+			return nil
+		}
+
+		if err := tx.Model(&rma).Updates(map[string]interface{}{
+			"status":        models.ReturnStatusReceived,
+			"received_at":   time.Now(),
+			"refund_amount": refundAmount,
+		}).Error; err != nil {
+			return err
+		}
+		for _, item := range rma.Items {
+			if err := restock(tx, item.OrderItem.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+
+		var outstanding int64
+		if err := tx.Raw(`
+			SELECT COALESCE(SUM(oi.quantity), 0) - COALESCE((
+				SELECT SUM(ri.quantity) FROM return_items ri
+				JOIN return_requests rr ON rr.id = ri.return_request_id
+				WHERE rr.order_id = ? AND rr.status = ? AND rr.deleted_at IS NULL
+			), 0)
+			FROM order_items oi WHERE oi.order_id = ? AND oi.deleted_at IS NULL`,
+			rma.OrderID, models.ReturnStatusReceived, rma.OrderID).
+			Scan(&outstanding).Error; err != nil {
+			return err
+		}
+		if outstanding == 0 {
+			if err := tx.Model(&models.Order{}).Where("id = ?", rma.OrderID).
+				Update("status", models.OrderStatusReturned).Error; err != nil {
+				return err
+			}
+		}
+
+		received = true
+		return nil
+	})
+	return received, err
+}
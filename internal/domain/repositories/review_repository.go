@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type ReviewRepository interface {
+	Create(ctx context.Context, review *models.Review) error
+	GetByID(ctx context.Context, id uint) (*models.Review, error)
+	GetByProduct(ctx context.Context, productID uint, page, limit int) ([]models.Review, int64, error)
+	GetByCustomer(ctx context.Context, customerID uint) ([]models.Review, error)
+	GetByStatus(ctx context.Context, status models.ReviewStatus, page, limit int) ([]models.Review, int64, error)
+	Moderate(ctx context.Context, id uint, status models.ReviewStatus, note string) (bool, error)
+	Delete(ctx context.Context, id uint) error
+	AddVote(ctx context.Context, reviewID, customerID uint) (bool, error)
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Create(review).Error
+}
+
+func (r *reviewRepository) GetByID(ctx context.Context, id uint) (*models.Review, error) {
+	var review models.Review
+	if err := r.db.WithContext(ctx).First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetByProduct lists a product's approved reviews, most helpful first.
+func (r *reviewRepository) GetByProduct(ctx context.Context, productID uint, page, limit int) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	var count int64
+
+	query := r.db.WithContext(ctx).Model(&models.Review{}).
+		Where("product_id = ? AND status = ?", productID, models.ReviewStatusApproved)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("helpful_count DESC").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, count, nil
+}
+
+func (r *reviewRepository) GetByCustomer(ctx context.Context, customerID uint) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+// GetByStatus lists reviews for moderation, oldest first. An empty status
+// lists every review.
+func (r *reviewRepository) GetByStatus(ctx context.Context, status models.ReviewStatus, page, limit int) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	var count int64
+
+	query := r.db.WithContext(ctx).Model(&models.Review{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, count, nil
+}
+
+// Moderate moves a review to approved or rejected and adds it to, or takes it
+// out of, its product's rating in the same transaction. It reports false when
+// the review already had that status.
+func (r *reviewRepository) Moderate(ctx context.Context, id uint, status models.ReviewStatus, note string) (bool, error) {
+	moderated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error; err != nil {
+			return err
+		}
+		if review.Status == status {
+			return nil
+		}
+
+		if err := tx.Model(&review).Updates(map[string]interface{}{
+			"status":          status,
+			"moderation_note": note,
+			"moderated_at":    time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case status == models.ReviewStatusApproved:
+			if err := adjustRating(tx, review.ProductID, review.Rating, 1); err != nil {
+				return err
+			}
+		case review.Status == models.ReviewStatusApproved:
+			if err := adjustRating(tx, review.ProductID, -review.Rating, -1); err != nil {
+				return err
+			}
+		}
+
+		moderated = true
+		return nil
+	})
+	return moderated, err
+}
+
+// Delete removes a review and its votes, taking it out of its product's
+// rating if it was approved.
+func (r *reviewRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("review_id = ?", id).Delete(&models.ReviewVote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		if review.Status == models.ReviewStatusApproved {
+			return adjustRating(tx, review.ProductID, -review.Rating, -1)
+		}
+		return nil
+	})
+}
+
+// AddVote records the customer's helpful vote and bumps the review's count.
+// It reports false when the customer had already voted.
+func (r *reviewRepository) AddVote(ctx context.Context, reviewID, customerID uint) (bool, error) {
+	added := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.ReviewVote{ReviewID: reviewID, CustomerID: customerID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Model(&models.Review{}).Where("id = ?", reviewID).
+			UpdateColumn("helpful_count", gorm.Expr("helpful_count + 1")).Error; err != nil {
+			return err
+		}
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+// adjustRating adds a rating to (or, with negative deltas, removes one from)
+// a product's running total and count, and recomputes the average from them
+// without rescanning its reviews.
+func adjustRating(tx *gorm.DB, productID uint, rating, count int) error {
+	return tx.Unscoped().Model(&models.Product{}).
+		Where("id = ?", productID).
+		UpdateColumns(map[string]interface{}{
+			"rating_total": gorm.Expr("rating_total + ?", rating),
+			"rating_count": gorm.Expr("rating_count + ?", count),
+			"rating_average": gorm.Expr(
+				"CASE WHEN rating_count + ? > 0 THEN ROUND((rating_total + ?)::numeric / (rating_count + ?), 2) ELSE 0 END",
+				count, rating, count),
+		}).Error
+}
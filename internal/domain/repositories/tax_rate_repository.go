@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type TaxRateRepository interface {
+	Create(ctx context.Context, rate *models.TaxRate) error
+	GetByID(ctx context.Context, id uint) (*models.TaxRate, error)
+	GetAll(ctx context.Context) ([]models.TaxRate, error)
+	Delete(ctx context.Context, id uint) error
+	GetEffective(ctx context.Context, categoryIDs []uint, at time.Time) ([]models.TaxRate, error)
+}
+
+type taxRateRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRateRepository(db *gorm.DB) TaxRateRepository {
+	return &taxRateRepository{db: db}
+}
+
+func (r *taxRateRepository) Create(ctx context.Context, rate *models.TaxRate) error {
+	return r.db.WithContext(ctx).Omit("Category").Create(rate).Error
+}
+
+func (r *taxRateRepository) GetByID(ctx context.Context, id uint) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	if err := r.db.WithContext(ctx).Preload("Category").First(&rate, id).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// GetAll lists every rate version, the store-wide default first, then by
+// category and newest version first.
+func (r *taxRateRepository) GetAll(ctx context.Context) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	err := r.db.WithContext(ctx).Preload("Category").
+		Order("category_id NULLS FIRST").
+		Order("effective_from DESC").
+		Find(&rates).Error
+	return rates, err
+}
+
+func (r *taxRateRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.TaxRate{}, id).Error
+}
+
+// GetEffective returns the rate versions already in effect at the given time
+// for the categories and for the store-wide default, newest first. Callers
+// pick the first row per category.
+func (r *taxRateRepository) GetEffective(ctx context.Context, categoryIDs []uint, at time.Time) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	query := r.db.WithContext(ctx).Where("effective_from <= ?", at)
+	if len(categoryIDs) > 0 {
+		query = query.Where("category_id IN ? OR category_id IS NULL", categoryIDs)
+	} else {
+		query = query.Where("category_id IS NULL")
+	}
+	err := query.Order("effective_from DESC").Order("id DESC").Find(&rates).Error
+	return rates, err
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+type WishlistRepository interface {
+	GetItems(ctx context.Context, customerID uint) ([]models.WishlistItem, error)
+	AddItem(ctx context.Context, item *models.WishlistItem) error
+	RemoveItem(ctx context.Context, customerID, productID uint) (bool, error)
+
+	GetAlerts(ctx context.Context, customerID uint) ([]models.StockAlert, error)
+	SubscribeAlert(ctx context.Context, alert *models.StockAlert) error
+	UnsubscribeAlert(ctx context.Context, customerID, productID uint) (bool, error)
+	GetDueAlerts(ctx context.Context, productID uint) ([]models.StockAlert, error)
+	ClaimAlert(ctx context.Context, id uint, at time.Time) (bool, error)
+	ReleaseAlert(ctx context.Context, id uint) error
+}
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+func (r *wishlistRepository) GetItems(ctx context.Context, customerID uint) ([]models.WishlistItem, error) {
+	var items []models.WishlistItem
+	err := r.db.WithContext(ctx).Preload("Product").
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		Find(&items).Error
+	return items, err
+}
+
+// AddItem saves the product to the wishlist; adding it twice is a no-op.
+func (r *wishlistRepository) AddItem(ctx context.Context, item *models.WishlistItem) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "customer_id"}, {Name: "product_id"}},
+			DoNothing: true,
+		}).
+		Create(item).Error
+}
+
+func (r *wishlistRepository) RemoveItem(ctx context.Context, customerID, productID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("customer_id = ? AND product_id = ?", customerID, productID).
+		Delete(&models.WishlistItem{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetAlerts lists the customer's alerts that have not fired yet.
+func (r *wishlistRepository) GetAlerts(ctx context.Context, customerID uint) ([]models.StockAlert, error) {
+	var alerts []models.StockAlert
+	err := r.db.WithContext(ctx).Preload("Product").
+		Where("customer_id = ? AND notified_at IS NULL", customerID).
+		Order("created_at DESC").
+		Find(&alerts).Error
+	return alerts, err
+}
+
+// SubscribeAlert creates the alert, or re-arms an existing one for the same
+// product with the new channel.
+func (r *wishlistRepository) SubscribeAlert(ctx context.Context, alert *models.StockAlert) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "customer_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"channel":     gorm.Expr("excluded.channel"),
+				"notified_at": nil,
+				"updated_at":  gorm.Expr("excluded.updated_at"),
+			}),
+		}).
+		Create(alert).Error
+}
+
+func (r *wishlistRepository) UnsubscribeAlert(ctx context.Context, customerID, productID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("customer_id = ? AND product_id = ? AND notified_at IS NULL", customerID, productID).
+		Delete(&models.StockAlert{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetDueAlerts lists alerts that have not fired for products that are back in
+// stock, for one product or, when productID is zero, for all of them.
+func (r *wishlistRepository) GetDueAlerts(ctx context.Context, productID uint) ([]models.StockAlert, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Customer").
+		Preload("Product").
+		Joins("JOIN products ON products.id = stock_alerts.product_id AND products.deleted_at IS NULL").
+		Where("stock_alerts.notified_at IS NULL AND products.stock > 0")
+	if productID != 0 {
+		query = query.Where("stock_alerts.product_id = ?", productID)
+	}
+
+	var alerts []models.StockAlert
+	err := query.Order("stock_alerts.created_at").Find(&alerts).Error
+	return alerts, err
+}
+
+// ClaimAlert marks the alert as sent. It reports false when another process
+// already claimed it, so each alert goes out once.
+func (r *wishlistRepository) ClaimAlert(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.StockAlert{}).
+		Where("id = ? AND notified_at IS NULL", id).
+		Update("notified_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseAlert re-arms an alert whose message could not be sent.
+func (r *wishlistRepository) ReleaseAlert(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.StockAlert{}).
+		Where("id = ?", id).
+		Update("notified_at", nil).Error
+}
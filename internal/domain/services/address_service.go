@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type AddressService interface {
+	GetAddresses(ctx context.Context, customerID uint) ([]models.Address, error)
+	CreateAddress(ctx context.Context, customerID uint, req *AddressRequest) (*models.Address, error)
+	UpdateAddress(ctx context.Context, customerID, id uint, req *AddressRequest) (*models.Address, error)
+	DeleteAddress(ctx context.Context, customerID, id uint) error
+	SetDefaultAddress(ctx context.Context, customerID, id uint) error
+	QuoteDelivery(ctx context.Context, customerID, id uint, weightKg float64) (*models.DeliveryQuote, error)
+}
+
+type AddressRequest struct {
+	Label         string `json:"label" binding:"required,max=50"`
+	RecipientName string `json:"recipient_name" binding:"required,max=200"`
+	Phone         string `json:"phone" binding:"required,max=20"`
+	Line1         string `json:"line1" binding:"required,max=255"`
+	Line2         string `json:"line2" binding:"max=255"`
+	Town          string `json:"town" binding:"required,max=100"`
+	County        string `json:"county" binding:"required"`
+	PostalCode    string `json:"postal_code" binding:"max=20"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+type addressService struct {
+	addressRepo repositories.AddressRepository
+	delivery    DeliveryService
+}
+
+func NewAddressService(addressRepo repositories.AddressRepository, delivery DeliveryService) AddressService {
+	return &addressService{addressRepo: addressRepo, delivery: delivery}
+}
+
+func (s *addressService) GetAddresses(ctx context.Context, customerID uint) ([]models.Address, error) {
+	return s.addressRepo.GetByCustomerID(ctx, customerID)
+}
+
+func (s *addressService) CreateAddress(ctx context.Context, customerID uint, req *AddressRequest) (*models.Address, error) {
+	address := &models.Address{CustomerID: customerID}
+	if err := fillAddress(address, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.addressRepo.Create(ctx, address); err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+// UpdateAddress edits an address. Clearing is_default on the default address
+// is ignored; pick another default instead.
+func (s *addressService) UpdateAddress(ctx context.Context, customerID, id uint, req *AddressRequest) (*models.Address, error) {
+	address, err := s.getAddress(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	wasDefault := address.IsDefault
+	if err := fillAddress(address, req); err != nil {
+		return nil, err
+	}
+	address.IsDefault = address.IsDefault || wasDefault
+
+	if err := s.addressRepo.Update(ctx, address); err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+func (s *addressService) DeleteAddress(ctx context.Context, customerID, id uint) error {
+	err := s.addressRepo.Delete(ctx, customerID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrAddressNotFound
+	}
+	return err
+}
+
+func (s *addressService) SetDefaultAddress(ctx context.Context, customerID, id uint) error {
+	err := s.addressRepo.SetDefault(ctx, customerID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrAddressNotFound
+	}
+	return err
+}
+
+// QuoteDelivery prices delivering a basket of the given weight to one of the
+// customer's addresses.
+func (s *addressService) QuoteDelivery(ctx context.Context, customerID, id uint, weightKg float64) (*models.DeliveryQuote, error) {
+	address, err := s.getAddress(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.delivery.Quote(ctx, address.County, address.Town, weightKg)
+}
+
+func (s *addressService) getAddress(ctx context.Context, customerID, id uint) (*models.Address, error) {
+	address, err := s.addressRepo.GetByID(ctx, customerID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrAddressNotFound
+	}
+	return address, err
+}
+
+func fillAddress(address *models.Address, req *AddressRequest) error {
+	county, err := models.CanonicalCounty(req.County)
+	if err != nil {
+		return err
+	}
+
+	address.Label = strings.TrimSpace(req.Label)
+	address.RecipientName = strings.TrimSpace(req.RecipientName)
+	address.Phone = strings.TrimSpace(req.Phone)
+	address.Line1 = strings.TrimSpace(req.Line1)
+	address.Line2 = strings.TrimSpace(req.Line2)
+	address.Town = strings.TrimSpace(req.Town)
+	address.County = county
+	address.PostalCode = strings.TrimSpace(req.PostalCode)
+	address.IsDefault = req.IsDefault
+	return nil
+}
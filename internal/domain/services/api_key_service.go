@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+const (
+	// apiKeyTag starts every key, so leaked keys are easy to recognise and
+	// scan for.
+	apiKeyTag = "sav_"
+	// defaultAPIKeyLifetime applies when a request does not say how long a
+	// key should last.
+	defaultAPIKeyLifetime = 90 * 24 * time.Hour
+	// lastUsedResolution is how stale a key's last-used time may get before
+	// a request updates it.
+	lastUsedResolution = time.Minute
+)
+
+// APIKeyService issues and checks the API keys service accounts call the API
+// with.
+type APIKeyService interface {
+	ListKeys(ctx context.Context, includeRevoked bool) ([]models.APIKey, error)
+	CreateKey(ctx context.Context, staffID uint, req *APIKeyCreateRequest) (*IssuedAPIKey, error)
+	RotateKey(ctx context.Context, staffID, id uint, req *APIKeyRotateRequest) (*IssuedAPIKey, error)
+	RevokeKey(ctx context.Context, id uint) (*models.APIKey, error)
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// APIKeyCreateRequest.Name names the service account, e.g. "ERP". Keys last
+// 90 days unless ExpiresInDays says otherwise.
+type APIKeyCreateRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// APIKeyRotateRequest.GracePeriodMinutes keeps the old key working for a
+// while after rotation, so the service account can switch over without
+// downtime.
+type APIKeyRotateRequest struct {
+	ExpiresInDays      int `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	GracePeriodMinutes int `json:"grace_period_minutes" binding:"min=0,max=10080"`
+}
+
+// IssuedAPIKey is a new key with its plaintext value, which is never shown
+// again.
+type IssuedAPIKey struct {
+	APIKey *models.APIKey `json:"api_key"`
+	Key    string         `json:"key"`
+}
+
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	now        func() time.Time
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		now:        time.Now,
+	}
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context, includeRevoked bool) ([]models.APIKey, error) {
+	return s.apiKeyRepo.List(ctx, includeRevoked)
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, staffID uint, req *APIKeyCreateRequest) (*IssuedAPIKey, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, plain, err := s.newKey(strings.TrimSpace(req.Name), scopes, req.ExpiresInDays, staffID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &IssuedAPIKey{APIKey: key, Key: plain}, nil
+}
+
+// RotateKey issues a key with the same name and scopes as key id and retires
+// the old one once the grace period is over. Expired keys can be rotated;
+// revoked or already rotated ones cannot.
+func (s *apiKeyService) RotateKey(ctx context.Context, staffID, id uint, req *APIKeyRotateRequest) (*IssuedAPIKey, error) {
+	old, err := s.getKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil || old.ReplacedByID != nil {
+		return nil, models.ErrAPIKeyRetired
+	}
+
+	key, plain, err := s.newKey(old.Name, old.Scopes, req.ExpiresInDays, staffID)
+	if err != nil {
+		return nil, err
+	}
+	retireAt := s.now().Add(time.Duration(req.GracePeriodMinutes) * time.Minute)
+	rotated, err := s.apiKeyRepo.Rotate(ctx, old, key, retireAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, models.ErrAPIKeyRetired
+	}
+	return &IssuedAPIKey{APIKey: key, Key: plain}, nil
+}
+
+// RevokeKey stops a key working straight away, including an old key still in
+// its rotation grace period.
+func (s *apiKeyService) RevokeKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	revoked, err := s.apiKeyRepo.Revoke(ctx, id, s.now())
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.getKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, models.ErrAPIKeyRetired
+	}
+	return key, nil
+}
+
+// Authenticate returns the key a request presented. It returns
+// ErrAPIKeyInvalid for a key that was never issued, and ErrAPIKeyRevoked or
+// ErrAPIKeyExpired for one that no longer works.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	prefix, ok := apiKeyPrefix(key)
+	if !ok {
+		return nil, models.ErrAPIKeyInvalid
+	}
+	apiKey, err := s.apiKeyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, models.ErrAPIKeyInvalid
+	}
+
+	now := s.now()
+	if err := apiKey.Usable(now); err != nil {
+		return nil, err
+	}
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, apiKey.ID, now, now.Add(-lastUsedResolution)); err != nil {
+		// A missed last-used time is not worth failing the request over
+		log.Warn().Err(err).Uint("apiKeyID", apiKey.ID).Msg("Failed to record API key use")
+	}
+	return apiKey, nil
+}
+
+func (s *apiKeyService) getKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// newKey generates a key of the form sav_<prefix>_<secret> and returns it
+// with the record that stores its hash.
+func (s *apiKeyService) newKey(name string, scopes []string, expiresInDays int, staffID uint) (*models.APIKey, string, error) {
+	prefix := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	lifetime := defaultAPIKeyLifetime
+	if expiresInDays > 0 {
+		lifetime = time.Duration(expiresInDays) * 24 * time.Hour
+	}
+
+	key := &models.APIKey{
+		Name:        name,
+		Prefix:      hex.EncodeToString(prefix),
+		Scopes:      scopes,
+		ExpiresAt:   s.now().Add(lifetime),
+		CreatedByID: staffID,
+	}
+	plain := fmt.Sprintf("%s%s_%s", apiKeyTag, key.Prefix, base64.RawURLEncoding.EncodeToString(secret))
+	key.KeyHash = hashAPIKey(plain)
+	return key, plain, nil
+}
+
+// apiKeyPrefix extracts the lookup prefix from a presented key.
+func apiKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyTag)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// hashAPIKey needs no salt or stretching: keys are 256 random bits, so
+// there is nothing to guess.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes checks each scope is known and drops duplicates, keeping
+// the documented order.
+func normalizeScopes(scopes []string) ([]string, error) {
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		requested[strings.TrimSpace(scope)] = true
+	}
+
+	normalized := make([]string, 0, len(requested))
+	for _, scope := range models.APIKeyScopes {
+		if requested[scope] {
+			normalized = append(normalized, scope)
+			delete(requested, scope)
+		}
+	}
+	for scope := range requested {
+		return nil, fmt.Errorf("%w: %q", models.ErrUnknownScope, scope)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	repo := &fakeAPIKeyRepo{keys: map[uint]*models.APIKey{}}
+	s := &apiKeyService{apiKeyRepo: repo, now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	_, err := s.CreateKey(ctx, 1, &APIKeyCreateRequest{Name: "ERP", Scopes: []string{"products:delete"}})
+	assert.ErrorIs(t, err, models.ErrUnknownScope)
+
+	issued, err := s.CreateKey(ctx, 1, &APIKeyCreateRequest{
+		Name: " ERP ", Scopes: []string{models.ScopeOrdersRead, models.ScopeProductsWrite, models.ScopeOrdersRead},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ERP", issued.APIKey.Name)
+	assert.Equal(t, []string{models.ScopeProductsWrite, models.ScopeOrdersRead}, issued.APIKey.Scopes)
+	assert.Equal(t, now.Add(defaultAPIKeyLifetime), issued.APIKey.ExpiresAt)
+	assert.NotContains(t, repo.keys[issued.APIKey.ID].KeyHash, issued.Key, "only the hash is stored")
+
+	key, err := s.Authenticate(ctx, issued.Key)
+	require.NoError(t, err)
+	assert.Equal(t, issued.APIKey.ID, key.ID)
+	assert.Equal(t, now, *repo.keys[key.ID].LastUsedAt)
+
+	for _, bad := range []string{"", "sav_", issued.Key + "x", "sav_" + issued.APIKey.Prefix + "_guess"} {
+		_, err = s.Authenticate(ctx, bad)
+		assert.ErrorIs(t, err, models.ErrAPIKeyInvalid, bad)
+	}
+
+	now = issued.APIKey.ExpiresAt
+	_, err = s.Authenticate(ctx, issued.Key)
+	assert.ErrorIs(t, err, models.ErrAPIKeyExpired)
+}
+
+func TestAPIKeyService_RotateAndRevoke(t *testing.T) {
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	repo := &fakeAPIKeyRepo{keys: map[uint]*models.APIKey{}}
+	s := &apiKeyService{apiKeyRepo: repo, now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	old, err := s.CreateKey(ctx, 1, &APIKeyCreateRequest{Name: "POS", Scopes: []string{models.ScopeProductsRead}})
+	require.NoError(t, err)
+
+	_, err = s.RotateKey(ctx, 1, 99, &APIKeyRotateRequest{})
+	assert.ErrorIs(t, err, models.ErrAPIKeyNotFound)
+
+	rotated, err := s.RotateKey(ctx, 1, old.APIKey.ID, &APIKeyRotateRequest{ExpiresInDays: 30, GracePeriodMinutes: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "POS", rotated.APIKey.Name)
+	assert.Equal(t, old.APIKey.Scopes, rotated.APIKey.Scopes)
+	assert.Equal(t, now.AddDate(0, 0, 30), rotated.APIKey.ExpiresAt)
+	assert.NotEqual(t, old.Key, rotated.Key)
+
+	_, err = s.RotateKey(ctx, 1, old.APIKey.ID, &APIKeyRotateRequest{})
+	assert.ErrorIs(t, err, models.ErrAPIKeyRetired, "a key is only rotated once")
+
+	_, err = s.Authenticate(ctx, old.Key)
+	assert.NoError(t, err, "the old key works during the grace period")
+
+	now = now.Add(time.Hour)
+	_, err = s.Authenticate(ctx, old.Key)
+	assert.ErrorIs(t, err, models.ErrAPIKeyRevoked)
+	_, err = s.Authenticate(ctx, rotated.Key)
+	require.NoError(t, err)
+
+	revoked, err := s.RevokeKey(ctx, rotated.APIKey.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = s.Authenticate(ctx, rotated.Key)
+	assert.ErrorIs(t, err, models.ErrAPIKeyRevoked)
+
+	_, err = s.RevokeKey(ctx, rotated.APIKey.ID)
+	assert.ErrorIs(t, err, models.ErrAPIKeyRetired)
+}
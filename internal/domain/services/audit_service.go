@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+// AuditService searches the audit trail of changes made through the
+// repositories.
+type AuditService interface {
+	SearchEvents(ctx context.Context, req *AuditEventSearchRequest) ([]models.AuditEvent, int64, error)
+}
+
+type AuditEventSearchRequest struct {
+	Entity    string    `form:"entity" binding:"omitempty,max=50"`
+	EntityID  string    `form:"entity_id" binding:"omitempty,max=50"`
+	ActorType string    `form:"actor_type" binding:"omitempty,oneof=customer api_key system"`
+	ActorID   uint      `form:"actor_id"`
+	RequestID string    `form:"request_id" binding:"omitempty,max=64"`
+	From      time.Time `form:"from" time_format:"2006-01-02"`
+	To        time.Time `form:"to" time_format:"2006-01-02"`
+	Page      int       `form:"page,default=1" binding:"min=1"`
+	Limit     int       `form:"limit,default=50" binding:"min=1,max=200"`
+}
+
+type auditService struct {
+	eventRepo repositories.AuditEventRepository
+}
+
+func NewAuditService(eventRepo repositories.AuditEventRepository) AuditService {
+	return &auditService{eventRepo: eventRepo}
+}
+
+func (s *auditService) SearchEvents(ctx context.Context, req *AuditEventSearchRequest) ([]models.AuditEvent, int64, error) {
+	filter := repositories.AuditEventFilter{
+		Entity:    req.Entity,
+		EntityID:  req.EntityID,
+		ActorType: req.ActorType,
+		ActorID:   req.ActorID,
+		RequestID: req.RequestID,
+		From:      req.From,
+	}
+	if !req.To.IsZero() {
+		// The whole of the To day is included
+		filter.To = req.To.AddDate(0, 0, 1)
+	}
+	return s.eventRepo.Search(ctx, filter, req.Page, req.Limit)
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+	"github.com/Mutonya/Savanah/pkg/geoip"
+)
+
+// ClientInfo is where a request came from, as recorded in the audit log.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// AuthAuditService keeps the authentication audit log and runs the anomaly
+// rules on each event as it is recorded.
+type AuthAuditService interface {
+	Record(ctx context.Context, event *models.AuthEvent)
+	SearchEvents(ctx context.Context, req *AuthEventSearchRequest) ([]models.AuthEvent, int64, error)
+}
+
+type AuthEventSearchRequest struct {
+	Type       string    `form:"type" binding:"omitempty,oneof=login login_failed token_refresh logout role_change"`
+	CustomerID uint      `form:"customer_id"`
+	IP         string    `form:"ip" binding:"omitempty,ip"`
+	From       time.Time `form:"from" time_format:"2006-01-02"`
+	To         time.Time `form:"to" time_format:"2006-01-02"`
+	Alerted    bool      `form:"alerted"`
+	Page       int       `form:"page,default=1" binding:"min=1"`
+	Limit      int       `form:"limit,default=50" binding:"min=1,max=200"`
+}
+
+type authAuditService struct {
+	eventRepo    repositories.AuthEventRepository
+	customerRepo repositories.CustomerRepository
+	notifier     NotificationService
+	geo          *geoip.DB
+	config       *config.Config
+	now          func() time.Time
+}
+
+// NewAuthAuditService records events with their country when geo is set.
+func NewAuthAuditService(
+	eventRepo repositories.AuthEventRepository,
+	customerRepo repositories.CustomerRepository,
+	notifier NotificationService,
+	geo *geoip.DB,
+	config *config.Config,
+) AuthAuditService {
+	return &authAuditService{
+		eventRepo:    eventRepo,
+		customerRepo: customerRepo,
+		notifier:     notifier,
+		geo:          geo,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// Record saves an event and sends the alerts for any anomaly rules it trips.
+// The log must not get in the way of signing in, so failures are only
+// logged.
+func (s *authAuditService) Record(ctx context.Context, event *models.AuthEvent) {
+	event.CreatedAt = s.now()
+	event.Country = s.geo.Country(event.IP)
+	if len(event.UserAgent) > 255 {
+		event.UserAgent = event.UserAgent[:255]
+	}
+
+	alerts := s.checkRules(ctx, event)
+	for _, alert := range alerts {
+		event.Alerts = append(event.Alerts, alert.rule)
+	}
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		log.Error().Err(err).Str("type", event.Type).Str("ip", event.IP).Msg("Failed to record auth event")
+	}
+
+	for _, alert := range alerts {
+		if err := alert.notify(); err != nil {
+			log.Error().Err(err).Str("alert", alert.rule).Uint("eventID", event.ID).Msg("Failed to send auth alert")
+		}
+	}
+	if len(alerts) > 0 {
+		log.Warn().Strs("alerts", event.Alerts).Str("type", event.Type).Str("ip", event.IP).Msg("Suspicious auth event")
+	}
+}
+
+// authAlert is an anomaly rule an event tripped, with the notification to
+// send for it.
+type authAlert struct {
+	rule   string
+	notify func() error
+}
+
+// checkRules runs the anomaly rules on an event before it is saved.
+func (s *authAuditService) checkRules(ctx context.Context, event *models.AuthEvent) []authAlert {
+	var alerts []authAlert
+
+	switch event.Type {
+	case models.AuthEventLoginFailed:
+		if event.IP == "" || s.config.AuthFailedAttemptLimit <= 0 {
+			break
+		}
+		earlier, err := s.eventRepo.CountFromIP(ctx, event.Type, event.IP, event.CreatedAt.Add(-s.config.AuthFailedAttemptWindow))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to count failed sign-ins")
+			break
+		}
+		// Alert once, as the limit is reached, not on every attempt after it
+		if earlier+1 == int64(s.config.AuthFailedAttemptLimit) {
+			summary := fmt.Sprintf("%d failed sign-ins from %s within %s",
+				s.config.AuthFailedAttemptLimit, event.IP, s.config.AuthFailedAttemptWindow)
+			alerts = append(alerts, authAlert{models.AuthAlertFailedAttempts, func() error {
+				return s.notifier.SendSecurityAlert(event, summary)
+			}})
+		}
+
+	case models.AuthEventLogin:
+		if event.CustomerID == nil || event.Country == "" {
+			break
+		}
+		countries, err := s.eventRepo.LoginCountries(ctx, *event.CustomerID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to look up sign-in countries")
+			break
+		}
+		// A first sign-in has nothing to compare with
+		if len(countries) == 0 || slices.Contains(countries, event.Country) {
+			break
+		}
+		alerts = append(alerts, authAlert{models.AuthAlertNewCountry, func() error {
+			customer, err := s.customerRepo.GetByID(*event.CustomerID)
+			if err != nil {
+				return err
+			}
+			return s.notifier.SendNewCountrySignIn(customer, event)
+		}})
+	}
+	return alerts
+}
+
+func (s *authAuditService) SearchEvents(ctx context.Context, req *AuthEventSearchRequest) ([]models.AuthEvent, int64, error) {
+	filter := repositories.AuthEventFilter{
+		Type:       req.Type,
+		CustomerID: req.CustomerID,
+		IP:         req.IP,
+		From:       req.From,
+		Alerted:    req.Alerted,
+	}
+	if !req.To.IsZero() {
+		// The whole of the To day is included
+		filter.To = req.To.AddDate(0, 0, 1)
+	}
+	return s.eventRepo.Search(ctx, filter, req.Page, req.Limit)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestAuthAuditService_FailedAttempts(t *testing.T) {
+	s, events, notifier := newTestAuthAuditService(&fakeCustomerRepo{customers: map[uint]*models.Customer{}})
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	fail := func(ip string) {
+		s.Record(ctx, &models.AuthEvent{Type: models.AuthEventLoginFailed, IP: ip, Reason: "state_mismatch"})
+		now = now.Add(time.Minute)
+	}
+	fail("105.161.2.3")
+	fail("41.0.0.9")
+	fail("105.161.2.3")
+	assert.Empty(t, notifier.securityAlerts)
+
+	fail("105.161.2.3")
+	require.Len(t, notifier.securityAlerts, 1, "the admin hears when one IP reaches the limit")
+	assert.Contains(t, notifier.securityAlerts[0], "3 failed sign-ins from 105.161.2.3")
+	assert.Equal(t, []string{models.AuthAlertFailedAttempts}, events.events[3].Alerts)
+	assert.Equal(t, "KE", events.events[3].Country)
+
+	fail("105.161.2.3")
+	assert.Len(t, notifier.securityAlerts, 1, "and only once")
+
+	now = now.Add(time.Hour)
+	fail("105.161.2.3")
+	fail("105.161.2.3")
+	fail("105.161.2.3")
+	assert.Len(t, notifier.securityAlerts, 2, "a new burst after the window alerts again")
+}
+
+func TestAuthAuditService_NewCountry(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{
+		7: {Model: gorm.Model{ID: 7}, FirstName: "Amina", Email: "amina@example.com"},
+	}}
+	s, events, notifier := newTestAuthAuditService(customers)
+	ctx := context.Background()
+	customerID := uint(7)
+	login := func(ip string) {
+		s.Record(ctx, &models.AuthEvent{Type: models.AuthEventLogin, CustomerID: &customerID, IP: ip})
+	}
+
+	login("105.161.2.3")
+	assert.Empty(t, notifier.securityAlerts, "a first sign-in has nothing to compare with")
+	login("105.162.0.1")
+	login("10.0.0.1")
+	assert.Empty(t, notifier.securityAlerts, "same country, or no known country")
+
+	login("41.0.0.9")
+	assert.Equal(t, []string{"new country ZA for amina@example.com"}, notifier.securityAlerts)
+	assert.Equal(t, []string{models.AuthAlertNewCountry}, events.events[3].Alerts)
+
+	login("41.0.0.10")
+	assert.Len(t, notifier.securityAlerts, 1)
+}
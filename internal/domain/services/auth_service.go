@@ -2,8 +2,16 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm" //ORM for database operations (used for error handling).
 
 	"github.com/Mutonya/Savanah/internal/config"
@@ -15,9 +23,11 @@ import (
 // interface definition
 // Defines the contract for authentication services  (method signatures)
 type AuthService interface {
-	GetAuthCodeURL(state string) string
-	Authenticate(ctx context.Context, code string) (*models.Customer, string, error)
+	Providers() []string
+	BeginLogin(provider string) (string, *oauth2.LoginState, error)
+	Authenticate(ctx context.Context, login *oauth2.LoginState, code string, client ClientInfo) (*models.Customer, string, error)
 	ValidateToken(ctx context.Context, token string) (*models.Customer, error)
+	ValidateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
 	GetCustomerByID(id uint) (*models.Customer, error)
 }
 
@@ -25,91 +35,290 @@ type AuthService interface {
 // Service Implementation Struct
 // combine values of different types into one logical unit
 type authService struct {
-	oauthProvider oauth2.OAuthProvider
-	customerRepo  repositories.CustomerRepository
-	config        *config.Config
+	providers    map[string]oauth2.OAuthProvider
+	customerRepo repositories.CustomerRepository
+	identityRepo repositories.IdentityRepository
+	sessionRepo  repositories.SessionRepository
+	apiKeys      APIKeyService
+	audit        AuthAuditService
+	config       *config.Config
+	now          func() time.Time
 }
 
 // initialize  the service
-func NewAuthService(oauthProvider oauth2.OAuthProvider, customerRepo repositories.CustomerRepository, config *config.Config) AuthService {
+// providers are keyed by the names in config.OAuthProviders
+func NewAuthService(
+	providers map[string]oauth2.OAuthProvider,
+	customerRepo repositories.CustomerRepository,
+	identityRepo repositories.IdentityRepository,
+	sessionRepo repositories.SessionRepository,
+	apiKeys APIKeyService,
+	audit AuthAuditService,
+	config *config.Config,
+) AuthService {
 	return &authService{
-		oauthProvider: oauthProvider, // handles OAuth2 flow
-		customerRepo:  customerRepo,  // manages customer data
-		config:        config,        //app config
+		providers:    providers,    // handle the OAuth2 flow, one per identity provider
+		customerRepo: customerRepo, // manages customer data
+		identityRepo: identityRepo, // links provider accounts to customers
+		sessionRepo:  sessionRepo,  // signed-in customers' bearer tokens
+		apiKeys:      apiKeys,      // service account keys
+		audit:        audit,        // records sign-ins
+		config:       config,       //app config
+		now:          time.Now,
 	}
 }
 
-// Generates the OAuth2 authorization URL
-// Delegates to OAuthProvider to construct the URL
-// Returns URL to redirect user to identity provider
-func (s *authService) GetAuthCodeURL(state string) string {
-	return s.oauthProvider.GetAuthCodeURL(state)
+// Providers lists the names of the identity providers customers can sign in
+// with, the default first.
+func (s *authService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for _, p := range s.config.OAuthProviders {
+		if _, ok := s.providers[p.Name]; ok {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// provider looks up an identity provider by name and returns it with its
+// name; an empty name is the default provider.
+func (s *authService) provider(name string) (string, oauth2.OAuthProvider, error) {
+	if name == "" {
+		names := s.Providers()
+		if len(names) == 0 {
+			return "", nil, models.ErrUnknownProvider
+		}
+		name = names[0]
+	}
+	provider, ok := s.providers[name]
+	if !ok {
+		return "", nil, models.ErrUnknownProvider
+	}
+	return name, provider, nil
+}
+
+// Starts a sign-in with the named provider ("" for the default)
+// Generates the state, nonce and PKCE code verifier for the login and
+// returns the URL to redirect the user to, with the LoginState the callback
+// needs to finish the login
+func (s *authService) BeginLogin(provider string) (string, *oauth2.LoginState, error) {
+	name, p, err := s.provider(provider)
+	if err != nil {
+		return "", nil, err
+	}
+
+	state, err := oauth2.RandomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+	nonce, err := oauth2.RandomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+	login := &oauth2.LoginState{
+		Provider:     name,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+	}
+	return p.GetAuthCodeURL(login.State, login.Nonce, login.CodeVerifier), login, nil
 }
 
 // auth implimentation
-func (s *authService) Authenticate(ctx context.Context, code string) (*models.Customer, string, error) {
-	// Step 1: Exchange authorization code for tokens
-	token, err := s.oauthProvider.Exchange(ctx, code)
+// Finishes the sign-in started by BeginLogin. Failures at the provider come
+// back wrapped in the matching models.AuthError.
+// A first sign-in with an identity whose verified email belongs to an
+// existing customer links the identity to that customer; otherwise it
+// registers a new customer. The customer is returned with the bearer token
+// of a new session.
+func (s *authService) Authenticate(ctx context.Context, login *oauth2.LoginState, code string, client ClientInfo) (*models.Customer, string, error) {
+	provider, p, err := s.provider(login.Provider)
 	if err != nil {
 		return nil, "", err
 	}
-	// Step 2: Verify ID token (JWT validation)
-	idToken, err := s.oauthProvider.VerifyIDToken(ctx, token)
+
+	// Step 1: Exchange authorization code (and PKCE verifier) for tokens
+	token, err := p.Exchange(ctx, code, login.CodeVerifier)
 	if err != nil {
-		return nil, "", err
+		return nil, "", fmt.Errorf("%w: %w", models.ErrAuthCodeExchange, err)
+	}
+	// Step 2: Verify ID token (JWT validation) and its nonce
+	idToken, err := p.VerifyIDToken(ctx, token, login.Nonce)
+	if errors.Is(err, oauth2.ErrNonceMismatch) {
+		return nil, "", fmt.Errorf("%w: %w", models.ErrAuthNonceMismatch, err)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", models.ErrAuthInvalidIDToken, err)
 	}
 	// Step 3: Extract claims from ID token
 	var claims struct {
-		Email   string `json:"email"` //user email {johndoe@gmail.com} google is our auth provider
-		Name    string `json:"name"`  // user name { John Doe}
-		Subject string `json:"sub"`   // Unique user ID from provider
+		Email         string `json:"email"`          //user email {johndoe@gmail.com}
+		EmailVerified bool   `json:"email_verified"` // whether the provider checked the email belongs to the user
+		Name          string `json:"name"`           // user name { John Doe}
+		Subject       string `json:"sub"`            // Unique user ID at the provider
 	}
 
 	if err := idToken.Claims(&claims); err != nil {
 		return nil, "", err
 	}
-	// Step 4: Find or create customer
-	customer, err := s.customerRepo.GetByOAuthID(claims.Subject)
+	// Step 4: Find the customer behind the identity, linking or creating one
+	customer, err := s.customerFor(ctx, provider, claims.Subject, claims.Email, claims.EmailVerified, claims.Name)
 	if err != nil {
-		// handle  not found error
-		if err == gorm.ErrRecordNotFound {
-			// create customer
-			customer = &models.Customer{
-				OAuthID: claims.Subject,
-				Email:   claims.Email,
-			}
-			//username {John Doe}
-			// split the stringinto First and Last name
-			names := strings.SplitN(claims.Name, " ", 2)
-			if len(names) > 0 {
-
-				customer.FirstName = names[0] // John
-			}
-			if len(names) > 1 {
-				customer.LastName = names[1] // Doe
-			}
-
-			//Save to database
-			if err := s.customerRepo.Create(customer); err != nil {
-				return nil, "", err
-			}
-		} else {
-			// other database Errors
-			return nil, "", err
-		}
+		return nil, "", err
+	}
+
+	// Step 5: Record the sign-in in the audit log
+	if s.audit != nil {
+		s.audit.Record(ctx, &models.AuthEvent{
+			Type:       models.AuthEventLogin,
+			CustomerID: &customer.ID,
+			Provider:   provider,
+			IP:         client.IP,
+			UserAgent:  client.UserAgent,
+		})
+	}
+
+	// Step 6: Start a session; the provider's own tokens stay with us
+	sessionToken, err := s.startSession(ctx, customer.ID)
+	if err != nil {
+		return nil, "", err
 	}
 
 	//  return the customer Model and token {Authenticated User}
 	// to meet Single responsibity you should use a mapper
 	// the customer model should not be responsible for any other thing other than db access
-	return customer, token.AccessToken, nil
+	return customer, sessionToken, nil
+}
+
+// startSession records a session for the customer, lasting SessionTTL, and
+// returns its bearer token.
+func (s *authService) startSession(ctx context.Context, customerID uint) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	session := &models.Session{
+		CustomerID: customerID,
+		TokenHash:  hashSessionToken(token),
+		ExpiresAt:  s.now().Add(s.config.SessionTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *authService) customerFor(ctx context.Context, provider, subject, email string, emailVerified bool, name string) (*models.Customer, error) {
+	identity, err := s.identityRepo.Get(ctx, provider, subject)
+	if err == nil {
+		if err := s.identityRepo.TouchLogin(ctx, identity.ID, s.now()); err != nil {
+			return nil, err
+		}
+		return s.customerRepo.GetByID(identity.CustomerID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		// other database Errors
+		return nil, err
+	}
+
+	now := s.now()
+	identity = &models.Identity{
+		Provider:      provider,
+		Subject:       subject,
+		Email:         email,
+		EmailVerified: emailVerified,
+		LastLoginAt:   &now,
+	}
+
+	// A customer already has this email: link the identity to them, but only
+	// when the provider vouches for the email
+	existing, err := s.customerRepo.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !emailVerified {
+			return nil, models.ErrUnverifiedEmail
+		}
+		identity.CustomerID = existing.ID
+		if err := s.identityRepo.Create(ctx, identity); err != nil {
+			return s.afterRace(ctx, provider, subject, err)
+		}
+		log.Info().Uint("customerID", existing.ID).Str("provider", provider).Msg("Identity linked to existing customer")
+		return existing, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	// Whoever later signs in with the email verified is linked to the new
+	// customer, so an unverified one could be used to take their account
+	if !emailVerified {
+		return nil, models.ErrEmailNotVerified
+	}
+
+	// create customer
+	customer := &models.Customer{Email: email, Role: models.RoleCustomer}
+	//username {John Doe}
+	// split the stringinto First and Last name
+	names := strings.SplitN(name, " ", 2)
+	if len(names) > 0 {
+		customer.FirstName = names[0] // John
+	}
+	if len(names) > 1 {
+		customer.LastName = names[1] // Doe
+	}
+
+	//Save to database
+	if err := s.identityRepo.Register(ctx, customer, identity); err != nil {
+		return s.afterRace(ctx, provider, subject, err)
+	}
+	return customer, nil
+}
+
+// afterRace handles a failed insert: when a concurrent first sign-in with
+// the same identity won, its customer is used.
+func (s *authService) afterRace(ctx context.Context, provider, subject string, err error) (*models.Customer, error) {
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, err
+	}
+	identity, getErr := s.identityRepo.Get(ctx, provider, subject)
+	if getErr != nil {
+		return nil, err
+	}
+	return s.customerRepo.GetByID(identity.CustomerID)
 }
 
+// ValidateToken returns the customer whose session a bearer token belongs
+// to. It returns ErrSessionInvalid for a token that was never issued or whose
+// session was ended, e.g. by erasing the account, and ErrSessionExpired once
+// the session is over.
 func (s *authService) ValidateToken(ctx context.Context, token string) (*models.Customer, error) {
-	// In a real implementation, we would validate the JWT token
-	// For simplicity, we'll just get the customer by ID from the token claims
-	// This would be replaced with proper JWT validation in production
-	return s.customerRepo.GetByID(1) // {Place Holder}
+	session, err := s.sessionRepo.GetByTokenHash(ctx, hashSessionToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.ExpiresAt.After(s.now()) {
+		return nil, models.ErrSessionExpired
+	}
+
+	customer, err := s.customerRepo.GetByID(session.CustomerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrSessionInvalid
+	}
+	return customer, err
+}
+
+// ValidateAPIKey authenticates a service account by the key it presented.
+func (s *authService) ValidateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	return s.apiKeys.Authenticate(ctx, key)
 }
 
 // fetching the user with ID {Profile one  scenario }
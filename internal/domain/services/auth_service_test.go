@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/pkg/oauth2"
+)
+
+func TestAuthService_LinksIdentitiesByVerifiedEmail(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{
+		7: {Model: gorm.Model{ID: 7}, FirstName: "Amina", Email: "amina@example.com"},
+	}}
+	identities := &fakeIdentityRepo{customers: customers, identities: []models.Identity{
+		{ID: 1, CustomerID: 7, Provider: "google", Subject: "g-7"},
+	}}
+	s := &authService{customerRepo: customers, identityRepo: identities, now: time.Now}
+	ctx := context.Background()
+
+	customer, err := s.customerFor(ctx, "google", "g-7", "amina@example.com", true, "Amina")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), customer.ID)
+
+	_, err = s.customerFor(ctx, "microsoft", "m-7", "Amina@Example.com", false, "Amina")
+	assert.ErrorIs(t, err, models.ErrUnverifiedEmail, "an unverified email is not enough to take over an account")
+
+	customer, err = s.customerFor(ctx, "microsoft", "m-7", "Amina@Example.com", true, "Amina")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), customer.ID, "merged into the existing customer")
+	assert.Len(t, identities.identities, 2)
+
+	customer, err = s.customerFor(ctx, "microsoft", "m-8", "baraka@example.com", true, "Baraka Otieno")
+	require.NoError(t, err)
+	assert.NotEqual(t, uint(7), customer.ID)
+	assert.Equal(t, "Baraka", customer.FirstName)
+	assert.Equal(t, "Otieno", customer.LastName)
+	assert.Len(t, customers.customers, 2)
+}
+
+func TestAuthService_UnverifiedEmailCannotPreRegister(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{}}
+	identities := &fakeIdentityRepo{customers: customers}
+	s := &authService{customerRepo: customers, identityRepo: identities, now: time.Now}
+	ctx := context.Background()
+
+	// Someone signs up first with the victim's email, unverified...
+	_, err := s.customerFor(ctx, "microsoft", "m-1", "wanjiru@example.com", false, "Mallory")
+	assert.ErrorIs(t, err, models.ErrEmailNotVerified)
+	assert.Empty(t, customers.customers)
+	assert.Empty(t, identities.identities)
+
+	// ...so the victim's own sign-in gets a fresh account, not theirs
+	customer, err := s.customerFor(ctx, "google", "g-1", "wanjiru@example.com", true, "Wanjiru Kamau")
+	require.NoError(t, err)
+	assert.Equal(t, "Wanjiru", customer.FirstName)
+	assert.Len(t, identities.identities, 1)
+}
+
+func TestAuthService_Providers(t *testing.T) {
+	s := &authService{
+		providers: map[string]oauth2.OAuthProvider{"google": nil, "microsoft": nil},
+		config: &config.Config{OAuthProviders: []config.OAuthProviderConfig{
+			{Name: "microsoft"}, {Name: "google"}, {Name: "broken"},
+		}},
+	}
+	assert.Equal(t, []string{"microsoft", "google"}, s.Providers())
+
+	name, _, err := s.provider("")
+	require.NoError(t, err)
+	assert.Equal(t, "microsoft", name, "the first configured provider is the default")
+
+	_, _, err = s.BeginLogin("github")
+	assert.ErrorIs(t, err, models.ErrUnknownProvider)
+}
+
+func TestAuthService_SignInWithMockIssuer(t *testing.T) {
+	issuer, err := oauth2.NewMockIssuer("http://localhost:8080/dev/oidc", oauth2.NewMockUser("amina.wanjiru@example.com"))
+	require.NoError(t, err)
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{}}
+	audit, events, _ := newTestAuthAuditService(customers)
+	s := &authService{
+		providers:    map[string]oauth2.OAuthProvider{"dev": issuer.Provider(context.Background(), "savannah", "http://localhost:8080/auth/callback/dev")},
+		customerRepo: customers,
+		identityRepo: &fakeIdentityRepo{customers: customers},
+		sessionRepo:  &fakeSessionRepo{},
+		audit:        audit,
+		config:       &config.Config{OAuthProviders: []config.OAuthProviderConfig{{Name: "dev"}}, SessionTTL: time.Hour},
+		now:          time.Now,
+	}
+
+	authURL, login, err := s.BeginLogin("dev")
+	require.NoError(t, err)
+	client := issuer.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, login.State, callback.Query().Get("state"))
+
+	customer, token, err := s.Authenticate(context.Background(), login, callback.Query().Get("code"), ClientInfo{IP: "105.161.2.3", UserAgent: "curl/8"})
+	require.NoError(t, err)
+	caller, err := s.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, caller.ID, "the token is the new customer's")
+	require.Len(t, events.events, 1, "the sign-in is audited")
+	assert.Equal(t, models.AuthEventLogin, events.events[0].Type)
+	assert.Equal(t, customer.ID, *events.events[0].CustomerID)
+	assert.Equal(t, "dev", events.events[0].Provider)
+	assert.Equal(t, "KE", events.events[0].Country)
+	assert.Equal(t, "amina.wanjiru@example.com", customer.Email)
+	assert.Equal(t, "Amina", customer.FirstName)
+	assert.Equal(t, "Wanjiru", customer.LastName)
+}
+
+func TestAuthService_ValidateToken(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{
+		7: {Model: gorm.Model{ID: 7}, FirstName: "Amina"},
+		8: {Model: gorm.Model{ID: 8}, FirstName: "Baraka"},
+	}}
+	now := time.Now()
+	s := &authService{
+		customerRepo: customers,
+		sessionRepo:  &fakeSessionRepo{},
+		config:       &config.Config{SessionTTL: time.Hour},
+		now:          func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	amina, err := s.startSession(ctx, 7)
+	require.NoError(t, err)
+	baraka, err := s.startSession(ctx, 8)
+	require.NoError(t, err)
+
+	customer, err := s.ValidateToken(ctx, amina)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), customer.ID)
+	customer, err = s.ValidateToken(ctx, baraka)
+	require.NoError(t, err)
+	assert.Equal(t, uint(8), customer.ID)
+
+	_, err = s.ValidateToken(ctx, "made-up")
+	assert.ErrorIs(t, err, models.ErrSessionInvalid)
+
+	// An erased customer is no longer found
+	delete(customers.customers, 8)
+	_, err = s.ValidateToken(ctx, baraka)
+	assert.ErrorIs(t, err, models.ErrSessionInvalid)
+
+	now = now.Add(time.Hour)
+	_, err = s.ValidateToken(ctx, amina)
+	assert.ErrorIs(t, err, models.ErrSessionExpired)
+}
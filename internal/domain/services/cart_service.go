@@ -0,0 +1,462 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type CartService interface {
+	GetCart(ctx context.Context, customerID uint) (*CartView, error)
+	AddItem(ctx context.Context, customerID uint, req *CartItemRequest) (*CartView, error)
+	UpdateItem(ctx context.Context, customerID, itemID uint, req *CartItemUpdateRequest) (*CartView, error)
+	RemoveItem(ctx context.Context, customerID, itemID uint) (*CartView, error)
+	ClearCart(ctx context.Context, customerID uint) error
+	Checkout(ctx context.Context, customerID uint, req *CartCheckoutRequest) (*models.Order, error)
+	PurgeExpired(ctx context.Context) (int64, error)
+	Reorder(ctx context.Context, customerID, orderID uint, req *ReorderRequest) (*ReorderResult, error)
+}
+
+type CartItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"required,min=1"`
+}
+
+type CartItemUpdateRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
+
+type CartCheckoutRequest struct {
+	// AcceptPriceChanges confirms that the customer has seen the repriced
+	// lines; without it checkout refuses a cart whose prices moved.
+	AcceptPriceChanges bool   `json:"accept_price_changes"`
+	CouponCode         string `json:"coupon_code"`
+	ShippingAddressID  *uint  `json:"shipping_address_id"`
+}
+
+// ReorderRequest.Target is "cart" (the default) to add a past order's items to
+// the cart, or "order" to place a new order straight away. CouponCode and
+// ShippingAddressID apply to a new order as they do at checkout.
+type ReorderRequest struct {
+	Target            string `json:"target" binding:"omitempty,oneof=cart order"`
+	CouponCode        string `json:"coupon_code"`
+	ShippingAddressID *uint  `json:"shipping_address_id"`
+}
+
+// Reorder targets.
+const (
+	ReorderToCart  = "cart"
+	ReorderToOrder = "order"
+)
+
+// ReorderResult lists every line of the past order with what happened to it,
+// plus the cart or the new order it went into.
+type ReorderResult struct {
+	Target     string        `json:"target"`
+	Items      []ReorderLine `json:"items"`
+	HasChanges bool          `json:"has_changes"`
+	Cart       *CartView     `json:"cart,omitempty"`
+	Order      *models.Order `json:"order,omitempty"`
+}
+
+// ReorderLine compares a past order line with the catalogue. PreviousPrice is
+// what the customer paid per unit before discounts, set when it differs from
+// UnitPrice. Unavailable lines are left out; Reason says why.
+type ReorderLine struct {
+	ProductID     uint    `json:"product_id"`
+	Name          string  `json:"name,omitempty"`
+	Quantity      int     `json:"quantity"`
+	UnitPrice     float64 `json:"unit_price"`
+	PreviousPrice float64 `json:"previous_price,omitempty"`
+	Status        string  `json:"status"`
+	Reason        string  `json:"reason,omitempty"`
+}
+
+// Reasons a reorder line is unavailable.
+const (
+	ReorderReasonDiscontinued = "discontinued"
+	ReorderReasonOutOfStock   = "out_of_stock"
+)
+
+// Cart line states reported to the client after re-pricing.
+const (
+	CartLineOK          = "ok"
+	CartLineRepriced    = "repriced"
+	CartLineUnavailable = "unavailable"
+)
+
+// CartView is the cart re-priced against the current catalogue.
+type CartView struct {
+	ID          uint       `json:"id"`
+	Items       []CartLine `json:"items"`
+	Subtotal    float64    `json:"subtotal"`
+	HasChanges  bool       `json:"has_changes"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUpdated time.Time  `json:"last_updated"`
+}
+
+type CartLine struct {
+	ItemID        uint    `json:"item_id"`
+	ProductID     uint    `json:"product_id"`
+	Name          string  `json:"name,omitempty"`
+	Quantity      int     `json:"quantity"`
+	UnitPrice     float64 `json:"unit_price"`
+	PreviousPrice float64 `json:"previous_price,omitempty"`
+	LineTotal     float64 `json:"line_total"`
+	Status        string  `json:"status"`
+}
+
+type cartService struct {
+	cartRepo     repositories.CartRepository
+	productRepo  repositories.ProductRepository
+	orderService OrderService
+	idleTTL      time.Duration
+	now          func() time.Time
+}
+
+func NewCartService(
+	cartRepo repositories.CartRepository,
+	productRepo repositories.ProductRepository,
+	orderService OrderService,
+	config *config.Config,
+) CartService {
+	return &cartService{
+		cartRepo:     cartRepo,
+		productRepo:  productRepo,
+		orderService: orderService,
+		idleTTL:      config.CartIdleTTL,
+		now:          time.Now,
+	}
+}
+
+func (s *cartService) GetCart(ctx context.Context, customerID uint) (*CartView, error) {
+	cart, err := s.loadCart(ctx, customerID)
+	if errors.Is(err, models.ErrCartNotFound) {
+		// No cart yet is not an error for the reader, just an empty basket
+		return &CartView{Items: []CartLine{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.price(ctx, cart)
+}
+
+func (s *cartService) AddItem(ctx context.Context, customerID uint, req *CartItemRequest) (*CartView, error) {
+	product, err := s.productRepo.GetByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := s.loadCart(ctx, customerID)
+	if errors.Is(err, models.ErrCartNotFound) {
+		cart = &models.Cart{CustomerID: customerID, LastActivityAt: s.now()}
+		err = s.cartRepo.Create(ctx, cart)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.AddItem(ctx, &models.CartItem{
+		CartID:    cart.ID,
+		ProductID: product.ID,
+		Quantity:  req.Quantity,
+		UnitPrice: product.Price,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.touchAndPrice(ctx, cart.ID, customerID)
+}
+
+func (s *cartService) UpdateItem(ctx context.Context, customerID, itemID uint, req *CartItemUpdateRequest) (*CartView, error) {
+	cart, err := s.loadCart(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := findCartItem(cart, itemID)
+	if item == nil {
+		return nil, models.ErrCartItemNotFound
+	}
+	item.Quantity = req.Quantity
+
+	if err := s.cartRepo.UpdateItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	return s.touchAndPrice(ctx, cart.ID, customerID)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, customerID, itemID uint) (*CartView, error) {
+	cart, err := s.loadCart(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.DeleteItem(ctx, cart.ID, itemID); err != nil {
+		return nil, err
+	}
+
+	return s.touchAndPrice(ctx, cart.ID, customerID)
+}
+
+func (s *cartService) ClearCart(ctx context.Context, customerID uint) error {
+	cart, err := s.loadCart(ctx, customerID)
+	if errors.Is(err, models.ErrCartNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.cartRepo.Delete(ctx, cart.ID)
+}
+
+// Checkout turns the cart into an order. Only product IDs and quantities are
+// taken from the cart; prices come from the catalogue inside CreateOrder.
+func (s *cartService) Checkout(ctx context.Context, customerID uint, req *CartCheckoutRequest) (*models.Order, error) {
+	cart, err := s.loadCart(ctx, customerID)
+	if errors.Is(err, models.ErrCartNotFound) {
+		return nil, models.ErrCartEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, models.ErrCartEmpty
+	}
+
+	view, err := s.price(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+
+	orderReq := &OrderCreateRequest{CouponCode: req.CouponCode, ShippingAddressID: req.ShippingAddressID}
+	for _, line := range view.Items {
+		switch line.Status {
+		case CartLineUnavailable:
+			return nil, models.ErrCartUnavailable
+		case CartLineRepriced:
+			if !req.AcceptPriceChanges {
+				return nil, models.ErrCartPricesChanged
+			}
+		}
+		orderReq.Items = append(orderReq.Items, OrderItemRequest{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+		})
+	}
+
+	order, err := s.orderService.CreateOrder(ctx, customerID, orderReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// The order exists at this point; a leftover cart is only an annoyance
+	_ = s.cartRepo.Delete(ctx, cart.ID)
+
+	return order, nil
+}
+
+// Reorder repeats one of the customer's past orders at current prices, either
+// into the cart or as a new order. Products that were deleted or are short of
+// stock are skipped, and lines whose price moved since the original order are
+// flagged; both are reported in the result.
+func (s *cartService) Reorder(ctx context.Context, customerID, orderID uint, req *ReorderRequest) (*ReorderResult, error) {
+	past, err := s.orderService.GetOrder(ctx, customerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReorderResult{Target: req.Target, Items: []ReorderLine{}}
+	if result.Target == "" {
+		result.Target = ReorderToCart
+	}
+
+	var available []ReorderLine
+	for _, item := range mergeOrderItems(past.OrderItems) {
+		line := ReorderLine{
+			ProductID: item.ProductID,
+			Name:      item.Product.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.Price,
+			Status:    CartLineOK,
+		}
+
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			line.Status, line.Reason = CartLineUnavailable, ReorderReasonDiscontinued
+		case err != nil:
+			return nil, err
+		case !product.InStock(item.Quantity):
+			line.Name = product.Name
+			line.Status, line.Reason = CartLineUnavailable, ReorderReasonOutOfStock
+		default:
+			line.Name = product.Name
+			if product.Price != item.Price {
+				line.Status = CartLineRepriced
+				line.PreviousPrice = item.Price
+				line.UnitPrice = product.Price
+			}
+		}
+
+		if line.Status != CartLineOK {
+			result.HasChanges = true
+		}
+		if line.Status != CartLineUnavailable {
+			available = append(available, line)
+		}
+		result.Items = append(result.Items, line)
+	}
+
+	if len(available) == 0 {
+		return nil, models.ErrNothingToReorder
+	}
+
+	if result.Target == ReorderToOrder {
+		orderReq := &OrderCreateRequest{CouponCode: req.CouponCode, ShippingAddressID: req.ShippingAddressID}
+		for _, line := range available {
+			orderReq.Items = append(orderReq.Items, OrderItemRequest{ProductID: line.ProductID, Quantity: line.Quantity})
+		}
+		if result.Order, err = s.orderService.CreateOrder(ctx, customerID, orderReq); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	cart, err := s.loadCart(ctx, customerID)
+	if errors.Is(err, models.ErrCartNotFound) {
+		cart = &models.Cart{CustomerID: customerID, LastActivityAt: s.now()}
+		err = s.cartRepo.Create(ctx, cart)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range available {
+		if err := s.cartRepo.AddItem(ctx, &models.CartItem{
+			CartID:    cart.ID,
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if result.Cart, err = s.touchAndPrice(ctx, cart.ID, customerID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeOrderItems folds lines for the same product into one, keeping the
+// order they first appear in.
+func mergeOrderItems(items []models.OrderItem) []models.OrderItem {
+	merged := make([]models.OrderItem, 0, len(items))
+	index := make(map[uint]int, len(items))
+	for _, item := range items {
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func (s *cartService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.cartRepo.DeleteIdleSince(ctx, s.now().Add(-s.idleTTL))
+}
+
+// loadCart returns the customer's cart, dropping it first if it has been idle
+// for longer than the configured TTL.
+func (s *cartService) loadCart(ctx context.Context, customerID uint) (*models.Cart, error) {
+	cart, err := s.cartRepo.GetByCustomerID(ctx, customerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.idleTTL > 0 && s.now().Sub(cart.LastActivityAt) > s.idleTTL {
+		if err := s.cartRepo.Delete(ctx, cart.ID); err != nil {
+			return nil, err
+		}
+		return nil, models.ErrCartNotFound
+	}
+
+	return cart, nil
+}
+
+func (s *cartService) touchAndPrice(ctx context.Context, cartID, customerID uint) (*CartView, error) {
+	if err := s.cartRepo.Touch(ctx, cartID, s.now()); err != nil {
+		return nil, err
+	}
+	cart, err := s.loadCart(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.price(ctx, cart)
+}
+
+// price re-prices every line against the current product. Lines whose product
+// has been deleted are flagged unavailable and left out of the subtotal.
+func (s *cartService) price(ctx context.Context, cart *models.Cart) (*CartView, error) {
+	view := &CartView{
+		ID:          cart.ID,
+		Items:       make([]CartLine, 0, len(cart.Items)),
+		LastUpdated: cart.LastActivityAt,
+	}
+	if s.idleTTL > 0 {
+		view.ExpiresAt = cart.LastActivityAt.Add(s.idleTTL)
+	}
+
+	for _, item := range cart.Items {
+		line := CartLine{
+			ItemID:    item.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Status:    CartLineOK,
+		}
+
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			line.Status = CartLineUnavailable
+			view.HasChanges = true
+		case err != nil:
+			return nil, err
+		default:
+			line.Name = product.Name
+			if product.Price != item.UnitPrice {
+				line.Status = CartLineRepriced
+				line.PreviousPrice = item.UnitPrice
+				line.UnitPrice = product.Price
+				view.HasChanges = true
+			}
+			line.LineTotal = line.UnitPrice * float64(line.Quantity)
+			view.Subtotal += line.LineTotal
+		}
+
+		view.Items = append(view.Items, line)
+	}
+
+	return view, nil
+}
+
+func findCartItem(cart *models.Cart, itemID uint) *models.CartItem {
+	for i := range cart.Items {
+		if cart.Items[i].ID == itemID {
+			return &cart.Items[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func newTestCartService() (*cartService, *fakeProductRepo, *fakeOrderService) {
+	products := &fakeProductRepo{products: map[uint]*models.Product{
+		1: {Model: gorm.Model{ID: 1}, Name: "Sugar", Price: 200},
+		2: {Model: gorm.Model{ID: 2}, Name: "Tea", Price: 350},
+	}}
+	orders := &fakeOrderService{}
+	return &cartService{
+		cartRepo:     &fakeCartRepo{},
+		productRepo:  products,
+		orderService: orders,
+		idleTTL:      time.Hour,
+		now:          time.Now,
+	}, products, orders
+}
+
+func TestCartService_FlagsRepricedAndDeletedProducts(t *testing.T) {
+	s, products, _ := newTestCartService()
+	ctx := context.Background()
+
+	_, err := s.AddItem(ctx, 7, &CartItemRequest{ProductID: 1, Quantity: 2})
+	require.NoError(t, err)
+	_, err = s.AddItem(ctx, 7, &CartItemRequest{ProductID: 2, Quantity: 1})
+	require.NoError(t, err)
+
+	products.products[1].Price = 250
+	_ = products.Delete(ctx, 2)
+
+	view, err := s.GetCart(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, view.Items, 2)
+
+	assert.True(t, view.HasChanges)
+	assert.Equal(t, CartLineRepriced, view.Items[0].Status)
+	assert.Equal(t, 200.0, view.Items[0].PreviousPrice)
+	assert.Equal(t, 250.0, view.Items[0].UnitPrice)
+	assert.Equal(t, CartLineUnavailable, view.Items[1].Status)
+	assert.Equal(t, 500.0, view.Subtotal)
+}
+
+func TestCartService_CheckoutRequiresAcceptedPriceChanges(t *testing.T) {
+	s, products, orders := newTestCartService()
+	ctx := context.Background()
+
+	_, err := s.AddItem(ctx, 7, &CartItemRequest{ProductID: 1, Quantity: 3})
+	require.NoError(t, err)
+	products.products[1].Price = 210
+
+	_, err = s.Checkout(ctx, 7, &CartCheckoutRequest{})
+	assert.ErrorIs(t, err, models.ErrCartPricesChanged)
+
+	order, err := s.Checkout(ctx, 7, &CartCheckoutRequest{AcceptPriceChanges: true})
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), order.ID)
+	assert.Equal(t, []OrderItemRequest{{ProductID: 1, Quantity: 3}}, orders.lastReq.Items)
+
+	_, err = s.Checkout(ctx, 7, &CartCheckoutRequest{})
+	assert.ErrorIs(t, err, models.ErrCartEmpty)
+}
+
+func TestCartService_IdleCartExpires(t *testing.T) {
+	s, _, _ := newTestCartService()
+	ctx := context.Background()
+
+	_, err := s.AddItem(ctx, 7, &CartItemRequest{ProductID: 1, Quantity: 1})
+	require.NoError(t, err)
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	view, err := s.GetCart(ctx, 7)
+	require.NoError(t, err)
+	assert.Empty(t, view.Items)
+}
+
+func TestCartService_Reorder(t *testing.T) {
+	s, products, orders := newTestCartService()
+	products.products[1].Stock = intPtr(10)
+	products.products[2].Stock = intPtr(1)
+	products.products[2].Price = 400
+	products.products[3] = &models.Product{Model: gorm.Model{ID: 3}, Name: "Flour", Price: 150, Stock: intPtr(1)}
+	orders.past = map[uint]*models.Order{5: {
+		Model:      gorm.Model{ID: 5},
+		CustomerID: 7,
+		OrderItems: []models.OrderItem{
+			{ProductID: 1, Quantity: 1, Price: 200},
+			{ProductID: 2, Quantity: 1, Price: 350},
+			{ProductID: 3, Quantity: 2, Price: 150},
+			{ProductID: 4, Quantity: 1, Price: 90, Product: models.Product{Name: "Salt"}},
+			{ProductID: 1, Quantity: 1, Price: 200},
+		},
+	}}
+	ctx := context.Background()
+
+	_, err := s.Reorder(ctx, 8, 5, &ReorderRequest{})
+	assert.ErrorIs(t, err, models.ErrOrderNotFound, "other customers' orders are invisible")
+
+	result, err := s.Reorder(ctx, 7, 5, &ReorderRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, ReorderToCart, result.Target)
+	assert.True(t, result.HasChanges)
+	assert.Equal(t, []ReorderLine{
+		{ProductID: 1, Name: "Sugar", Quantity: 2, UnitPrice: 200, Status: CartLineOK},
+		{ProductID: 2, Name: "Tea", Quantity: 1, UnitPrice: 400, PreviousPrice: 350, Status: CartLineRepriced},
+		{ProductID: 3, Name: "Flour", Quantity: 2, UnitPrice: 150, Status: CartLineUnavailable, Reason: ReorderReasonOutOfStock},
+		{ProductID: 4, Name: "Salt", Quantity: 1, UnitPrice: 90, Status: CartLineUnavailable, Reason: ReorderReasonDiscontinued},
+	}, result.Items)
+	require.NotNil(t, result.Cart)
+	assert.Len(t, result.Cart.Items, 2)
+	assert.Equal(t, 800.0, result.Cart.Subtotal)
+
+	result, err = s.Reorder(ctx, 7, 5, &ReorderRequest{Target: ReorderToOrder, CouponCode: "KARIBU"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Order)
+	assert.Equal(t, "KARIBU", orders.lastReq.CouponCode)
+	assert.Equal(t, []OrderItemRequest{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}, orders.lastReq.Items)
+
+	products.products[1].Stock, products.products[2].Stock = intPtr(0), intPtr(0)
+	_, err = s.Reorder(ctx, 7, 5, &ReorderRequest{})
+	assert.ErrorIs(t, err, models.ErrNothingToReorder)
+
+	// A product whose stock is not tracked never runs out
+	products.products[1].Stock = nil
+	result, err = s.Reorder(ctx, 7, 5, &ReorderRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, CartLineOK, result.Items[0].Status)
+}
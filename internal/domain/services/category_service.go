@@ -1,16 +1,18 @@
 package services
 
 import (
+	"context"
+
 	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/repositories"
 )
 
 type CategoryService interface {
-	CreateCategory(req *CategoryCreateRequest) (*models.Category, error)
+	CreateCategory(ctx context.Context, req *CategoryCreateRequest) (*models.Category, error)
 	GetCategory(id uint) (*models.Category, error)
 	GetCategories() ([]models.Category, error)
-	UpdateCategory(id uint, req *CategoryUpdateRequest) (*models.Category, error)
-	DeleteCategory(id uint) error
+	UpdateCategory(ctx context.Context, id uint, req *CategoryUpdateRequest) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id uint) error
 	GetCategoryProducts(categoryID uint, page, limit int) ([]models.Product, int64, error)
 	GetAveragePrice(categoryID uint) (float64, error)
 }
@@ -33,13 +35,13 @@ func NewCategoryService(categoryRepo repositories.CategoryRepository) CategorySe
 	return &categoryService{categoryRepo: categoryRepo}
 }
 
-func (s *categoryService) CreateCategory(req *CategoryCreateRequest) (*models.Category, error) {
+func (s *categoryService) CreateCategory(ctx context.Context, req *CategoryCreateRequest) (*models.Category, error) {
 	category := &models.Category{
 		Name:     req.Name,
 		ParentID: req.ParentID,
 	}
 
-	if err := s.categoryRepo.Create(category); err != nil {
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
 		return nil, err
 	}
 
@@ -54,7 +56,7 @@ func (s *categoryService) GetCategories() ([]models.Category, error) {
 	return s.categoryRepo.GetAll()
 }
 
-func (s *categoryService) UpdateCategory(id uint, req *CategoryUpdateRequest) (*models.Category, error) {
+func (s *categoryService) UpdateCategory(ctx context.Context, id uint, req *CategoryUpdateRequest) (*models.Category, error) {
 	category, err := s.categoryRepo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -67,15 +69,15 @@ func (s *categoryService) UpdateCategory(id uint, req *CategoryUpdateRequest) (*
 		category.ParentID = req.ParentID
 	}
 
-	if err := s.categoryRepo.Update(category); err != nil {
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
 		return nil, err
 	}
 
 	return category, nil
 }
 
-func (s *categoryService) DeleteCategory(id uint) error {
-	return s.categoryRepo.Delete(id)
+func (s *categoryService) DeleteCategory(ctx context.Context, id uint) error {
+	return s.categoryRepo.Delete(ctx, id)
 }
 
 func (s *categoryService) GetCategoryProducts(categoryID uint, page, limit int) ([]models.Product, int64, error) {
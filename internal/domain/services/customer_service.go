@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+// recentOrderCount is how many of a customer's latest orders their detail
+// view shows.
+const recentOrderCount = 5
+
+// CustomerService is the customer directory used by support staff.
+type CustomerService interface {
+	SearchCustomers(ctx context.Context, req *CustomerSearchRequest) ([]repositories.CustomerSummary, int64, error)
+	GetCustomer(ctx context.Context, id uint) (*CustomerDetail, error)
+	SuspendCustomer(ctx context.Context, staffID, id uint, req *CustomerSuspendRequest) (*CustomerDetail, error)
+	ReactivateCustomer(ctx context.Context, id uint) (*CustomerDetail, error)
+	ChangeRole(ctx context.Context, adminID, id uint, req *CustomerRoleRequest, client ClientInfo) (*CustomerDetail, error)
+}
+
+// CustomerSearchRequest.Q matches part of a customer's name, email or phone;
+// a phone number in any common format also finds its E.164 form.
+type CustomerSearchRequest struct {
+	Q         string    `form:"q" binding:"max=100"`
+	From      time.Time `form:"from" time_format:"2006-01-02"`
+	To        time.Time `form:"to" time_format:"2006-01-02"`
+	MinOrders int       `form:"min_orders" binding:"min=0"`
+	MaxOrders *int      `form:"max_orders" binding:"omitempty,min=0"`
+	Status    string    `form:"status" binding:"omitempty,oneof=active suspended"`
+	Sort      string    `form:"sort" binding:"omitempty,oneof=created_at -created_at orders -orders lifetime_value -lifetime_value"`
+	Page      int       `form:"page,default=1" binding:"min=1"`
+	Limit     int       `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+type CustomerSuspendRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type CustomerRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=customer staff admin"`
+}
+
+// CustomerDetail is a customer with their order stats and latest orders.
+// AverageOrderValue is over the orders counted in LifetimeValue.
+type CustomerDetail struct {
+	Customer          *repositories.CustomerSummary `json:"customer"`
+	AverageOrderValue float64                       `json:"average_order_value"`
+	RecentOrders      []models.Order                `json:"recent_orders"`
+}
+
+type customerService struct {
+	customerRepo repositories.CustomerRepository
+	orderRepo    repositories.OrderRepository
+	audit        AuthAuditService
+	now          func() time.Time
+}
+
+// NewCustomerService records role changes in the audit log when audit is
+// set.
+func NewCustomerService(customerRepo repositories.CustomerRepository, orderRepo repositories.OrderRepository, audit AuthAuditService) CustomerService {
+	return &customerService{
+		customerRepo: customerRepo,
+		orderRepo:    orderRepo,
+		audit:        audit,
+		now:          time.Now,
+	}
+}
+
+func (s *customerService) SearchCustomers(ctx context.Context, req *CustomerSearchRequest) ([]repositories.CustomerSummary, int64, error) {
+	return s.customerRepo.Search(ctx, customerFilter(req), req.Page, req.Limit)
+}
+
+func customerFilter(req *CustomerSearchRequest) repositories.CustomerFilter {
+	filter := repositories.CustomerFilter{
+		Query:      strings.TrimSpace(req.Q),
+		From:       req.From,
+		MinOrders:  req.MinOrders,
+		MaxOrders:  req.MaxOrders,
+		Sort:       strings.TrimPrefix(req.Sort, "-"),
+		Descending: strings.HasPrefix(req.Sort, "-"),
+	}
+	if phone, err := models.NormalizePhone(filter.Query); err == nil {
+		filter.Phone = phone
+	}
+	if !req.To.IsZero() {
+		// The whole of the To day is included
+		filter.To = req.To.AddDate(0, 0, 1)
+	}
+	if req.Status != "" {
+		suspended := req.Status == "suspended"
+		filter.Suspended = &suspended
+	}
+	return filter
+}
+
+func (s *customerService) GetCustomer(ctx context.Context, id uint) (*CustomerDetail, error) {
+	customer, err := s.customerRepo.GetSummary(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+
+	orders, _, err := s.orderRepo.Search(ctx, repositories.OrderFilter{CustomerID: id}, 1, recentOrderCount)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &CustomerDetail{Customer: customer, RecentOrders: orders}
+	if customer.PaidOrderCount > 0 {
+		detail.AverageOrderValue = roundMoney(customer.LifetimeValue / float64(customer.PaidOrderCount))
+	}
+	return detail, nil
+}
+
+// SuspendCustomer shuts a customer out of the API until they are reactivated.
+// Staff cannot suspend themselves.
+func (s *customerService) SuspendCustomer(ctx context.Context, staffID, id uint, req *CustomerSuspendRequest) (*CustomerDetail, error) {
+	if staffID == id {
+		return nil, models.ErrSuspendSelf
+	}
+
+	suspended, err := s.customerRepo.Suspend(ctx, id, s.now(), strings.TrimSpace(req.Reason))
+	if err != nil {
+		return nil, err
+	}
+	detail, err := s.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !suspended {
+		return nil, models.ErrCustomerAlreadySuspended
+	}
+	return detail, nil
+}
+
+func (s *customerService) ReactivateCustomer(ctx context.Context, id uint) (*CustomerDetail, error) {
+	reactivated, err := s.customerRepo.Reactivate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := s.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !reactivated {
+		return nil, models.ErrCustomerNotSuspended
+	}
+	return detail, nil
+}
+
+// ChangeRole makes a customer a customer, staff member or admin, and records
+// the change in the audit log. Admins cannot change their own role, so the
+// last admin cannot lock everyone out. adminID is 0 for a change made from
+// the command line, which is recorded without an actor.
+func (s *customerService) ChangeRole(ctx context.Context, adminID, id uint, req *CustomerRoleRequest, client ClientInfo) (*CustomerDetail, error) {
+	if req.Role != models.RoleCustomer && req.Role != models.RoleStaff && req.Role != models.RoleAdmin {
+		return nil, models.ErrInvalidRole
+	}
+	if adminID == id {
+		return nil, models.ErrChangeOwnRole
+	}
+
+	before, err := s.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.customerRepo.SetRole(ctx, id, req.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, models.ErrRoleUnchanged
+	}
+
+	if s.audit != nil {
+		event := &models.AuthEvent{
+			Type:       models.AuthEventRoleChange,
+			CustomerID: &id,
+			IP:         client.IP,
+			UserAgent:  client.UserAgent,
+			Reason:     before.Customer.Role + " -> " + req.Role,
+		}
+		if adminID != 0 {
+			event.ActorID = &adminID
+		}
+		s.audit.Record(ctx, event)
+	}
+	return s.GetCustomer(ctx, id)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+func TestCustomerFilter(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	maxOrders := 0
+	filter := customerFilter(&CustomerSearchRequest{
+		Q: " 0712 345 678 ", From: day, To: day, MaxOrders: &maxOrders, Status: "suspended", Sort: "-lifetime_value",
+	})
+
+	suspended := true
+	assert.Equal(t, repositories.CustomerFilter{
+		Query: "0712 345 678", Phone: "+254712345678", From: day, To: day.AddDate(0, 0, 1),
+		MaxOrders: &maxOrders, Suspended: &suspended, Sort: "lifetime_value", Descending: true,
+	}, filter, "phone searches also match the stored E.164 form")
+
+	filter = customerFilter(&CustomerSearchRequest{Q: "amina"})
+	assert.Empty(t, filter.Phone)
+	assert.Nil(t, filter.Suspended)
+}
+
+func TestCustomerService_SuspendAndReactivate(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{
+		1: {Model: gorm.Model{ID: 1}, FirstName: "Staff", Role: models.RoleStaff},
+		7: {Model: gorm.Model{ID: 7}, FirstName: "Amina"},
+	}}
+	orderRepo := newFakeOrderRepo()
+	s := &customerService{customerRepo: customers, orderRepo: orderRepo, now: time.Now}
+	ctx := context.Background()
+	req := &CustomerSuspendRequest{Reason: " chargebacks "}
+
+	_, err := s.SuspendCustomer(ctx, 1, 1, req)
+	assert.ErrorIs(t, err, models.ErrSuspendSelf)
+
+	_, err = s.SuspendCustomer(ctx, 1, 99, req)
+	assert.ErrorIs(t, err, models.ErrCustomerNotFound)
+
+	detail, err := s.SuspendCustomer(ctx, 1, 7, req)
+	require.NoError(t, err)
+	assert.True(t, detail.Customer.Suspended())
+	assert.Equal(t, "chargebacks", detail.Customer.SuspensionReason)
+	assert.Len(t, detail.RecentOrders, 1)
+
+	_, err = s.SuspendCustomer(ctx, 1, 7, req)
+	assert.ErrorIs(t, err, models.ErrCustomerAlreadySuspended)
+
+	detail, err = s.ReactivateCustomer(ctx, 7)
+	require.NoError(t, err)
+	assert.False(t, detail.Customer.Suspended())
+
+	_, err = s.ReactivateCustomer(ctx, 7)
+	assert.ErrorIs(t, err, models.ErrCustomerNotSuspended)
+}
+
+func TestCustomerService_ChangeRole(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{
+		1: {Model: gorm.Model{ID: 1}, FirstName: "Admin", Role: models.RoleAdmin},
+		7: {Model: gorm.Model{ID: 7}, FirstName: "Amina", Role: models.RoleCustomer},
+	}}
+	audit, events, _ := newTestAuthAuditService(customers)
+	orderRepo := newFakeOrderRepo()
+	s := &customerService{customerRepo: customers, orderRepo: orderRepo, audit: audit, now: time.Now}
+	ctx := context.Background()
+	client := ClientInfo{IP: "105.161.2.3", UserAgent: "Mozilla/5.0"}
+
+	_, err := s.ChangeRole(ctx, 1, 1, &CustomerRoleRequest{Role: models.RoleCustomer}, client)
+	assert.ErrorIs(t, err, models.ErrChangeOwnRole)
+
+	detail, err := s.ChangeRole(ctx, 1, 7, &CustomerRoleRequest{Role: models.RoleStaff}, client)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleStaff, detail.Customer.Role)
+	require.Len(t, events.events, 1)
+	assert.Equal(t, models.AuthEventRoleChange, events.events[0].Type)
+	assert.Equal(t, "customer -> staff", events.events[0].Reason)
+	assert.Equal(t, uint(1), *events.events[0].ActorID)
+
+	_, err = s.ChangeRole(ctx, 1, 7, &CustomerRoleRequest{Role: models.RoleStaff}, client)
+	assert.ErrorIs(t, err, models.ErrRoleUnchanged)
+	assert.Len(t, events.events, 1)
+
+	// From the command line there is no admin to record
+	_, err = s.ChangeRole(ctx, 0, 7, &CustomerRoleRequest{Role: models.RoleAdmin}, ClientInfo{})
+	require.NoError(t, err)
+	require.Len(t, events.events, 2)
+	assert.Nil(t, events.events[1].ActorID)
+}
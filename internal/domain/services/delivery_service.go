@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type DeliveryService interface {
+	CreateZone(ctx context.Context, req *DeliveryZoneRequest) (*models.DeliveryZone, error)
+	GetZone(ctx context.Context, id uint) (*models.DeliveryZone, error)
+	GetZones(ctx context.Context) ([]models.DeliveryZone, error)
+	UpdateZone(ctx context.Context, id uint, req *DeliveryZoneRequest) (*models.DeliveryZone, error)
+	DeleteZone(ctx context.Context, id uint) error
+	Quote(ctx context.Context, county, town string, weightKg float64) (*models.DeliveryQuote, error)
+}
+
+type DeliveryZoneRequest struct {
+	Name  string                `json:"name" binding:"required,max=100"`
+	Areas []DeliveryAreaRequest `json:"areas" binding:"required,min=1,dive"`
+	Rates []DeliveryRateRequest `json:"rates" binding:"required,min=1,dive"`
+}
+
+// DeliveryAreaRequest covers a whole county, or only one town in it when Town
+// is set.
+type DeliveryAreaRequest struct {
+	County string `json:"county" binding:"required"`
+	Town   string `json:"town" binding:"max=100"`
+}
+
+type DeliveryRateRequest struct {
+	MaxWeightKg float64 `json:"max_weight_kg" binding:"gt=0"`
+	Fee         float64 `json:"fee" binding:"gte=0"`
+}
+
+type deliveryService struct {
+	deliveryRepo repositories.DeliveryRepository
+}
+
+func NewDeliveryService(deliveryRepo repositories.DeliveryRepository) DeliveryService {
+	return &deliveryService{deliveryRepo: deliveryRepo}
+}
+
+func (s *deliveryService) CreateZone(ctx context.Context, req *DeliveryZoneRequest) (*models.DeliveryZone, error) {
+	zone := &models.DeliveryZone{}
+	if err := fillZone(zone, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.deliveryRepo.CreateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+
+	return zone, nil
+}
+
+func (s *deliveryService) GetZone(ctx context.Context, id uint) (*models.DeliveryZone, error) {
+	zone, err := s.deliveryRepo.GetZone(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrDeliveryZoneNotFound
+	}
+	return zone, err
+}
+
+func (s *deliveryService) GetZones(ctx context.Context) ([]models.DeliveryZone, error) {
+	return s.deliveryRepo.GetZones(ctx)
+}
+
+func (s *deliveryService) UpdateZone(ctx context.Context, id uint, req *DeliveryZoneRequest) (*models.DeliveryZone, error) {
+	zone, err := s.GetZone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fillZone(zone, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.deliveryRepo.UpdateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+
+	return zone, nil
+}
+
+func (s *deliveryService) DeleteZone(ctx context.Context, id uint) error {
+	err := s.deliveryRepo.DeleteZone(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrDeliveryZoneNotFound
+	}
+	return err
+}
+
+// Quote prices delivery of a basket of the given weight to a town.
+func (s *deliveryService) Quote(ctx context.Context, county, town string, weightKg float64) (*models.DeliveryQuote, error) {
+	zone, err := s.deliveryRepo.FindZone(ctx, strings.TrimSpace(county), strings.TrimSpace(town))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrNoDeliveryZone
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := pickDeliveryRate(zone.Rates, weightKg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeliveryQuote{
+		ZoneID:   zone.ID,
+		Zone:     zone.Name,
+		WeightKg: weightKg,
+		Fee:      rate.Fee,
+	}, nil
+}
+
+// pickDeliveryRate returns the lightest weight band the basket fits in.
+func pickDeliveryRate(rates []models.DeliveryRate, weightKg float64) (*models.DeliveryRate, error) {
+	var best *models.DeliveryRate
+	for i := range rates {
+		if rates[i].MaxWeightKg < weightKg {
+			continue
+		}
+		if best == nil || rates[i].MaxWeightKg < best.MaxWeightKg {
+			best = &rates[i]
+		}
+	}
+	if best == nil {
+		return nil, models.ErrDeliveryOverweight
+	}
+	return best, nil
+}
+
+func fillZone(zone *models.DeliveryZone, req *DeliveryZoneRequest) error {
+	zone.Name = strings.TrimSpace(req.Name)
+
+	zone.Areas = make([]models.DeliveryArea, 0, len(req.Areas))
+	for _, area := range req.Areas {
+		county, err := models.CanonicalCounty(area.County)
+		if err != nil {
+			return err
+		}
+		zone.Areas = append(zone.Areas, models.DeliveryArea{County: county, Town: strings.TrimSpace(area.Town)})
+	}
+
+	zone.Rates = make([]models.DeliveryRate, 0, len(req.Rates))
+	for _, rate := range req.Rates {
+		zone.Rates = append(zone.Rates, models.DeliveryRate{MaxWeightKg: rate.MaxWeightKg, Fee: rate.Fee})
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestQuote(t *testing.T) {
+	rates := func(fees ...float64) []models.DeliveryRate {
+		return []models.DeliveryRate{
+			{MaxWeightKg: 20, Fee: fees[2]},
+			{MaxWeightKg: 2, Fee: fees[0]},
+			{MaxWeightKg: 5, Fee: fees[1]},
+		}
+	}
+	repo := &fakeDeliveryRepo{zones: []models.DeliveryZone{
+		{Model: gorm.Model{ID: 1}, Name: "Nairobi CBD", Areas: []models.DeliveryArea{{County: "Nairobi", Town: "CBD"}}, Rates: rates(100, 150, 400)},
+		{Model: gorm.Model{ID: 2}, Name: "Greater Nairobi", Areas: []models.DeliveryArea{{County: "Nairobi"}, {County: "Kiambu"}}, Rates: rates(250, 350, 800)},
+	}}
+	svc := NewDeliveryService(repo)
+	ctx := context.Background()
+
+	quote, err := svc.Quote(ctx, "nairobi", "cbd", 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, "Nairobi CBD", quote.Zone, "a town-specific area beats the county")
+	assert.Equal(t, 100.0, quote.Fee)
+
+	quote, err = svc.Quote(ctx, "Nairobi", "Karen", 2.5)
+	require.NoError(t, err)
+	assert.Equal(t, "Greater Nairobi", quote.Zone)
+	assert.Equal(t, 350.0, quote.Fee)
+
+	quote, err = svc.Quote(ctx, "Kiambu", "Thika", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 350.0, quote.Fee, "band limits are inclusive")
+
+	_, err = svc.Quote(ctx, "Kiambu", "Thika", 25)
+	assert.ErrorIs(t, err, models.ErrDeliveryOverweight)
+
+	_, err = svc.Quote(ctx, "Turkana", "Lodwar", 1)
+	assert.ErrorIs(t, err, models.ErrNoDeliveryZone)
+}
+
+func TestFillZone_CanonicalisesCounties(t *testing.T) {
+	zone := &models.DeliveryZone{}
+	err := fillZone(zone, &DeliveryZoneRequest{
+		Name:  " Coast ",
+		Areas: []DeliveryAreaRequest{{County: "mombasa county"}, {County: "TAITA TAVETA", Town: " Voi "}},
+		Rates: []DeliveryRateRequest{{MaxWeightKg: 5, Fee: 300}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Coast", zone.Name)
+	assert.Equal(t, []models.DeliveryArea{{County: "Mombasa"}, {County: "Taita Taveta", Town: "Voi"}}, zone.Areas)
+
+	err = fillZone(zone, &DeliveryZoneRequest{Name: "Nowhere", Areas: []DeliveryAreaRequest{{County: "Atlantis"}}})
+	assert.ErrorIs(t, err, models.ErrUnknownCounty)
+}
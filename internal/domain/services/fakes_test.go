@@ -0,0 +1,1015 @@
+package services
+
+// In-memory fakes of the repositories and collaborators the service tests
+// share. Each test sets up only the data it needs.
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+	"github.com/Mutonya/Savanah/pkg/geoip"
+	"github.com/Mutonya/Savanah/pkg/payments"
+)
+
+type fakePaymentRepo struct {
+	payments []*models.Payment
+	refunds  []*models.Refund
+}
+
+func (r *fakePaymentRepo) Create(ctx context.Context, payment *models.Payment) error {
+	payment.ID = uint(len(r.payments) + 1)
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = time.Now()
+	}
+	stored := *payment
+	r.payments = append(r.payments, &stored)
+	return nil
+}
+
+func (r *fakePaymentRepo) GetByProviderReference(ctx context.Context, provider, reference string) (*models.Payment, error) {
+	for _, p := range r.payments {
+		if p.Provider == provider && p.ProviderReference == reference {
+			c := *p
+			return &c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePaymentRepo) GetByOrderID(ctx context.Context, orderID uint) ([]models.Payment, error) {
+	var out []models.Payment
+	for _, p := range r.payments {
+		if p.OrderID == orderID {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepo) GetPendingBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Payment, error) {
+	var out []models.Payment
+	for _, p := range r.payments {
+		if p.Status == models.PaymentStatusPending && p.CreatedAt.Before(cutoff) {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepo) Settle(ctx context.Context, payment *models.Payment) (bool, error) {
+	stored := r.payments[payment.ID-1]
+	if stored.Status != models.PaymentStatusPending {
+		return false, nil
+	}
+	stored.Status = payment.Status
+	stored.ProviderTransactionID = payment.ProviderTransactionID
+	stored.ResultCode = payment.ResultCode
+	stored.ResultDesc = payment.ResultDesc
+	return true, nil
+}
+
+func (r *fakePaymentRepo) ReserveRefund(ctx context.Context, paymentID uint, amount float64) (bool, error) {
+	stored := r.payments[paymentID-1]
+	if stored.Status != models.PaymentStatusSucceeded || stored.RefundedAmount+amount > stored.Amount {
+		return false, nil
+	}
+	stored.RefundedAmount += amount
+	return true, nil
+}
+
+func (r *fakePaymentRepo) ReleaseRefund(ctx context.Context, paymentID uint, amount float64) error {
+	r.payments[paymentID-1].RefundedAmount -= amount
+	return nil
+}
+
+func (r *fakePaymentRepo) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	refund.ID = uint(len(r.refunds) + 1)
+	if refund.CreatedAt.IsZero() {
+		refund.CreatedAt = time.Now()
+	}
+	stored := *refund
+	r.refunds = append(r.refunds, &stored)
+	return nil
+}
+
+func (r *fakePaymentRepo) GetUnsentRefundsBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Refund, error) {
+	var out []models.Refund
+	for _, refund := range r.refunds {
+		if refund.Status == models.PaymentStatusPending && refund.ProviderReference == "" && refund.CreatedAt.Before(cutoff) {
+			c := *refund
+			c.Payment = *r.payments[refund.PaymentID-1]
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepo) GetRefundByProviderReference(ctx context.Context, reference string) (*models.Refund, error) {
+	for _, refund := range r.refunds {
+		if refund.ProviderReference == reference {
+			c := *refund
+			return &c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePaymentRepo) SettleRefund(ctx context.Context, refund *models.Refund) (bool, error) {
+	stored := r.refunds[refund.ID-1]
+	if stored.Status != models.PaymentStatusPending {
+		return false, nil
+	}
+	*stored = *refund
+	return true, nil
+}
+
+type fakeOrderRepo struct {
+	orders map[uint]*models.Order
+}
+
+func (r *fakeOrderRepo) Create(ctx context.Context, order *models.Order) error {
+	order.ID = uint(len(r.orders) + 1)
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *fakeOrderRepo) GetByID(ctx context.Context, id uint) (*models.Order, error) {
+	o, ok := r.orders[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c := *o
+	return &c, nil
+}
+
+func (r *fakeOrderRepo) GetByReference(ctx context.Context, reference string) (*models.Order, error) {
+	for _, o := range r.orders {
+		if o.Reference == reference {
+			c := *o
+			return &c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOrderRepo) GetByCustomerID(ctx context.Context, customerID uint, page, limit int) ([]models.Order, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeOrderRepo) Search(ctx context.Context, filter repositories.OrderFilter, page, limit int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	for _, o := range r.orders {
+		if filter.CustomerID != 0 && o.CustomerID != filter.CustomerID {
+			continue
+		}
+		orders = append(orders, *o)
+	}
+	return orders, int64(len(orders)), nil
+}
+
+func (r *fakeOrderRepo) Update(ctx context.Context, order *models.Order) error {
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *fakeOrderRepo) UpdateStatus(ctx context.Context, orderID uint, status models.OrderStatus) error {
+	r.orders[orderID].Status = status
+	return nil
+}
+
+func (r *fakeOrderRepo) TransitionStatus(ctx context.Context, orderID uint, from, to models.OrderStatus) (bool, error) {
+	if r.orders[orderID].Status != from {
+		return false, nil
+	}
+	r.orders[orderID].Status = to
+	return true, nil
+}
+
+func (r *fakeOrderRepo) Cancel(ctx context.Context, orderID uint, reason string) (models.OrderStatus, error) {
+	o := r.orders[orderID]
+	if !o.Status.Cancellable() {
+		return "", models.ErrOrderNotCancellable
+	}
+	previous := o.Status
+	o.Status = models.OrderStatusCancelled
+	o.CancelReason = reason
+	return previous, nil
+}
+
+func (r *fakeOrderRepo) HasPurchased(ctx context.Context, customerID, productID uint) (bool, error) {
+	for _, o := range r.orders {
+		if o.CustomerID != customerID || o.Status != models.OrderStatusCompleted {
+			continue
+		}
+		for _, item := range o.OrderItems {
+			if item.ProductID == productID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+type fakeNotifier struct {
+	NotificationService
+	statusUpdates   []models.OrderStatus
+	cancellations   []*models.Refund
+	returns         []models.ReturnStatus
+	backInStock     []string
+	failBackInStock bool
+	codes           map[string]string
+	securityAlerts  []string
+}
+
+func (n *fakeNotifier) SendOTP(phone, code string, ttl time.Duration) error {
+	if n.codes == nil {
+		n.codes = map[string]string{}
+	}
+	n.codes[phone] = code
+	return nil
+}
+
+func (n *fakeNotifier) SendOrderCancelled(order *models.Order, refund *models.Refund) error {
+	n.cancellations = append(n.cancellations, refund)
+	return nil
+}
+
+func (n *fakeNotifier) SendReturnUpdate(order *models.Order, rma *models.ReturnRequest) error {
+	n.returns = append(n.returns, rma.Status)
+	return nil
+}
+
+func (n *fakeNotifier) SendBackInStock(alert *models.StockAlert) error {
+	if n.failBackInStock {
+		return errors.New("sms gateway down")
+	}
+	n.backInStock = append(n.backInStock, alert.Channel)
+	return nil
+}
+
+func (n *fakeNotifier) SendStatusUpdate(order *models.Order) error {
+	n.statusUpdates = append(n.statusUpdates, order.Status)
+	return nil
+}
+
+func intPtr(v int) *int { return &v }
+
+// newFakeOrderRepo holds one pending order: ID 1, by customer 7, for 1000.
+func newFakeOrderRepo() *fakeOrderRepo {
+	return &fakeOrderRepo{orders: map[uint]*models.Order{
+		1: {Model: gorm.Model{ID: 1}, CustomerID: 7, Status: models.OrderStatusPending, Total: 1000},
+	}}
+}
+
+// newTestPaymentService takes payments for the order in newFakeOrderRepo
+// through an in-memory provider named "memory".
+func newTestPaymentService() (*paymentService, *payments.MemoryProvider, *fakePaymentRepo, *fakeOrderRepo) {
+	provider := payments.NewMemoryProvider("memory")
+	paymentRepo := &fakePaymentRepo{}
+	orderRepo := newFakeOrderRepo()
+	return &paymentService{
+		paymentRepo:    paymentRepo,
+		orderRepo:      orderRepo,
+		notifier:       &fakeNotifier{},
+		providers:      map[string]payments.PaymentProvider{provider.Name(): provider},
+		pendingTimeout: 10 * time.Minute,
+		now:            time.Now,
+	}, provider, paymentRepo, orderRepo
+}
+
+type fakeCustomerRepo struct {
+	repositories.CustomerRepository
+	customers map[uint]*models.Customer
+}
+
+func (r *fakeCustomerRepo) GetByID(id uint) (*models.Customer, error) {
+	customer, ok := r.customers[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c := *customer
+	return &c, nil
+}
+
+func (r *fakeCustomerRepo) Update(ctx context.Context, customer *models.Customer) error {
+	c := *customer
+	r.customers[customer.ID] = &c
+	return nil
+}
+
+func (r *fakeCustomerRepo) Erase(ctx context.Context, id uint, at time.Time) error {
+	customer, ok := r.customers[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	customer.FirstName, customer.Email, customer.Phone = models.ErasedCustomerName, models.ErasedCustomerEmail(id), ""
+	customer.ErasedAt = &at
+	return nil
+}
+
+type fakeNotificationRepo struct {
+	repositories.NotificationRepository
+	entries []models.Notification
+}
+
+func (r *fakeNotificationRepo) Create(ctx context.Context, n *models.Notification) error {
+	r.entries = append(r.entries, *n)
+	return nil
+}
+
+type fakeCartRepo struct {
+	cart *models.Cart
+}
+
+func (r *fakeCartRepo) GetByCustomerID(ctx context.Context, customerID uint) (*models.Cart, error) {
+	if r.cart == nil || r.cart.CustomerID != customerID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c := *r.cart
+	return &c, nil
+}
+
+func (r *fakeCartRepo) Create(ctx context.Context, cart *models.Cart) error {
+	cart.ID = 1
+	r.cart = cart
+	return nil
+}
+
+func (r *fakeCartRepo) Touch(ctx context.Context, cartID uint, at time.Time) error {
+	r.cart.LastActivityAt = at
+	return nil
+}
+
+func (r *fakeCartRepo) AddItem(ctx context.Context, item *models.CartItem) error {
+	item.ID = uint(len(r.cart.Items) + 1)
+	r.cart.Items = append(r.cart.Items, *item)
+	return nil
+}
+
+func (r *fakeCartRepo) UpdateItem(ctx context.Context, item *models.CartItem) error { return nil }
+
+func (r *fakeCartRepo) DeleteItem(ctx context.Context, cartID, itemID uint) error { return nil }
+
+func (r *fakeCartRepo) Delete(ctx context.Context, cartID uint) error {
+	r.cart = nil
+	return nil
+}
+
+func (r *fakeCartRepo) DeleteIdleSince(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeProductRepo struct {
+	products map[uint]*models.Product
+}
+
+func (r *fakeProductRepo) Create(ctx context.Context, product *models.Product) error { return nil }
+
+func (r *fakeProductRepo) GetByID(ctx context.Context, id uint) (*models.Product, error) {
+	p, ok := r.products[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c := *p
+	return &c, nil
+}
+
+func (r *fakeProductRepo) GetBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	for _, p := range r.products {
+		if p.SKU == sku {
+			c := *p
+			return &c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeProductRepo) GetAll(ctx context.Context, page, limit int) ([]models.Product, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeProductRepo) Update(ctx context.Context, product *models.Product) error { return nil }
+
+func (r *fakeProductRepo) Delete(ctx context.Context, id uint) error {
+	delete(r.products, id)
+	return nil
+}
+
+func (r *fakeProductRepo) GetByCategory(ctx context.Context, categoryID uint, page, limit int) ([]models.Product, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeProductRepo) GetAveragePrice(ctx context.Context, categoryID uint) (float64, error) {
+	return 0, nil
+}
+
+type fakeOrderService struct {
+	OrderService
+	lastReq *OrderCreateRequest
+	past    map[uint]*models.Order
+}
+
+func (s *fakeOrderService) GetOrder(ctx context.Context, customerID, orderID uint) (*models.Order, error) {
+	order, ok := s.past[orderID]
+	if !ok || order.CustomerID != customerID {
+		return nil, models.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *fakeOrderService) CreateOrder(ctx context.Context, customerID uint, req *OrderCreateRequest) (*models.Order, error) {
+	s.lastReq = req
+	return &models.Order{Model: gorm.Model{ID: 42}, CustomerID: customerID}, nil
+}
+
+type fakeAuthEventRepo struct {
+	repositories.AuthEventRepository
+	events []models.AuthEvent
+}
+
+func (r *fakeAuthEventRepo) Create(ctx context.Context, event *models.AuthEvent) error {
+	event.ID = uint(len(r.events) + 1)
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeAuthEventRepo) CountFromIP(ctx context.Context, eventType, ip string, since time.Time) (int64, error) {
+	var count int64
+	for _, e := range r.events {
+		if e.Type == eventType && e.IP == ip && !e.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeAuthEventRepo) LoginCountries(ctx context.Context, customerID uint) ([]string, error) {
+	var countries []string
+	for _, e := range r.events {
+		if e.Type == models.AuthEventLogin && e.CustomerID != nil && *e.CustomerID == customerID && e.Country != "" {
+			countries = append(countries, e.Country)
+		}
+	}
+	return countries, nil
+}
+
+func (n *fakeNotifier) SendNewCountrySignIn(customer *models.Customer, event *models.AuthEvent) error {
+	n.securityAlerts = append(n.securityAlerts, "new country "+event.Country+" for "+customer.Email)
+	return nil
+}
+
+func (n *fakeNotifier) SendSecurityAlert(event *models.AuthEvent, summary string) error {
+	n.securityAlerts = append(n.securityAlerts, summary)
+	return nil
+}
+
+func newTestAuthAuditService(customers *fakeCustomerRepo) (*authAuditService, *fakeAuthEventRepo, *fakeNotifier) {
+	geo, err := geoip.Load(strings.NewReader("105.160.0.0,105.167.255.255,KE\n41.0.0.0,41.0.255.255,ZA\n"))
+	if err != nil {
+		panic(err)
+	}
+	events := &fakeAuthEventRepo{}
+	notifier := &fakeNotifier{}
+	return &authAuditService{
+		eventRepo:    events,
+		customerRepo: customers,
+		notifier:     notifier,
+		geo:          geo,
+		config:       &config.Config{AuthFailedAttemptLimit: 3, AuthFailedAttemptWindow: 15 * time.Minute},
+		now:          time.Now,
+	}, events, notifier
+}
+
+type fakeAPIKeyRepo struct {
+	keys map[uint]*models.APIKey
+}
+
+func (r *fakeAPIKeyRepo) List(ctx context.Context, includeRevoked bool) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	for _, key := range r.keys {
+		if includeRevoked || key.RevokedAt == nil {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepo) Get(ctx context.Context, id uint) (*models.APIKey, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	k := *key
+	return &k, nil
+}
+
+func (r *fakeAPIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			k := *key
+			return &k, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeyRepo) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = uint(len(r.keys) + 1)
+	k := *key
+	r.keys[key.ID] = &k
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) Rotate(ctx context.Context, old, replacement *models.APIKey, retireAt time.Time) (bool, error) {
+	stored := r.keys[old.ID]
+	if stored.RevokedAt != nil || stored.ReplacedByID != nil {
+		return false, nil
+	}
+	if err := r.Create(ctx, replacement); err != nil {
+		return false, err
+	}
+	stored.RevokedAt, stored.ReplacedByID = &retireAt, &replacement.ID
+	return true, nil
+}
+
+func (r *fakeAPIKeyRepo) Revoke(ctx context.Context, id uint, at time.Time) (bool, error) {
+	key, ok := r.keys[id]
+	if !ok || (key.RevokedAt != nil && !key.RevokedAt.After(at)) {
+		return false, nil
+	}
+	key.RevokedAt = &at
+	return true, nil
+}
+
+func (r *fakeAPIKeyRepo) TouchLastUsed(ctx context.Context, id uint, at, staleBefore time.Time) error {
+	if key := r.keys[id]; key.LastUsedAt == nil || key.LastUsedAt.Before(staleBefore) {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
+
+func (r *fakeCustomerRepo) GetByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	for _, customer := range r.customers {
+		if strings.EqualFold(customer.Email, email) {
+			c := *customer
+			return &c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeIdentityRepo struct {
+	repositories.IdentityRepository
+	customers  *fakeCustomerRepo
+	identities []models.Identity
+}
+
+func (r *fakeIdentityRepo) Get(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			i := identity
+			return &i, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepo) Create(ctx context.Context, identity *models.Identity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) Register(ctx context.Context, customer *models.Customer, identity *models.Identity) error {
+	customer.ID = uint(100 + len(r.customers.customers))
+	r.customers.customers[customer.ID] = customer
+	identity.CustomerID = customer.ID
+	return r.Create(ctx, identity)
+}
+
+func (r *fakeIdentityRepo) TouchLogin(ctx context.Context, id uint, at time.Time) error {
+	return nil
+}
+
+type fakeSessionRepo struct {
+	repositories.SessionRepository
+	sessions []models.Session
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *models.Session) error {
+	session.ID = uint(len(r.sessions) + 1)
+	r.sessions = append(r.sessions, *session)
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	for _, session := range r.sessions {
+		if session.TokenHash == hash {
+			s := session
+			return &s, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCustomerRepo) GetSummary(ctx context.Context, id uint) (*repositories.CustomerSummary, error) {
+	customer, ok := r.customers[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &repositories.CustomerSummary{Customer: *customer}, nil
+}
+
+func (r *fakeCustomerRepo) Suspend(ctx context.Context, id uint, at time.Time, reason string) (bool, error) {
+	customer, ok := r.customers[id]
+	if !ok || customer.Suspended() {
+		return false, nil
+	}
+	customer.SuspendedAt, customer.SuspensionReason = &at, reason
+	return true, nil
+}
+
+func (r *fakeCustomerRepo) Reactivate(ctx context.Context, id uint) (bool, error) {
+	customer, ok := r.customers[id]
+	if !ok || !customer.Suspended() {
+		return false, nil
+	}
+	customer.SuspendedAt, customer.SuspensionReason = nil, ""
+	return true, nil
+}
+
+func (r *fakeCustomerRepo) SetRole(ctx context.Context, id uint, role string) (bool, error) {
+	customer, ok := r.customers[id]
+	if !ok || customer.Role == role {
+		return false, nil
+	}
+	customer.Role = role
+	return true, nil
+}
+
+type fakeDeliveryRepo struct {
+	repositories.DeliveryRepository
+	zones []models.DeliveryZone
+}
+
+func (r *fakeDeliveryRepo) FindZone(ctx context.Context, county, town string) (*models.DeliveryZone, error) {
+	var countyWide *models.DeliveryZone
+	for i := range r.zones {
+		for _, area := range r.zones[i].Areas {
+			if !strings.EqualFold(area.County, county) {
+				continue
+			}
+			if strings.EqualFold(area.Town, town) {
+				return &r.zones[i], nil
+			}
+			if area.Town == "" {
+				countyWide = &r.zones[i]
+			}
+		}
+	}
+	if countyWide == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return countyWide, nil
+}
+
+// fakeInvoiceRepo mimics the database: Issue bumps the year's counter and
+// inserts the invoice atomically, and rolls the counter back when the order
+// already has an invoice.
+type fakeInvoiceRepo struct {
+	mu       sync.Mutex
+	counters map[string]uint
+	byOrder  map[uint]*models.Invoice
+}
+
+func newFakeInvoiceRepo() *fakeInvoiceRepo {
+	return &fakeInvoiceRepo{counters: map[string]uint{}, byOrder: map[uint]*models.Invoice{}}
+}
+
+func (r *fakeInvoiceRepo) GetByOrderID(ctx context.Context, orderID uint) (*models.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invoice, ok := r.byOrder[orderID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c := *invoice
+	return &c, nil
+}
+
+func (r *fakeInvoiceRepo) Issue(ctx context.Context, invoice *models.Invoice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byOrder[invoice.OrderID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	series := models.InvoiceSeries(invoice.Year)
+	r.counters[series]++
+	invoice.Sequence = r.counters[series]
+	invoice.Number = models.InvoiceNumber(invoice.Year, invoice.Sequence)
+	c := *invoice
+	r.byOrder[invoice.OrderID] = &c
+	return nil
+}
+
+type fakeOTPRepo struct {
+	otps   map[string]*models.OTP
+	nextID uint
+}
+
+func otpKey(purpose models.OTPPurpose, subject string) string {
+	return string(purpose) + "|" + subject
+}
+
+func (r *fakeOTPRepo) Get(ctx context.Context, purpose models.OTPPurpose, subject string) (*models.OTP, error) {
+	otp, ok := r.otps[otpKey(purpose, subject)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c := *otp
+	return &c, nil
+}
+
+func (r *fakeOTPRepo) Save(ctx context.Context, otp *models.OTP) error {
+	r.nextID++
+	otp.ID = r.nextID
+	c := *otp
+	r.otps[otpKey(otp.Purpose, otp.Subject)] = &c
+	return nil
+}
+
+func (r *fakeOTPRepo) byID(id uint) (string, *models.OTP) {
+	for key, otp := range r.otps {
+		if otp.ID == id {
+			return key, otp
+		}
+	}
+	return "", nil
+}
+
+func (r *fakeOTPRepo) RecordAttempt(ctx context.Context, id uint, maxAttempts int) (bool, error) {
+	_, otp := r.byID(id)
+	if otp == nil || otp.Attempts >= maxAttempts {
+		return false, nil
+	}
+	otp.Attempts++
+	return true, nil
+}
+
+func (r *fakeOTPRepo) Delete(ctx context.Context, id uint) (bool, error) {
+	key, otp := r.byID(id)
+	delete(r.otps, key)
+	return otp != nil, nil
+}
+
+func (r *fakeOTPRepo) DeleteFor(ctx context.Context, purpose models.OTPPurpose, subject string) error {
+	delete(r.otps, otpKey(purpose, subject))
+	return nil
+}
+
+func (r *fakeOTPRepo) DeleteStale(ctx context.Context, sentBefore time.Time) (int64, error) {
+	var removed int64
+	for key, otp := range r.otps {
+		if otp.LastSentAt.Before(sentBefore) {
+			delete(r.otps, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+type fakeCouponRepo struct {
+	coupons     map[string]*models.Coupon
+	redemptions int64
+}
+
+func (r *fakeCouponRepo) Create(ctx context.Context, coupon *models.Coupon) error { return nil }
+
+func (r *fakeCouponRepo) GetByID(ctx context.Context, id uint) (*models.Coupon, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCouponRepo) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	c, ok := r.coupons[code]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return c, nil
+}
+
+func (r *fakeCouponRepo) GetAll(ctx context.Context, page, limit int) ([]models.Coupon, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeCouponRepo) Update(ctx context.Context, coupon *models.Coupon) error { return nil }
+
+func (r *fakeCouponRepo) Delete(ctx context.Context, id uint) error { return nil }
+
+func (r *fakeCouponRepo) CountCustomerRedemptions(ctx context.Context, couponID, customerID uint) (int64, error) {
+	return r.redemptions, nil
+}
+
+// fakeReturnRepo keeps returns in memory. Create leaves the quantity checks
+// to the database-backed repository.
+type fakeReturnRepo struct {
+	orders  *fakeOrderRepo
+	returns []*models.ReturnRequest
+}
+
+func (r *fakeReturnRepo) Create(ctx context.Context, rma *models.ReturnRequest) error {
+	rma.ID = uint(len(r.returns) + 1)
+	r.returns = append(r.returns, rma)
+	return nil
+}
+
+func (r *fakeReturnRepo) GetByID(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	if id == 0 || int(id) > len(r.returns) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	rma := *r.returns[id-1]
+	order := r.orders.orders[rma.OrderID]
+	rma.Items = append([]models.ReturnItem(nil), rma.Items...)
+	for i := range rma.Items {
+		for _, line := range order.OrderItems {
+			if line.ID == rma.Items[i].OrderItemID {
+				rma.Items[i].OrderItem = line
+			}
+		}
+	}
+	return &rma, nil
+}
+
+func (r *fakeReturnRepo) GetByOrderID(ctx context.Context, orderID uint) ([]models.ReturnRequest, error) {
+	return nil, nil
+}
+
+func (r *fakeReturnRepo) GetAll(ctx context.Context, status models.ReturnStatus, page, limit int) ([]models.ReturnRequest, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeReturnRepo) Review(ctx context.Context, id uint, status models.ReturnStatus, note string) (bool, error) {
+	rma := r.returns[id-1]
+	if rma.Status != models.ReturnStatusRequested {
+		return false, nil
+	}
+	rma.Status, rma.StaffNote = status, note
+	return true, nil
+}
+
+func (r *fakeReturnRepo) MarkReceived(ctx context.Context, id uint, refundAmount float64) (bool, error) {
+	rma := r.returns[id-1]
+	if rma.Status != models.ReturnStatusApproved {
+		return false, nil
+	}
+	rma.Status, rma.RefundAmount = models.ReturnStatusReceived, refundAmount
+	return true, nil
+}
+
+type fakeReviewRepo struct {
+	repositories.ReviewRepository
+	reviews map[uint]*models.Review
+	votes   map[[2]uint]bool
+}
+
+func (r *fakeReviewRepo) Create(ctx context.Context, review *models.Review) error {
+	for _, existing := range r.reviews {
+		if existing.ProductID == review.ProductID && existing.CustomerID == review.CustomerID {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	review.ID = uint(len(r.reviews) + 1)
+	c := *review
+	r.reviews[review.ID] = &c
+	return nil
+}
+
+func (r *fakeReviewRepo) GetByID(ctx context.Context, id uint) (*models.Review, error) {
+	review, ok := r.reviews[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c := *review
+	return &c, nil
+}
+
+func (r *fakeReviewRepo) Moderate(ctx context.Context, id uint, status models.ReviewStatus, note string) (bool, error) {
+	review, ok := r.reviews[id]
+	if !ok {
+		return false, gorm.ErrRecordNotFound
+	}
+	if review.Status == status {
+		return false, nil
+	}
+	review.Status, review.ModerationNote = status, note
+	return true, nil
+}
+
+func (r *fakeReviewRepo) AddVote(ctx context.Context, reviewID, customerID uint) (bool, error) {
+	key := [2]uint{reviewID, customerID}
+	if r.votes[key] {
+		return false, nil
+	}
+	r.votes[key] = true
+	r.reviews[reviewID].HelpfulCount++
+	return true, nil
+}
+
+type fakeTaxRateRepo struct {
+	repositories.TaxRateRepository
+	rates []models.TaxRate
+}
+
+func (r *fakeTaxRateRepo) GetEffective(ctx context.Context, categoryIDs []uint, at time.Time) ([]models.TaxRate, error) {
+	wanted := make(map[uint]bool)
+	for _, id := range categoryIDs {
+		wanted[id] = true
+	}
+
+	var out []models.TaxRate
+	for _, rate := range r.rates {
+		if rate.EffectiveFrom.After(at) {
+			continue
+		}
+		if rate.CategoryID == nil || wanted[*rate.CategoryID] {
+			out = append(out, rate)
+		}
+	}
+	// Newest first, as the repository returns them
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j].EffectiveFrom.After(out[j-1].EffectiveFrom); j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out, nil
+}
+
+// fakeCategoryTree knows each category's parent.
+type fakeCategoryTree struct {
+	repositories.CategoryRepository
+	parents map[uint]uint
+}
+
+func (r *fakeCategoryTree) GetAncestorIDs(categoryID uint) ([]uint, error) {
+	ids := []uint{categoryID}
+	for parent, ok := r.parents[categoryID]; ok; parent, ok = r.parents[parent] {
+		ids = append(ids, parent)
+	}
+	return ids, nil
+}
+
+// fakeWishlistRepo keeps stock alerts in memory; due alerts are the unsent
+// ones whose product has stock.
+type fakeWishlistRepo struct {
+	repositories.WishlistRepository
+	products *fakeProductRepo
+	alerts   []*models.StockAlert
+}
+
+func (r *fakeWishlistRepo) SubscribeAlert(ctx context.Context, alert *models.StockAlert) error {
+	for _, a := range r.alerts {
+		if a.CustomerID == alert.CustomerID && a.ProductID == alert.ProductID {
+			a.Channel, a.NotifiedAt = alert.Channel, nil
+			alert.ID = a.ID
+			return nil
+		}
+	}
+	alert.ID = uint(len(r.alerts) + 1)
+	c := *alert
+	r.alerts = append(r.alerts, &c)
+	return nil
+}
+
+func (r *fakeWishlistRepo) GetDueAlerts(ctx context.Context, productID uint) ([]models.StockAlert, error) {
+	var due []models.StockAlert
+	for _, a := range r.alerts {
+		if a.NotifiedAt != nil || (productID != 0 && a.ProductID != productID) {
+			continue
+		}
+		if p, ok := r.products.products[a.ProductID]; ok && !p.OutOfStock() {
+			due = append(due, *a)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeWishlistRepo) ClaimAlert(ctx context.Context, id uint, at time.Time) (bool, error) {
+	a := r.alerts[id-1]
+	if a.NotifiedAt != nil {
+		return false, nil
+	}
+	a.NotifiedAt = &at
+	return true, nil
+}
+
+func (r *fakeWishlistRepo) ReleaseAlert(ctx context.Context, id uint) error {
+	r.alerts[id-1].NotifiedAt = nil
+	return nil
+}
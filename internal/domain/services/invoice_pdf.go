@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// invoiceColumns are the line item table columns; the widths add up to the
+// 190mm between the A4 page margins.
+var invoiceColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Description", 56, "L"},
+	{"Qty", 10, "R"},
+	{"Unit price", 22, "R"},
+	{"Discount", 20, "R"},
+	{"VAT", 18, "C"},
+	{"Net", 22, "R"},
+	{"VAT amount", 20, "R"},
+	{"Gross", 22, "R"},
+}
+
+// renderInvoicePDF lays out a tax invoice for the order: seller and buyer
+// details, the line items with their VAT, a VAT summary per rate and the
+// order totals.
+func renderInvoicePDF(invoice *models.Invoice, order *models.Order, currency string) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 12, 10)
+	pdf.SetTitle("Tax Invoice "+invoice.Number, true)
+	pdf.SetAuthor(invoice.SellerName, true)
+	pdf.SetCreationDate(invoice.IssuedAt)
+	pdf.SetModificationDate(invoice.IssuedAt)
+	pdf.AddPage()
+
+	// Core fonts are cp1252; translate so accented names print correctly
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+
+	// Seller
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(120, 8, tr(invoice.SellerName), "", 0, "L", false, 0, "")
+	pdf.CellFormat(70, 8, "TAX INVOICE", "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	if invoice.SellerAddress != "" {
+		pdf.CellFormat(120, 5, tr(invoice.SellerAddress), "", 1, "L", false, 0, "")
+	}
+	pdf.CellFormat(120, 5, "KRA PIN: "+invoice.SellerKRAPIN, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// Invoice details
+	details := [][2]string{
+		{"Invoice number", invoice.Number},
+		{"Invoice date", invoice.IssuedAt.Format("02 Jan 2006")},
+		{"Order", order.Reference},
+		{"Order date", order.CreatedAt.Format("02 Jan 2006")},
+	}
+	for _, d := range details {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(32, 5, d[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(60, 5, d[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Buyer and delivery address side by side
+	customer := order.Customer
+	billTo := []string{
+		strings.TrimSpace(customer.FirstName + " " + customer.LastName),
+		customer.Email,
+		customer.Phone,
+	}
+	ship := order.ShippingAddress
+	var shipTo []string
+	for _, line := range []string{
+		ship.RecipientName, ship.Line1, ship.Line2,
+		strings.TrimSpace(ship.Town + " " + ship.PostalCode), ship.County, ship.Phone,
+	} {
+		if line != "" {
+			shipTo = append(shipTo, line)
+		}
+	}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(95, 5, "Bill to", "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 5, "Deliver to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for i := 0; i < len(billTo) || i < len(shipTo); i++ {
+		var left, right string
+		if i < len(billTo) {
+			left = billTo[i]
+		}
+		if i < len(shipTo) {
+			right = shipTo[i]
+		}
+		pdf.CellFormat(95, 5, tr(left), "", 0, "L", false, 0, "")
+		pdf.CellFormat(95, 5, tr(right), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	// Line items
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFillColor(235, 235, 235)
+	for _, col := range invoiceColumns {
+		pdf.CellFormat(col.width, 7, col.title, "1", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for _, item := range order.OrderItems {
+		name := item.Product.Name
+		if name == "" {
+			name = fmt.Sprintf("Product #%d", item.ProductID)
+		}
+		row := []string{
+			tr(name),
+			fmt.Sprintf("%d", item.Quantity),
+			money(item.Price),
+			money(item.Discount),
+			vatLabel(item.TaxTreatment, item.TaxRate),
+			money(item.NetAmount),
+			money(item.TaxAmount),
+			money(item.GrossAmount),
+		}
+		// Keep long product names on one line
+		row[0] = fitText(pdf, row[0], invoiceColumns[0].width-2)
+		for i, col := range invoiceColumns {
+			pdf.CellFormat(col.width, 6, row[i], "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	// VAT summary per rate, then the order totals
+	type vatBand struct {
+		label    string
+		net, tax float64
+	}
+	var bands []*vatBand
+	byLabel := make(map[string]*vatBand)
+	for _, item := range order.OrderItems {
+		label := vatLabel(item.TaxTreatment, item.TaxRate)
+		band, ok := byLabel[label]
+		if !ok {
+			band = &vatBand{label: label}
+			byLabel[label] = band
+			bands = append(bands, band)
+		}
+		band.net += item.NetAmount
+		band.tax += item.TaxAmount
+	}
+
+	top := pdf.GetY()
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.CellFormat(25, 6, "VAT rate", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(30, 6, "Taxable amount", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(30, 6, "VAT", "1", 1, "R", true, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	for _, band := range bands {
+		pdf.CellFormat(25, 6, band.label, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 6, money(roundMoney(band.net)), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 6, money(roundMoney(band.tax)), "1", 1, "R", false, 0, "")
+	}
+
+	totals := [][2]string{
+		{"Subtotal", money(order.Subtotal)},
+		{"Discount", "-" + money(order.DiscountTotal)},
+		{"Net total", money(order.NetTotal)},
+		{"VAT", money(order.TaxTotal)},
+		{"Delivery (incl. VAT)", money(order.DeliveryFee)},
+	}
+	pdf.SetY(top)
+	for _, t := range totals {
+		pdf.SetX(120)
+		pdf.CellFormat(45, 6, t[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(35, 6, t[1], "", 1, "R", false, 0, "")
+	}
+	pdf.SetX(120)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(45, 8, "Total due", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(35, 8, strings.TrimSpace(currency+" "+money(order.Total)), "T", 1, "R", false, 0, "")
+
+	pdf.SetY(-25)
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.CellFormat(0, 5, tr("Thank you for shopping with "+invoice.SellerName+"."), "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render invoice %s: %w", invoice.Number, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func vatLabel(treatment models.TaxTreatment, rate float64) string {
+	switch treatment {
+	case models.TaxTreatmentZeroRated:
+		return "Zero-rated"
+	case models.TaxTreatmentExempt:
+		return "Exempt"
+	default:
+		return fmt.Sprintf("%g%%", rate)
+	}
+}
+
+// fitText shortens text with an ellipsis until it fits in width.
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
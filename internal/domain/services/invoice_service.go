@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type InvoiceService interface {
+	GetOrderInvoice(ctx context.Context, customerID, orderID uint) (*models.Invoice, []byte, error)
+	InvoicePDF(ctx context.Context, order *models.Order) (*models.Invoice, []byte, error)
+}
+
+type invoiceService struct {
+	invoiceRepo repositories.InvoiceRepository
+	orderRepo   repositories.OrderRepository
+	config      *config.Config
+	now         func() time.Time
+}
+
+func NewInvoiceService(invoiceRepo repositories.InvoiceRepository, orderRepo repositories.OrderRepository, config *config.Config) InvoiceService {
+	return &invoiceService{
+		invoiceRepo: invoiceRepo,
+		orderRepo:   orderRepo,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// GetOrderInvoice returns the invoice PDF for one of the customer's orders.
+func (s *invoiceService) GetOrderInvoice(ctx context.Context, customerID, orderID uint) (*models.Invoice, []byte, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, nil, models.ErrOrderNotFound
+	}
+
+	return s.InvoicePDF(ctx, order)
+}
+
+// InvoicePDF renders the order's invoice, issuing it first if the order has
+// none yet. The order must be loaded with its customer and items.
+func (s *invoiceService) InvoicePDF(ctx context.Context, order *models.Order) (*models.Invoice, []byte, error) {
+	invoice, err := s.issue(ctx, order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdf, err := renderInvoicePDF(invoice, order, s.config.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	return invoice, pdf, nil
+}
+
+// issue returns the order's invoice, creating it when there is none. When two
+// requests race to invoice the same order, the loser's insert is rejected by
+// the unique order index and it picks up the winner's invoice.
+func (s *invoiceService) issue(ctx context.Context, order *models.Order) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByOrderID(ctx, order.ID)
+	if err == nil {
+		return invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if order.Status == models.OrderStatusCancelled {
+		return nil, models.ErrOrderNotInvoiceable
+	}
+
+	issuedAt := s.now()
+	invoice = &models.Invoice{
+		OrderID:       order.ID,
+		Year:          issuedAt.Year(),
+		IssuedAt:      issuedAt,
+		SellerName:    s.config.MerchantName,
+		SellerKRAPIN:  s.config.MerchantKRAPIN,
+		SellerAddress: s.config.MerchantAddress,
+	}
+	err = s.invoiceRepo.Issue(ctx, invoice)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return s.invoiceRepo.GetByOrderID(ctx, order.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
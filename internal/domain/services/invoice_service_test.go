@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestInvoiceService_NumbersAreSequentialUnderConcurrency(t *testing.T) {
+	repo := newFakeInvoiceRepo()
+	issued := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	s := &invoiceService{
+		invoiceRepo: repo,
+		config:      &config.Config{MerchantName: "Savannah", MerchantKRAPIN: "P051234567X", Currency: "KES"},
+		now:         func() time.Time { return issued },
+	}
+
+	const orders = 20
+	var wg sync.WaitGroup
+	numbers := make(chan string, orders*3)
+	for i := 1; i <= orders; i++ {
+		// Each order is invoiced by three racing requests
+		for j := 0; j < 3; j++ {
+			wg.Add(1)
+			go func(orderID uint) {
+				defer wg.Done()
+				invoice, err := s.issue(context.Background(), &models.Order{Model: gorm.Model{ID: orderID}})
+				if assert.NoError(t, err) {
+					numbers <- invoice.Number
+				}
+			}(uint(i))
+		}
+	}
+	wg.Wait()
+	close(numbers)
+
+	distinct := map[string]bool{}
+	for n := range numbers {
+		distinct[n] = true
+	}
+	var got []string
+	for n := range distinct {
+		got = append(got, n)
+	}
+	sort.Strings(got)
+
+	require.Len(t, got, orders, "one invoice per order")
+	assert.Equal(t, "INV-2026-000001", got[0])
+	assert.Equal(t, "INV-2026-000020", got[orders-1], "no numbers skipped")
+}
+
+func TestInvoiceService_InvoicePDF(t *testing.T) {
+	repo := newFakeInvoiceRepo()
+	s := &invoiceService{
+		invoiceRepo: repo,
+		config:      &config.Config{MerchantName: "Savannah", MerchantKRAPIN: "P051234567X", Currency: "KES"},
+		now:         time.Now,
+	}
+	order := &models.Order{
+		Model:      gorm.Model{ID: 5},
+		Customer:   models.Customer{FirstName: "Wanjirũ", LastName: "Kamau", Email: "w@example.com"},
+		Subtotal:   200,
+		NetTotal:   200,
+		TaxTotal:   16,
+		Total:      216,
+		OrderItems: []models.OrderItem{{Product: models.Product{Name: "Tea"}, Quantity: 2, Price: 100, TaxRate: 16, NetAmount: 200, TaxAmount: 16, GrossAmount: 216}},
+	}
+
+	invoice, pdf, err := s.InvoicePDF(context.Background(), order)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	assert.Equal(t, "P051234567X", invoice.SellerKRAPIN)
+
+	again, _, err := s.InvoicePDF(context.Background(), order)
+	require.NoError(t, err)
+	assert.Equal(t, invoice.Number, again.Number, "reprints keep the number")
+
+	_, _, err = s.InvoicePDF(context.Background(), &models.Order{Model: gorm.Model{ID: 6}, Status: models.OrderStatusCancelled})
+	assert.ErrorIs(t, err, models.ErrOrderNotInvoiceable)
+}
@@ -2,29 +2,56 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/Mutonya/Savanah/internal/utils/templates"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/smtp"
+	"net/textproto"
+	"time"
 
 	"github.com/Mutonya/Savanah/internal/config"
 	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
 )
 
 type NotificationService interface {
 	SendOrderConfirmation(order *models.Order) error
 	SendStatusUpdate(order *models.Order) error
+	SendOrderCancelled(order *models.Order, refund *models.Refund) error
+	SendReturnUpdate(order *models.Order, rma *models.ReturnRequest) error
+	SendBackInStock(alert *models.StockAlert) error
+	SendOTP(phone, code string, ttl time.Duration) error
+	SendNewCountrySignIn(customer *models.Customer, event *models.AuthEvent) error
+	SendSecurityAlert(event *models.AuthEvent, summary string) error
 }
 
 type notificationService struct {
-	config *config.Config
+	config        *config.Config
+	invoices      InvoiceService
+	notifications repositories.NotificationRepository
 }
 
-func NewNotificationService(config *config.Config) NotificationService {
-	return &notificationService{config: config}
+// NewNotificationService sends SMS and email. When invoices is set, order
+// confirmations to customers carry the invoice PDF; when notifications is
+// set, every message to a customer is logged there.
+func NewNotificationService(
+	config *config.Config,
+	invoices InvoiceService,
+	notifications repositories.NotificationRepository,
+) NotificationService {
+	return &notificationService{config: config, invoices: invoices, notifications: notifications}
+}
+
+type emailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
 func (s *notificationService) SendOrderConfirmation(order *models.Order) error {
@@ -38,21 +65,38 @@ func (s *notificationService) SendOrderConfirmation(order *models.Order) error {
 		Config: s.config,
 	}
 
+	// Attach the invoice; the confirmation still goes out without it
+	var attachments []emailAttachment
+	if s.invoices != nil {
+		invoice, pdf, err := s.invoices.InvoicePDF(context.Background(), order)
+		if err != nil {
+			log.Printf("Failed to generate invoice for order %d: %v", order.ID, err)
+		} else {
+			attachments = append(attachments, emailAttachment{
+				Filename:    invoice.Number + ".pdf",
+				ContentType: "application/pdf",
+				Data:        pdf,
+			})
+		}
+	}
+
 	// Send email to customer
-	customerSubject := fmt.Sprintf("Order #%d Confirmation", order.ID)
+	customerSubject := fmt.Sprintf("Order %s Confirmation", order.Reference)
 
-	if err := s.sendHTMLEmail(
-		order.Customer.Email,
+	if err := s.emailCustomer(
+		&order.Customer,
+		&order.ID,
 		customerSubject,
 		"order_confirmation",
 		emailData,
+		attachments...,
 	); err != nil {
 		log.Printf("Failed to send customer confirmation email: %v", err)
 		return fmt.Errorf("failed to send customer email: %w", err)
 	}
 
 	// Send email to admin
-	adminSubject := fmt.Sprintf("New Order #%d Received", order.ID)
+	adminSubject := fmt.Sprintf("New Order %s Received", order.Reference)
 	if err := s.sendHTMLEmail(
 		s.config.AdminEmail,
 		adminSubject,
@@ -63,9 +107,9 @@ func (s *notificationService) SendOrderConfirmation(order *models.Order) error {
 		return fmt.Errorf("failed to send admin email: %w", err)
 	}
 	// Send SMS to customer
-	smsMsg := fmt.Sprintf("Hello %s, your order #%d has been received. Total: %.2f %s",
-		order.Customer.FirstName, order.ID, order.Total, s.config.Currency)
-	if err := s.sendSMS(order.Customer.Phone, smsMsg); err != nil {
+	smsMsg := fmt.Sprintf("Hello %s, your order %s has been received. Total: %.2f %s",
+		order.Customer.FirstName, order.Reference, order.Total, s.config.Currency)
+	if err := s.smsCustomer(&order.Customer, &order.ID, "order_confirmation", smsMsg); err != nil {
 		log.Printf("Failed to send order confirmation SMS: %v", err)
 		return fmt.Errorf("failed to send SMS: %w", err)
 	}
@@ -75,16 +119,17 @@ func (s *notificationService) SendOrderConfirmation(order *models.Order) error {
 
 func (s *notificationService) SendStatusUpdate(order *models.Order) error {
 	// Send SMS to customer about status change
-	smsMsg := fmt.Sprintf("Hello %s, your order #%d status is now: %s",
-		order.Customer.FirstName, order.ID, order.Status)
-	if err := s.sendSMS(order.Customer.Phone, smsMsg); err != nil {
+	smsMsg := fmt.Sprintf("Hello %s, your order %s status is now: %s",
+		order.Customer.FirstName, order.Reference, order.Status)
+	if err := s.smsCustomer(&order.Customer, &order.ID, "status_update", smsMsg); err != nil {
 		return fmt.Errorf("failed to send status update SMS: %w", err)
 	}
 
 	// Send status update email
-	if err := s.sendHTMLEmail(
-		order.Customer.Email,
-		fmt.Sprintf("Order #%d Status Update", order.ID),
+	if err := s.emailCustomer(
+		&order.Customer,
+		&order.ID,
+		fmt.Sprintf("Order %s Status Update", order.Reference),
 		"status_update",
 		struct {
 			Order *models.Order
@@ -96,6 +141,207 @@ func (s *notificationService) SendStatusUpdate(order *models.Order) error {
 	return nil
 }
 
+// SendOrderCancelled tells the customer their order was cancelled and, when
+// it had been paid, about the refund.
+func (s *notificationService) SendOrderCancelled(order *models.Order, refund *models.Refund) error {
+	smsMsg := fmt.Sprintf("Hello %s, your order %s has been cancelled.", order.Customer.FirstName, order.Reference)
+	if refund != nil {
+		smsMsg += fmt.Sprintf(" A refund of %.2f %s is on its way.", refund.Amount, s.config.Currency)
+	}
+	if err := s.smsCustomer(&order.Customer, &order.ID, "order_cancelled", smsMsg); err != nil {
+		return fmt.Errorf("failed to send cancellation SMS: %w", err)
+	}
+
+	if err := s.emailCustomer(
+		&order.Customer,
+		&order.ID,
+		fmt.Sprintf("Order %s Cancelled", order.Reference),
+		"order_cancelled",
+		struct {
+			Order  *models.Order
+			Refund *models.Refund
+			Config *config.Config
+		}{order, refund, s.config},
+	); err != nil {
+		return fmt.Errorf("failed to send cancellation email: %w", err)
+	}
+
+	return nil
+}
+
+// SendReturnUpdate tells the customer where their return request stands.
+func (s *notificationService) SendReturnUpdate(order *models.Order, rma *models.ReturnRequest) error {
+	smsMsg := fmt.Sprintf("Hello %s, your return #%d for order %s is now: %s",
+		order.Customer.FirstName, rma.ID, order.Reference, rma.Status)
+	if rma.Status == models.ReturnStatusReceived && rma.RefundAmount > 0 {
+		smsMsg += fmt.Sprintf(". A refund of %.2f %s is on its way.", rma.RefundAmount, s.config.Currency)
+	}
+	if err := s.smsCustomer(&order.Customer, &order.ID, "return_update", smsMsg); err != nil {
+		return fmt.Errorf("failed to send return update SMS: %w", err)
+	}
+
+	if err := s.emailCustomer(
+		&order.Customer,
+		&order.ID,
+		fmt.Sprintf("Return #%d for Order %s", rma.ID, order.Reference),
+		"return_update",
+		struct {
+			Order  *models.Order
+			Return *models.ReturnRequest
+			Config *config.Config
+		}{order, rma, s.config},
+	); err != nil {
+		return fmt.Errorf("failed to send return update email: %w", err)
+	}
+
+	return nil
+}
+
+// SendBackInStock tells a subscriber that a product is available again,
+// through the channel they chose. The alert must carry its customer and
+// product.
+func (s *notificationService) SendBackInStock(alert *models.StockAlert) error {
+	customer, product := alert.Customer, alert.Product
+
+	if alert.Channel == models.AlertChannelSMS {
+		smsMsg := fmt.Sprintf("Hello %s, %s is back in stock at %.2f %s. Order now while it lasts!",
+			customer.FirstName, product.Name, product.Price, s.config.Currency)
+		if err := s.smsCustomer(&customer, nil, "back_in_stock", smsMsg); err != nil {
+			return fmt.Errorf("failed to send back-in-stock SMS: %w", err)
+		}
+		return nil
+	}
+
+	if err := s.emailCustomer(
+		&customer,
+		nil,
+		fmt.Sprintf("%s is back in stock", product.Name),
+		"back_in_stock",
+		struct {
+			Customer models.Customer
+			Product  models.Product
+			Config   *config.Config
+		}{customer, product, s.config},
+	); err != nil {
+		return fmt.Errorf("failed to send back-in-stock email: %w", err)
+	}
+	return nil
+}
+
+// SendOTP texts a one-time code. Codes often go to numbers that are not
+// verified yet, so it bypasses the verified-phone check.
+func (s *notificationService) SendOTP(phone, code string, ttl time.Duration) error {
+	smsMsg := fmt.Sprintf("Your %s code is %s. It expires in %d minutes. Do not share it with anyone.",
+		s.config.MerchantName, code, int(ttl.Minutes()))
+	if err := s.sendSMS(phone, smsMsg); err != nil {
+		return fmt.Errorf("failed to send code SMS: %w", err)
+	}
+	return nil
+}
+
+// SendNewCountrySignIn warns a customer of a sign-in from a country they
+// have not signed in from before.
+func (s *notificationService) SendNewCountrySignIn(customer *models.Customer, event *models.AuthEvent) error {
+	if err := s.emailCustomer(
+		customer,
+		nil,
+		"New sign-in to your account",
+		"new_country_sign_in",
+		struct {
+			Customer *models.Customer
+			Event    *models.AuthEvent
+			Config   *config.Config
+		}{customer, event, s.config},
+	); err != nil {
+		return fmt.Errorf("failed to send new sign-in email: %w", err)
+	}
+	return nil
+}
+
+// SendSecurityAlert emails the admin about an event that tripped an anomaly
+// rule.
+func (s *notificationService) SendSecurityAlert(event *models.AuthEvent, summary string) error {
+	if err := s.sendHTMLEmail(
+		s.config.AdminEmail,
+		"Security alert: "+summary,
+		"security_alert",
+		struct {
+			Event   *models.AuthEvent
+			Summary string
+		}{event, summary},
+	); err != nil {
+		return fmt.Errorf("failed to send security alert email: %w", err)
+	}
+	return nil
+}
+
+// smsCustomer texts the customer and logs the outcome. Customers without a
+// verified phone number are skipped, and the log records why.
+func (s *notificationService) smsCustomer(customer *models.Customer, orderID *uint, kind, message string) error {
+	entry := &models.Notification{
+		CustomerID: customer.ID,
+		OrderID:    orderID,
+		Channel:    models.NotificationChannelSMS,
+		Kind:       kind,
+		Recipient:  customer.Phone,
+	}
+	switch {
+	case customer.Phone == "":
+		entry.Status, entry.Reason = models.NotificationStatusSkipped, "no phone number"
+	case !customer.HasVerifiedPhone():
+		entry.Status, entry.Reason = models.NotificationStatusSkipped, "phone number not verified"
+	}
+	if entry.Status == models.NotificationStatusSkipped {
+		log.Printf("Skipping %s SMS to customer %d: %s", kind, customer.ID, entry.Reason)
+		s.record(entry)
+		return nil
+	}
+
+	err := s.sendSMS(customer.Phone, message)
+	s.record(withResult(entry, err))
+	return err
+}
+
+// emailCustomer emails the customer and logs the outcome.
+func (s *notificationService) emailCustomer(
+	customer *models.Customer,
+	orderID *uint,
+	subject, templateName string,
+	data interface{},
+	attachments ...emailAttachment,
+) error {
+	err := s.sendHTMLEmail(customer.Email, subject, templateName, data, attachments...)
+	s.record(withResult(&models.Notification{
+		CustomerID: customer.ID,
+		OrderID:    orderID,
+		Channel:    models.NotificationChannelEmail,
+		Kind:       templateName,
+		Recipient:  customer.Email,
+	}, err))
+	return err
+}
+
+// withResult marks the entry sent, or failed with the error as the reason.
+func withResult(entry *models.Notification, err error) *models.Notification {
+	entry.Status = models.NotificationStatusSent
+	if err != nil {
+		entry.Status, entry.Reason = models.NotificationStatusFailed, err.Error()
+		if len(entry.Reason) > 255 {
+			entry.Reason = entry.Reason[:255]
+		}
+	}
+	return entry
+}
+
+func (s *notificationService) record(entry *models.Notification) {
+	if s.notifications == nil || entry.CustomerID == 0 {
+		return
+	}
+	if err := s.notifications.Create(context.Background(), entry); err != nil {
+		log.Printf("Failed to log %s %s notification for customer %d: %v", entry.Kind, entry.Channel, entry.CustomerID, err)
+	}
+}
+
 func (s *notificationService) sendSMS(to, message string) error {
 	if s.config.AfricaTalkingAPIKey == "" || s.config.AfricaTalkingUsername == "" {
 		return fmt.Errorf("Africa's Talking credentials not configured")
@@ -103,8 +349,7 @@ func (s *notificationService) sendSMS(to, message string) error {
 
 	url := "https://api.africastalking.com/version1/messaging/bulk"
 
-	// Hardcoded phone number as a slice
-	phoneNumbers := []string{"+254711866694"}
+	phoneNumbers := []string{to}
 
 	payload := map[string]interface{}{
 		"username":     s.config.AfricaTalkingUsername,
@@ -156,7 +401,7 @@ func (s *notificationService) sendSMS(to, message string) error {
 	return nil
 }
 
-func (s *notificationService) sendHTMLEmail(to, subject, templateName string, data interface{}) error {
+func (s *notificationService) sendHTMLEmail(to, subject, templateName string, data interface{}, attachments ...emailAttachment) error {
 	log.Printf("Sending email to: %s using host %s:%d", to, s.config.SMTPHost, s.config.SMTPPort)
 
 	// Get email template
@@ -177,14 +422,26 @@ func (s *notificationService) sendHTMLEmail(to, subject, templateName string, da
 	headers["To"] = to
 	headers["Subject"] = subject
 	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=\"UTF-8\""
+
+	var content []byte
+	if len(attachments) == 0 {
+		headers["Content-Type"] = "text/html; charset=\"UTF-8\""
+		content = []byte(body)
+	} else {
+		var contentType string
+		content, contentType, err = mixedEmailBody(body, attachments)
+		if err != nil {
+			return fmt.Errorf("error building email body: %w", err)
+		}
+		headers["Content-Type"] = contentType
+	}
 
 	var msg bytes.Buffer
 	for k, v := range headers {
 		msg.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
 	}
 	msg.WriteString("\r\n")
-	msg.WriteString(body)
+	msg.Write(content)
 
 	// Connect without authentication
 	client, err := smtp.Dial(fmt.Sprintf("%s:%d", s.config.SMTPHost, s.config.SMTPPort))
@@ -220,3 +477,47 @@ func (s *notificationService) sendHTMLEmail(to, subject, templateName string, da
 	log.Printf("Email successfully sent to %s", to)
 	return nil
 }
+
+// mixedEmailBody builds a multipart/mixed body holding the HTML part and the
+// attachments, base64 encoded in 76-character lines. It returns the body and
+// its Content-Type header.
+func mixedEmailBody(html string, attachments []emailAttachment) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/html; charset=\"UTF-8\""},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.WriteString(part, html); err != nil {
+		return nil, "", err
+	}
+
+	for _, a := range attachments {
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", a.ContentType, a.Filename)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Filename)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			if _, err := io.WriteString(part, encoded[:76]+"\r\n"); err != nil {
+				return nil, "", err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := io.WriteString(part, encoded+"\r\n"); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "multipart/mixed; boundary=" + w.Boundary(), nil
+}
@@ -2,6 +2,14 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
 	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/repositories"
 )
@@ -9,15 +17,56 @@ import (
 type OrderService interface {
 	CreateOrder(ctx context.Context, customerID uint, req *OrderCreateRequest) (*models.Order, error)
 	GetOrder(ctx context.Context, customerID, orderID uint) (*models.Order, error)
+	GetOrderByReference(ctx context.Context, customerID uint, reference string) (*models.Order, error)
 	GetOrders(ctx context.Context, customerID uint, page, limit int) ([]models.Order, int64, error)
 	UpdateOrderStatus(ctx context.Context, orderID uint, status models.OrderStatus) (*models.Order, error)
+	CancelOrder(ctx context.Context, customerID, orderID uint, req *OrderCancelRequest) (*models.Order, error)
+	SearchOrders(ctx context.Context, req *OrderSearchRequest) ([]models.Order, int64, error)
+	ExportOrders(ctx context.Context, req *OrderSearchRequest) ([]models.Order, error)
+	BulkUpdateOrderStatus(ctx context.Context, req *BulkOrderStatusRequest) []BulkOrderStatusResult
 }
 
+// OrderCreateRequest.ShippingAddressID picks an address from the customer's
+// address book; the default address is used when it is omitted.
 type OrderCreateRequest struct {
-	Items []OrderItemRequest `json:"items" binding:"required,min=1"`
+	Items             []OrderItemRequest `json:"items" binding:"required,min=1"`
+	CouponCode        string             `json:"coupon_code"`
+	ShippingAddressID *uint              `json:"shipping_address_id"`
 }
 type OrderStatusUpdateRequest struct {
-	Status models.OrderStatus `json:"status" binding:"required,oneof=pending completed cancelled"`
+	Status models.OrderStatus `json:"status" binding:"required,oneof=pending paid shipped completed cancelled returned"`
+}
+
+type OrderCancelRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// OrderSearchRequest filters the staff order listing. From and To are
+// inclusive dates; Sort is created_at, total or status, with a leading "-"
+// for descending.
+type OrderSearchRequest struct {
+	Status     models.OrderStatus `form:"status" binding:"omitempty,oneof=pending paid shipped completed cancelled returned"`
+	From       time.Time          `form:"from" time_format:"2006-01-02"`
+	To         time.Time          `form:"to" time_format:"2006-01-02"`
+	CustomerID uint               `form:"customer_id"`
+	MinTotal   float64            `form:"min_total" binding:"gte=0"`
+	ProductID  uint               `form:"product_id"`
+	Sort       string             `form:"sort" binding:"omitempty,oneof=created_at -created_at total -total status -status"`
+	Page       int                `form:"page,default=1" binding:"min=1"`
+	Limit      int                `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+type BulkOrderStatusRequest struct {
+	OrderIDs []uint             `json:"order_ids" binding:"required,min=1,max=100,dive,required"`
+	Status   models.OrderStatus `json:"status" binding:"required,oneof=pending paid shipped completed cancelled returned"`
+}
+
+// BulkOrderStatusResult reports the outcome for one order of a bulk update;
+// Error is empty when the order moved.
+type BulkOrderStatusResult struct {
+	OrderID uint               `json:"order_id"`
+	Status  models.OrderStatus `json:"status,omitempty"`
+	Error   string             `json:"error,omitempty"`
 }
 
 type OrderItemRequest struct {
@@ -30,6 +79,11 @@ type orderService struct {
 	productRepo  repositories.ProductRepository
 	customerRepo repositories.CustomerRepository
 	notifier     NotificationService
+	promotions   PromotionService
+	taxes        TaxService
+	addressRepo  repositories.AddressRepository
+	delivery     DeliveryService
+	payments     PaymentService
 }
 
 func NewOrderService(
@@ -37,12 +91,22 @@ func NewOrderService(
 	productRepo repositories.ProductRepository,
 	customerRepo repositories.CustomerRepository,
 	notifier NotificationService,
+	promotions PromotionService,
+	taxes TaxService,
+	addressRepo repositories.AddressRepository,
+	delivery DeliveryService,
+	payments PaymentService,
 ) OrderService {
 	return &orderService{
 		orderRepo:    orderRepo,
 		productRepo:  productRepo,
 		customerRepo: customerRepo,
 		notifier:     notifier,
+		promotions:   promotions,
+		taxes:        taxes,
+		addressRepo:  addressRepo,
+		delivery:     delivery,
+		payments:     payments,
 	}
 }
 func (s *orderService) CreateOrder(ctx context.Context, customerID uint, req *OrderCreateRequest) (*models.Order, error) {
@@ -58,7 +122,12 @@ func (s *orderService) CreateOrder(ctx context.Context, customerID uint, req *Or
 		Status:     models.OrderStatusPending,
 	}
 
-	var total float64
+	address, err := s.shippingAddress(ctx, customerID, req.ShippingAddressID)
+	if err != nil {
+		return nil, err
+	}
+
+	var subtotal, weight float64
 	var orderItems []models.OrderItem
 
 	// Process each item
@@ -69,18 +138,50 @@ func (s *orderService) CreateOrder(ctx context.Context, customerID uint, req *Or
 		}
 
 		itemTotal := product.Price * float64(item.Quantity)
-		total += itemTotal
+		subtotal += itemTotal
+		weight += product.WeightKg * float64(item.Quantity)
 
 		orderItems = append(orderItems, models.OrderItem{
 			ProductID: product.ID,
+			Product:   *product,
 			Quantity:  item.Quantity,
 			Price:     product.Price,
 		})
 	}
 
-	order.Total = total
+	order.Subtotal = roundMoney(subtotal)
+	order.Total = order.Subtotal
 	order.OrderItems = orderItems
 
+	// Apply the coupon, if any; it is redeemed when the order is saved
+	if req.CouponCode != "" {
+		if err := s.promotions.ApplyCoupon(ctx, customerID, req.CouponCode, order); err != nil {
+			return nil, err
+		}
+	}
+
+	// VAT is charged on the discounted lines
+	if err := s.taxes.ApplyTax(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// Delivery is priced on the whole basket and added after VAT
+	quote, err := s.delivery.Quote(ctx, address.County, address.Town, weight)
+	if err != nil {
+		return nil, err
+	}
+	order.ShippingAddressID = &address.ID
+	order.ShippingAddress = address.Snapshot()
+	order.DeliveryZone = quote.Zone
+	order.ShippingWeightKg = weight
+	order.DeliveryFee = quote.Fee
+	order.Total = roundMoney(order.Total + quote.Fee)
+
+	// Products were only loaded for pricing; don't let Create upsert them
+	for i := range order.OrderItems {
+		order.OrderItems[i].Product = models.Product{}
+	}
+
 	// Save order - after this, order won't have Customer loaded
 	if err := s.orderRepo.Create(ctx, order); err != nil {
 		return nil, err
@@ -114,16 +215,116 @@ func (s *orderService) GetOrder(ctx context.Context, customerID, orderID uint) (
 	return order, nil
 }
 
+// GetOrderByReference finds one of the customer's orders by its reference,
+// ignoring case.
+func (s *orderService) GetOrderByReference(ctx context.Context, customerID uint, reference string) (*models.Order, error) {
+	order, err := s.orderRepo.GetByReference(ctx, models.NormalizeOrderReference(reference))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if order.CustomerID != customerID {
+		return nil, models.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
 func (s *orderService) GetOrders(ctx context.Context, customerID uint, page, limit int) ([]models.Order, int64, error) {
 	return s.orderRepo.GetByCustomerID(ctx, customerID, page, limit)
 }
 
+// SearchOrders lists orders across all customers for staff.
+func (s *orderService) SearchOrders(ctx context.Context, req *OrderSearchRequest) ([]models.Order, int64, error) {
+	return s.orderRepo.Search(ctx, orderFilter(req), req.Page, req.Limit)
+}
+
+// ExportOrders returns every order matching the search, ignoring pagination.
+func (s *orderService) ExportOrders(ctx context.Context, req *OrderSearchRequest) ([]models.Order, error) {
+	orders, _, err := s.orderRepo.Search(ctx, orderFilter(req), 1, 0)
+	return orders, err
+}
+
+func orderFilter(req *OrderSearchRequest) repositories.OrderFilter {
+	filter := repositories.OrderFilter{
+		Status:     req.Status,
+		From:       req.From,
+		CustomerID: req.CustomerID,
+		MinTotal:   req.MinTotal,
+		ProductID:  req.ProductID,
+		Sort:       strings.TrimPrefix(req.Sort, "-"),
+		Descending: strings.HasPrefix(req.Sort, "-"),
+	}
+	if !req.To.IsZero() {
+		// The whole of the To day is included
+		filter.To = req.To.AddDate(0, 0, 1)
+	}
+	return filter
+}
+
+// BulkUpdateOrderStatus moves each order through UpdateOrderStatus, so every
+// order obeys the transition rules and its customer is notified. Orders are
+// handled one by one; a failure is reported for that order and does not stop
+// the rest.
+func (s *orderService) BulkUpdateOrderStatus(ctx context.Context, req *BulkOrderStatusRequest) []BulkOrderStatusResult {
+	results := make([]BulkOrderStatusResult, 0, len(req.OrderIDs))
+	seen := make(map[uint]bool, len(req.OrderIDs))
+	for _, id := range req.OrderIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := BulkOrderStatusResult{OrderID: id}
+		order, err := s.UpdateOrderStatus(ctx, id, req.Status)
+		switch {
+		case err == nil:
+			result.Status = order.Status
+		case errors.Is(err, models.ErrOrderNotFound), errors.Is(err, models.ErrInvalidStatusTransition),
+			errors.Is(err, models.ErrOrderNotCancellable):
+			result.Error = err.Error()
+		default:
+			log.Error().Err(err).Uint("orderID", id).Msg("Bulk status update failed")
+			result.Error = "failed to update order status"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// UpdateOrderStatus moves an order along the lifecycle. Moves that the
+// transition rules do not allow are refused; cancelling goes through the same
+// path as a customer cancellation, and orders only become returned through
+// the returns flow.
 func (s *orderService) UpdateOrderStatus(ctx context.Context, orderID uint, status models.OrderStatus) (*models.Order, error) {
-	if err := s.orderRepo.UpdateStatus(ctx, orderID, status); err != nil {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if status == models.OrderStatusCancelled {
+		return s.cancel(ctx, order, "Cancelled by the shop")
+	}
+	if status == models.OrderStatusReturned || !order.Status.CanTransitionTo(status) {
+		return nil, models.ErrInvalidStatusTransition
+	}
+
+	moved, err := s.orderRepo.TransitionStatus(ctx, orderID, order.Status, status)
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		// Someone else changed the order since we read it
+		return nil, models.ErrInvalidStatusTransition
+	}
+
+	order, err = s.orderRepo.GetByID(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,3 +336,76 @@ func (s *orderService) UpdateOrderStatus(ctx context.Context, orderID uint, stat
 
 	return order, nil
 }
+
+// CancelOrder lets a customer cancel their own order before it ships.
+func (s *orderService) CancelOrder(ctx context.Context, customerID, orderID uint, req *OrderCancelRequest) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && order.CustomerID != customerID) {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Cancelled by the customer"
+	}
+	return s.cancel(ctx, order, reason)
+}
+
+// cancel cancels the order and restocks it, refunds it in full if it was
+// paid, and tells the customer. Whether it was paid is what the repository
+// saw with the order locked, so a payment landing meanwhile is refunded too.
+// A refund the provider did not answer for stays pending and is sent again
+// by the reconciler; one that could not be made at all fails the call, with
+// the order left cancelled, so staff can refund it by hand.
+func (s *orderService) cancel(ctx context.Context, order *models.Order, reason string) (*models.Order, error) {
+	if !order.Status.Cancellable() {
+		return nil, models.ErrOrderNotCancellable
+	}
+
+	previous, err := s.orderRepo.Cancel(ctx, order.ID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	var refund *models.Refund
+	if previous == models.OrderStatusPaid {
+		refund, err = s.payments.RefundOrder(ctx, order.ID, &RefundCreateRequest{Reason: reason})
+		if err == nil && refund.Status == models.PaymentStatusFailed {
+			err = errors.New(refund.ResultDesc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("order %d is cancelled but was not refunded: %w", order.ID, err)
+		}
+	}
+
+	cancelled, err := s.orderRepo.GetByID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notifier.SendOrderCancelled(cancelled, refund); err != nil {
+		log.Error().Err(err).Uint("orderID", order.ID).Msg("Failed to send cancellation notification")
+	}
+
+	return cancelled, nil
+}
+
+// shippingAddress looks up the chosen address, or the default one.
+func (s *orderService) shippingAddress(ctx context.Context, customerID uint, addressID *uint) (*models.Address, error) {
+	if addressID != nil {
+		address, err := s.addressRepo.GetByID(ctx, customerID, *addressID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAddressNotFound
+		}
+		return address, err
+	}
+
+	address, err := s.addressRepo.GetDefault(ctx, customerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrShippingAddressRequired
+	}
+	return address, err
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+	"github.com/Mutonya/Savanah/pkg/payments"
+)
+
+func TestOrderStatusTransitions(t *testing.T) {
+	assert.True(t, models.OrderStatusPending.CanTransitionTo(models.OrderStatusPaid))
+	assert.True(t, models.OrderStatusPaid.CanTransitionTo(models.OrderStatusShipped))
+	assert.False(t, models.OrderStatusPending.CanTransitionTo(models.OrderStatusShipped))
+	assert.False(t, models.OrderStatusCancelled.CanTransitionTo(models.OrderStatusPending))
+
+	assert.True(t, models.OrderStatusPaid.Cancellable())
+	assert.False(t, models.OrderStatusShipped.Cancellable())
+}
+
+func TestOrderService_CancelPaidOrderRefunds(t *testing.T) {
+	paymentSvc, _, paymentRepo, orderRepo := newTestPaymentService()
+	notifier := &fakeNotifier{}
+	s := &orderService{orderRepo: orderRepo, notifier: notifier, payments: paymentSvc}
+	ctx := context.Background()
+
+	payment, err := paymentSvc.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NoError(t, paymentSvc.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123",
+	}))
+
+	// Shipping a paid order is fine, but not completing it straight away
+	_, err = s.UpdateOrderStatus(ctx, 1, models.OrderStatusCompleted)
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	_, err = s.CancelOrder(ctx, 8, 1, &OrderCancelRequest{})
+	assert.ErrorIs(t, err, models.ErrOrderNotFound, "other customers' orders are invisible")
+
+	order, err := s.CancelOrder(ctx, 7, 1, &OrderCancelRequest{Reason: "ordered twice"})
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	assert.Equal(t, "ordered twice", order.CancelReason)
+	assert.Equal(t, 1000.0, paymentRepo.payments[0].RefundedAmount)
+	require.Len(t, notifier.cancellations, 1)
+	require.NotNil(t, notifier.cancellations[0])
+	assert.Equal(t, 1000.0, notifier.cancellations[0].Amount)
+
+	_, err = s.CancelOrder(ctx, 7, 1, &OrderCancelRequest{})
+	assert.ErrorIs(t, err, models.ErrOrderNotCancellable)
+}
+
+func TestOrderService_CancelRetriesUnansweredRefund(t *testing.T) {
+	paymentSvc, provider, paymentRepo, orderRepo := newTestPaymentService()
+	notifier := &fakeNotifier{}
+	s := &orderService{orderRepo: orderRepo, notifier: notifier, payments: paymentSvc}
+	ctx := context.Background()
+
+	payment, err := paymentSvc.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NoError(t, paymentSvc.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123",
+	}))
+
+	provider.RefundErr = errors.New("connection reset")
+	_, err = s.CancelOrder(ctx, 7, 1, &OrderCancelRequest{})
+	require.NoError(t, err)
+	require.Len(t, paymentRepo.refunds, 1)
+	assert.Equal(t, models.PaymentStatusPending, paymentRepo.refunds[0].Status, "the refund waits to be sent again")
+	assert.Equal(t, 1000.0, paymentRepo.payments[0].RefundedAmount, "its amount stays reserved")
+
+	provider.RefundErr = nil
+	paymentSvc.now = func() time.Time { return time.Now().Add(15 * time.Minute) }
+	sent, err := paymentSvc.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, models.PaymentStatusSucceeded, paymentRepo.refunds[0].Status)
+	assert.Len(t, provider.Refunds, 1)
+}
+
+func TestOrderService_CancelFailsWhenRefundFails(t *testing.T) {
+	paymentSvc, provider, paymentRepo, orderRepo := newTestPaymentService()
+	s := &orderService{orderRepo: orderRepo, notifier: &fakeNotifier{}, payments: paymentSvc}
+	ctx := context.Background()
+
+	payment, err := paymentSvc.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NoError(t, paymentSvc.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123",
+	}))
+
+	provider.RefundStatus = payments.StatusFailed
+	_, err = s.CancelOrder(ctx, 7, 1, &OrderCancelRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, models.OrderStatusCancelled, orderRepo.orders[1].Status)
+	assert.Zero(t, paymentRepo.payments[0].RefundedAmount, "staff can still refund it")
+}
+
+func TestNewOrderReference(t *testing.T) {
+	format := regexp.MustCompile(`^SAV-[2-9A-HJKMNP-Z]{4}-[2-9A-HJKMNP-Z]{4}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		ref := models.NewOrderReference()
+		require.Regexp(t, format, ref)
+		require.False(t, seen[ref], "duplicate reference %s", ref)
+		seen[ref] = true
+	}
+}
+
+func TestOrderService_GetOrderByReference(t *testing.T) {
+	orderRepo := newFakeOrderRepo()
+	orderRepo.orders[1].Reference = "SAV-7K3Q-92XD"
+	s := &orderService{orderRepo: orderRepo}
+	ctx := context.Background()
+
+	order, err := s.GetOrderByReference(ctx, 7, " sav-7k3q-92xd ")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), order.ID)
+
+	_, err = s.GetOrderByReference(ctx, 8, "SAV-7K3Q-92XD")
+	assert.ErrorIs(t, err, models.ErrOrderNotFound, "other customers' orders are invisible")
+
+	_, err = s.GetOrderByReference(ctx, 7, "SAV-0000-0000")
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+}
+
+func TestOrderService_BulkUpdateOrderStatus(t *testing.T) {
+	orderRepo := newFakeOrderRepo()
+	orderRepo.orders[1].Status = models.OrderStatusPaid
+	orderRepo.orders[2] = &models.Order{Model: gorm.Model{ID: 2}, CustomerID: 8, Status: models.OrderStatusPending}
+	notifier := &fakeNotifier{}
+	s := &orderService{orderRepo: orderRepo, notifier: notifier}
+
+	results := s.BulkUpdateOrderStatus(context.Background(), &BulkOrderStatusRequest{
+		OrderIDs: []uint{1, 2, 99, 1},
+		Status:   models.OrderStatusShipped,
+	})
+
+	assert.Equal(t, []BulkOrderStatusResult{
+		{OrderID: 1, Status: models.OrderStatusShipped},
+		{OrderID: 2, Error: models.ErrInvalidStatusTransition.Error()},
+		{OrderID: 99, Error: models.ErrOrderNotFound.Error()},
+	}, results)
+	assert.Equal(t, []models.OrderStatus{models.OrderStatusShipped}, notifier.statusUpdates, "only moved orders are notified")
+	assert.Equal(t, models.OrderStatusPending, orderRepo.orders[2].Status)
+}
+
+func TestOrderFilter(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	filter := orderFilter(&OrderSearchRequest{From: day, To: day, Sort: "-total"})
+	assert.Equal(t, repositories.OrderFilter{
+		From: day, To: day.AddDate(0, 0, 1), Sort: "total", Descending: true,
+	}, filter, "the To day is included")
+}
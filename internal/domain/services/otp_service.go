@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+// otpDigits is the length of the codes sent.
+const otpDigits = 6
+
+// OTPService sends one-time codes by SMS and checks them. Flows that need a
+// code, such as phone verification, send one for a purpose and subject and
+// later verify what the customer typed in.
+type OTPService interface {
+	Send(ctx context.Context, purpose models.OTPPurpose, subject, phone string) (*OTPChallenge, error)
+	Resend(ctx context.Context, purpose models.OTPPurpose, subject string) (*OTPChallenge, error)
+	Verify(ctx context.Context, purpose models.OTPPurpose, subject, code string) (*models.OTP, error)
+	Cancel(ctx context.Context, purpose models.OTPPurpose, subject string) error
+	PurgeStale(ctx context.Context) (int64, error)
+}
+
+// OTPVerifier completes a flow once the customer has entered its code. The
+// result is returned to the client.
+type OTPVerifier interface {
+	VerifyOTP(ctx context.Context, customerID uint, code string) (interface{}, error)
+}
+
+type OTPVerifyRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// OTPChallenge tells the client where a code went, until when it can be
+// used, and when another can be requested.
+type OTPChallenge struct {
+	Purpose     models.OTPPurpose `json:"purpose"`
+	Phone       string            `json:"phone"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+	ResendAfter time.Time         `json:"resend_after"`
+}
+
+type otpService struct {
+	otpRepo  repositories.OTPRepository
+	notifier NotificationService
+	config   *config.Config
+	now      func() time.Time
+}
+
+func NewOTPService(otpRepo repositories.OTPRepository, notifier NotificationService, config *config.Config) OTPService {
+	return &otpService{
+		otpRepo:  otpRepo,
+		notifier: notifier,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// Send texts a new code to the phone, replacing any earlier code for the
+// purpose and subject. Codes cannot be requested more often than the resend
+// interval, nor more than the send limit within the send window.
+func (s *otpService) Send(ctx context.Context, purpose models.OTPPurpose, subject, phone string) (*OTPChallenge, error) {
+	previous, err := s.otpRepo.Get(ctx, purpose, subject)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return s.send(ctx, purpose, subject, phone, previous)
+}
+
+// Resend texts a new code to the phone the last one went to.
+func (s *otpService) Resend(ctx context.Context, purpose models.OTPPurpose, subject string) (*OTPChallenge, error) {
+	previous, err := s.otpRepo.Get(ctx, purpose, subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOTPNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.send(ctx, purpose, subject, previous.Phone, previous)
+}
+
+func (s *otpService) send(ctx context.Context, purpose models.OTPPurpose, subject, phone string, previous *models.OTP) (*OTPChallenge, error) {
+	now := s.now()
+	otp := &models.OTP{
+		Purpose:         purpose,
+		Subject:         subject,
+		Phone:           phone,
+		Sends:           1,
+		WindowStartedAt: now,
+		LastSentAt:      now,
+		ExpiresAt:       now.Add(s.config.OTPTTL),
+	}
+	if previous != nil {
+		if now.Before(previous.LastSentAt.Add(s.config.OTPResendInterval)) {
+			return nil, models.ErrOTPResendTooSoon
+		}
+		if now.Before(previous.WindowStartedAt.Add(s.config.OTPSendWindow)) {
+			if previous.Sends >= s.config.OTPMaxSends {
+				return nil, models.ErrOTPResendLimit
+			}
+			otp.Sends, otp.WindowStartedAt = previous.Sends+1, previous.WindowStartedAt
+		}
+	}
+
+	code, err := newOTPCode()
+	if err != nil {
+		return nil, err
+	}
+	otp.CodeHash = s.hash(otp, code)
+	if err := s.otpRepo.Save(ctx, otp); err != nil {
+		return nil, err
+	}
+	if err := s.notifier.SendOTP(phone, code, s.config.OTPTTL); err != nil {
+		return nil, err
+	}
+
+	return &OTPChallenge{
+		Purpose:     purpose,
+		Phone:       phone,
+		ExpiresAt:   otp.ExpiresAt,
+		ResendAfter: now.Add(s.config.OTPResendInterval),
+	}, nil
+}
+
+// Verify checks a code and uses it up, returning the OTP so the caller knows
+// which phone it went to. Each code allows a limited number of attempts.
+func (s *otpService) Verify(ctx context.Context, purpose models.OTPPurpose, subject, code string) (*models.OTP, error) {
+	otp, err := s.otpRepo.Get(ctx, purpose, subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOTPNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !s.now().Before(otp.ExpiresAt) {
+		return nil, models.ErrOTPExpired
+	}
+
+	allowed, err := s.otpRepo.RecordAttempt(ctx, otp.ID, s.config.OTPMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, models.ErrOTPTooManyAttempts
+	}
+
+	if !hmac.Equal([]byte(s.hash(otp, code)), []byte(otp.CodeHash)) {
+		if otp.Attempts+1 >= s.config.OTPMaxAttempts {
+			return nil, models.ErrOTPTooManyAttempts
+		}
+		return nil, models.ErrOTPInvalid
+	}
+
+	deleted, err := s.otpRepo.Delete(ctx, otp.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		// A concurrent request used the code first
+		return nil, models.ErrOTPNotFound
+	}
+	return otp, nil
+}
+
+func (s *otpService) Cancel(ctx context.Context, purpose models.OTPPurpose, subject string) error {
+	return s.otpRepo.DeleteFor(ctx, purpose, subject)
+}
+
+// PurgeStale removes codes that have expired and no longer count towards
+// the send limit.
+func (s *otpService) PurgeStale(ctx context.Context) (int64, error) {
+	keep := s.config.OTPSendWindow
+	if s.config.OTPTTL > keep {
+		keep = s.config.OTPTTL
+	}
+	return s.otpRepo.DeleteStale(ctx, s.now().Add(-keep))
+}
+
+// hash binds the code to what it was sent for and where, keyed with the OTP
+// secret so a leaked table cannot be brute-forced offline.
+func (s *otpService) hash(otp *models.OTP, code string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.OTPSecret))
+	fmt.Fprintf(mac, "%s|%s|%s|%s", otp.Purpose, otp.Subject, otp.Phone, code)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newOTPCode draws a random numeric code.
+func newOTPCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+// newTestOTPService allows 3 attempts per code and 3 codes an hour, a
+// minute apart. Advance the returned clock to move time.
+func newTestOTPService() (*otpService, *fakeNotifier, *time.Time) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	notifier := &fakeNotifier{}
+	return &otpService{
+		otpRepo:  &fakeOTPRepo{otps: map[string]*models.OTP{}},
+		notifier: notifier,
+		config: &config.Config{
+			OTPSecret:         "test",
+			OTPTTL:            10 * time.Minute,
+			OTPMaxAttempts:    3,
+			OTPResendInterval: time.Minute,
+			OTPMaxSends:       3,
+			OTPSendWindow:     time.Hour,
+		},
+		now: func() time.Time { return now },
+	}, notifier, &now
+}
+
+func TestOTPService_VerifyLimitsAttemptsAndExpires(t *testing.T) {
+	s, notifier, now := newTestOTPService()
+	ctx := context.Background()
+	purpose, subject := models.OTPPurposePhoneVerification, models.CustomerOTPSubject(7)
+
+	_, err := s.Verify(ctx, purpose, subject, "123456")
+	assert.ErrorIs(t, err, models.ErrOTPNotFound)
+
+	challenge, err := s.Send(ctx, purpose, subject, "+254712345678")
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(10*time.Minute), challenge.ExpiresAt)
+	code := notifier.codes["+254712345678"]
+	require.Regexp(t, `^\d{6}$`, code)
+
+	stored, _ := s.otpRepo.Get(ctx, purpose, subject)
+	assert.NotContains(t, stored.CodeHash, code, "only the hash is stored")
+
+	_, err = s.Verify(ctx, models.OTPPurpose("login"), subject, code)
+	assert.ErrorIs(t, err, models.ErrOTPNotFound, "codes only work for their purpose")
+
+	_, err = s.Verify(ctx, purpose, subject, wrongCode(code))
+	assert.ErrorIs(t, err, models.ErrOTPInvalid)
+	_, err = s.Verify(ctx, purpose, subject, wrongCode(code))
+	assert.ErrorIs(t, err, models.ErrOTPInvalid)
+	_, err = s.Verify(ctx, purpose, subject, wrongCode(code))
+	assert.ErrorIs(t, err, models.ErrOTPTooManyAttempts)
+	_, err = s.Verify(ctx, purpose, subject, code)
+	assert.ErrorIs(t, err, models.ErrOTPTooManyAttempts, "the right code no longer helps")
+
+	// A new code starts over
+	*now = now.Add(time.Minute)
+	_, err = s.Resend(ctx, purpose, subject)
+	require.NoError(t, err)
+	code = notifier.codes["+254712345678"]
+	otp, err := s.Verify(ctx, purpose, subject, code)
+	require.NoError(t, err)
+	assert.Equal(t, "+254712345678", otp.Phone)
+
+	_, err = s.Send(ctx, purpose, subject, "+254712345678")
+	require.NoError(t, err)
+	*now = now.Add(10 * time.Minute)
+	_, err = s.Verify(ctx, purpose, subject, notifier.codes["+254712345678"])
+	assert.ErrorIs(t, err, models.ErrOTPExpired)
+}
+
+func TestOTPService_ResendLimits(t *testing.T) {
+	s, _, now := newTestOTPService()
+	ctx := context.Background()
+	purpose, subject := models.OTPPurposePhoneVerification, models.CustomerOTPSubject(7)
+
+	_, err := s.Resend(ctx, purpose, subject)
+	assert.ErrorIs(t, err, models.ErrOTPNotFound)
+
+	_, err = s.Send(ctx, purpose, subject, "+254712345678")
+	require.NoError(t, err)
+	_, err = s.Resend(ctx, purpose, subject)
+	assert.ErrorIs(t, err, models.ErrOTPResendTooSoon)
+
+	for i := 0; i < 2; i++ {
+		*now = now.Add(time.Minute)
+		_, err = s.Resend(ctx, purpose, subject)
+		require.NoError(t, err)
+	}
+	*now = now.Add(time.Minute)
+	_, err = s.Send(ctx, purpose, subject, "+254722000000")
+	assert.ErrorIs(t, err, models.ErrOTPResendLimit, "a new number does not reset the limit")
+
+	*now = now.Add(time.Hour)
+	_, err = s.Resend(ctx, purpose, subject)
+	assert.NoError(t, err, "the window has passed")
+
+	removed, err := s.PurgeStale(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	*now = now.Add(time.Hour + time.Second)
+	removed, err = s.PurgeStale(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+	"github.com/Mutonya/Savanah/pkg/payments"
+)
+
+const (
+	// promptTTL is how long a payment prompt (e.g. an STK push) stays
+	// answerable. A pending attempt younger than this blocks a second one.
+	promptTTL = 2 * time.Minute
+
+	// pendingExpiry is how long the reconciler waits on a provider that keeps
+	// reporting an attempt as pending before giving up on it.
+	pendingExpiry = 24 * time.Hour
+
+	reconcileBatchSize = 100
+)
+
+type PaymentService interface {
+	InitiatePayment(ctx context.Context, customerID, orderID uint, provider string, req *PaymentInitiateRequest) (*models.Payment, error)
+	HandlePaymentResult(ctx context.Context, provider string, result *payments.Result) error
+	GetOrderPayments(ctx context.Context, customerID, orderID uint) ([]models.Payment, error)
+	RefundOrder(ctx context.Context, orderID uint, req *RefundCreateRequest) (*models.Refund, error)
+	HandleRefundResult(ctx context.Context, result *payments.Result) error
+	Reconcile(ctx context.Context) (int, error)
+}
+
+type PaymentInitiateRequest struct {
+	// Phone defaults to the customer's phone number when empty
+	Phone string `json:"phone"`
+}
+
+type RefundCreateRequest struct {
+	// Amount defaults to everything still refundable when zero
+	Amount float64 `json:"amount" binding:"omitempty,gt=0"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+type paymentService struct {
+	paymentRepo    repositories.PaymentRepository
+	orderRepo      repositories.OrderRepository
+	notifier       NotificationService
+	providers      map[string]payments.PaymentProvider
+	pendingTimeout time.Duration
+	now            func() time.Time
+}
+
+func NewPaymentService(
+	paymentRepo repositories.PaymentRepository,
+	orderRepo repositories.OrderRepository,
+	notifier NotificationService,
+	config *config.Config,
+	providers ...payments.PaymentProvider,
+) PaymentService {
+	registry := make(map[string]payments.PaymentProvider, len(providers))
+	for _, p := range providers {
+		registry[p.Name()] = p
+	}
+	return &paymentService{
+		paymentRepo:    paymentRepo,
+		orderRepo:      orderRepo,
+		notifier:       notifier,
+		providers:      registry,
+		pendingTimeout: config.PaymentPendingTimeout,
+		now:            time.Now,
+	}
+}
+
+func (s *paymentService) InitiatePayment(ctx context.Context, customerID, orderID uint, provider string, req *PaymentInitiateRequest) (*models.Payment, error) {
+	gateway, ok := s.providers[provider]
+	if !ok {
+		return nil, models.ErrUnknownPaymentProvider
+	}
+
+	order, err := s.customerOrder(ctx, customerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, models.ErrOrderNotPayable
+	}
+
+	previous, err := s.paymentRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range previous {
+		if p.Status == models.PaymentStatusPending && s.now().Sub(p.CreatedAt) < promptTTL {
+			return nil, models.ErrPaymentInProgress
+		}
+	}
+
+	phone := req.Phone
+	if phone == "" {
+		phone = order.Customer.Phone
+	}
+
+	// Without dashes the order reference fits M-Pesa's 12-character account
+	// reference
+	result, err := gateway.Initiate(ctx, payments.InitiateRequest{
+		OrderID:     order.ID,
+		Amount:      order.Total,
+		Phone:       phone,
+		Reference:   strings.ReplaceAll(order.Reference, "-", ""),
+		Description: order.Reference,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initiate %s payment: %w", provider, err)
+	}
+
+	payment := &models.Payment{
+		OrderID:           order.ID,
+		Provider:          provider,
+		Amount:            result.Amount,
+		Phone:             phone,
+		Status:            models.PaymentStatusPending,
+		ProviderReference: result.Reference,
+	}
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	// Some providers settle synchronously
+	if result.Status != payments.StatusPending {
+		if err := s.settle(ctx, payment, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
+
+// HandlePaymentResult records an outcome pushed by a provider callback.
+func (s *paymentService) HandlePaymentResult(ctx context.Context, provider string, result *payments.Result) error {
+	payment, err := s.paymentRepo.GetByProviderReference(ctx, provider, result.Reference)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrPaymentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.settle(ctx, payment, result)
+}
+
+func (s *paymentService) GetOrderPayments(ctx context.Context, customerID, orderID uint) ([]models.Payment, error) {
+	order, err := s.customerOrder(ctx, customerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentRepo.GetByOrderID(ctx, order.ID)
+}
+
+// RefundOrder returns all or part of what was paid for a cancelled or
+// returned order through the provider that took the payment.
+func (s *paymentService) RefundOrder(ctx context.Context, orderID uint, req *RefundCreateRequest) (*models.Refund, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !order.Status.Refundable() {
+		return nil, models.ErrOrderNotRefundable
+	}
+
+	attempts, err := s.paymentRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	var payment *models.Payment
+	for i := range attempts {
+		if attempts[i].Refundable() > 0 && (payment == nil || attempts[i].Refundable() > payment.Refundable()) {
+			payment = &attempts[i]
+		}
+	}
+	if payment == nil {
+		return nil, models.ErrNothingToRefund
+	}
+
+	return s.refundPayment(ctx, payment, req.Amount, req.Reason)
+}
+
+// refundPayment pays back amount of the payment, or all that is left of it
+// when amount is zero.
+func (s *paymentService) refundPayment(ctx context.Context, payment *models.Payment, amount float64, reason string) (*models.Refund, error) {
+	gateway, ok := s.providers[payment.Provider]
+	if !ok {
+		return nil, models.ErrUnknownPaymentProvider
+	}
+
+	amount = roundMoney(amount)
+	if amount == 0 {
+		amount = roundMoney(payment.Refundable())
+	}
+	reserved, err := s.paymentRepo.ReserveRefund(ctx, payment.ID, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return nil, models.ErrRefundExceedsPayment
+	}
+
+	refund := &models.Refund{
+		PaymentID: payment.ID,
+		OrderID:   payment.OrderID,
+		Amount:    amount,
+		Reason:    reason,
+		Status:    models.PaymentStatusPending,
+	}
+	if err := s.paymentRepo.CreateRefund(ctx, refund); err != nil {
+		_ = s.paymentRepo.ReleaseRefund(ctx, payment.ID, amount)
+		return nil, err
+	}
+
+	if err := s.sendRefund(ctx, gateway, payment, refund); err != nil {
+		// An amount the provider cannot refund is the caller's to fix
+		if errors.Is(err, payments.ErrInvalidAmount) {
+			return nil, err
+		}
+		// Without an answer the refund may or may not have reached the
+		// provider. It stays pending, with its amount reserved, and the
+		// reconciler sends it again.
+		log.Error().Err(err).Uint("refundID", refund.ID).Msg("Failed to send refund, it will be retried")
+	}
+
+	return refund, nil
+}
+
+// sendRefund asks the provider to pay the refund back and records the
+// answer. When there is none the refund is left pending and the error is
+// returned, except for an amount the provider cannot move, which fails the
+// refund for good.
+func (s *paymentService) sendRefund(ctx context.Context, gateway payments.PaymentProvider, payment *models.Payment, refund *models.Refund) error {
+	result, err := gateway.Refund(ctx, payments.RefundRequest{
+		PaymentReference:      payment.ProviderReference,
+		ProviderTransactionID: payment.ProviderTransactionID,
+		Amount:                refund.Amount,
+		Reason:                refund.Reason,
+	})
+	if errors.Is(err, payments.ErrInvalidAmount) {
+		if settleErr := s.settleRefund(ctx, refund, &payments.Result{Status: payments.StatusFailed, Description: err.Error()}); settleErr != nil {
+			return settleErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return s.settleRefund(ctx, refund, result)
+}
+
+// HandleRefundResult records the asynchronous outcome of a refund.
+func (s *paymentService) HandleRefundResult(ctx context.Context, result *payments.Result) error {
+	refund, err := s.paymentRepo.GetRefundByProviderReference(ctx, result.Reference)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrRefundNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.settleRefund(ctx, refund, result)
+}
+
+// Reconcile asks the providers about attempts that have been pending for
+// longer than the configured timeout and settles the ones that have an
+// outcome, then sends again the refunds that never reached a provider. It
+// returns the number of attempts settled and refunds sent.
+func (s *paymentService) Reconcile(ctx context.Context) (int, error) {
+	stuck, err := s.paymentRepo.GetPendingBefore(ctx, s.now().Add(-s.pendingTimeout), reconcileBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for i := range stuck {
+		payment := &stuck[i]
+
+		gateway, ok := s.providers[payment.Provider]
+		if !ok {
+			log.Warn().Uint("paymentID", payment.ID).Str("provider", payment.Provider).Msg("No provider to reconcile payment with")
+			continue
+		}
+
+		result, err := gateway.QueryStatus(ctx, payment.ProviderReference)
+		if err != nil {
+			log.Error().Err(err).Uint("paymentID", payment.ID).Msg("Failed to query payment status")
+			continue
+		}
+
+		if result.Status == payments.StatusPending {
+			if s.now().Sub(payment.CreatedAt) < pendingExpiry {
+				continue
+			}
+			result.Status = payments.StatusFailed
+			result.Description = "expired while pending"
+		}
+
+		if err := s.settle(ctx, payment, result); err != nil {
+			log.Error().Err(err).Uint("paymentID", payment.ID).Msg("Failed to settle reconciled payment")
+			continue
+		}
+		settled++
+	}
+
+	sent, err := s.resendRefunds(ctx)
+	return settled + sent, err
+}
+
+// resendRefunds sends the refunds a provider did not answer for again. One
+// may have arrived the first time after all; M-Pesa refuses to reverse a
+// transaction twice, so the repeat fails instead of paying out again.
+func (s *paymentService) resendRefunds(ctx context.Context) (int, error) {
+	unsent, err := s.paymentRepo.GetUnsentRefundsBefore(ctx, s.now().Add(-s.pendingTimeout), reconcileBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range unsent {
+		refund := &unsent[i]
+
+		gateway, ok := s.providers[refund.Payment.Provider]
+		if !ok {
+			log.Warn().Uint("refundID", refund.ID).Str("provider", refund.Payment.Provider).Msg("No provider to send refund through")
+			continue
+		}
+		if err := s.sendRefund(ctx, gateway, &refund.Payment, refund); err != nil {
+			log.Error().Err(err).Uint("refundID", refund.ID).Msg("Failed to send refund again")
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func (s *paymentService) settle(ctx context.Context, payment *models.Payment, result *payments.Result) error {
+	payment.Status = models.PaymentStatus(result.Status)
+	payment.ProviderTransactionID = result.ProviderTransactionID
+	payment.ResultCode = result.Code
+	payment.ResultDesc = result.Description
+
+	// An underpaid order must not ship: the payment is held for review
+	if payment.Status == models.PaymentStatusSucceeded && result.Amount > 0 && result.Amount < payment.Amount {
+		log.Warn().Uint("paymentID", payment.ID).Float64("expected", payment.Amount).
+			Float64("paid", result.Amount).Msg("Payment amount is lower than requested")
+		payment.Status = models.PaymentStatusAmountMismatch
+		payment.ResultDesc = fmt.Sprintf("paid %.2f of %.2f", result.Amount, payment.Amount)
+	}
+
+	settled, err := s.paymentRepo.Settle(ctx, payment)
+	if err != nil {
+		return err
+	}
+	if !settled {
+		log.Info().Uint("paymentID", payment.ID).Msg("Ignoring result for already settled payment")
+		return nil
+	}
+
+	if payment.Status != models.PaymentStatusSucceeded {
+		return nil
+	}
+	return s.markOrderPaid(ctx, payment)
+}
+
+func (s *paymentService) settleRefund(ctx context.Context, refund *models.Refund, result *payments.Result) error {
+	refund.Status = models.PaymentStatus(result.Status)
+	refund.ResultDesc = result.Description
+	if result.Reference != "" {
+		refund.ProviderReference = result.Reference
+	}
+	if result.ProviderTransactionID != "" {
+		refund.ProviderTransactionID = result.ProviderTransactionID
+	}
+
+	settled, err := s.paymentRepo.SettleRefund(ctx, refund)
+	if err != nil {
+		return err
+	}
+	if settled && refund.Status == models.PaymentStatusFailed {
+		return s.paymentRepo.ReleaseRefund(ctx, refund.PaymentID, refund.Amount)
+	}
+	return nil
+}
+
+// markOrderPaid moves the order from pending to paid. A payment that finds
+// the order no longer pending is paid back in full: the order was cancelled
+// while the customer was paying, e.g. while they typed their PIN, or was
+// already paid for, e.g. through an earlier prompt answered late.
+func (s *paymentService) markOrderPaid(ctx context.Context, payment *models.Payment) error {
+	moved, err := s.orderRepo.TransitionStatus(ctx, payment.OrderID, models.OrderStatusPending, models.OrderStatusPaid)
+	if err != nil {
+		return err
+	}
+	order, err := s.orderRepo.GetByID(ctx, payment.OrderID)
+	if err != nil {
+		return err
+	}
+
+	if !moved {
+		log.Warn().Uint("orderID", order.ID).Uint("paymentID", payment.ID).Str("status", string(order.Status)).
+			Msg("Payment received for an order that is no longer pending, refunding it")
+		reason := "Paid for an order that was already paid for"
+		if order.Status == models.OrderStatusCancelled {
+			reason = "Paid after the order was cancelled"
+		}
+		refund, err := s.refundPayment(ctx, payment, 0, reason)
+		if err != nil {
+			return fmt.Errorf("refunding payment %d of order %d: %w", payment.ID, order.ID, err)
+		}
+		if refund.Status == models.PaymentStatusFailed {
+			log.Error().Uint("orderID", order.ID).Uint("refundID", refund.ID).Str("reason", refund.ResultDesc).
+				Msg("Failed to refund payment for an order that is no longer pending")
+		}
+		return nil
+	}
+
+	if err := s.notifier.SendStatusUpdate(order); err != nil {
+		log.Error().Err(err).Uint("orderID", order.ID).Msg("Failed to send payment notification")
+	}
+	return nil
+}
+
+func (s *paymentService) customerOrder(ctx context.Context, customerID, orderID uint) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, models.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// roundMoney rounds to whole cents, matching the decimal(10,2) columns.
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
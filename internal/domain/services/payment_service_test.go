@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/pkg/payments"
+)
+
+func TestPaymentService_ReconcileSettlesStuckPayments(t *testing.T) {
+	s, provider, paymentRepo, orderRepo := newTestPaymentService()
+	ctx := context.Background()
+
+	payment, err := s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{Phone: "0712345678"})
+	require.NoError(t, err)
+
+	_, err = s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	assert.ErrorIs(t, err, models.ErrPaymentInProgress)
+
+	// Nothing is old enough to reconcile yet
+	settled, err := s.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, settled)
+
+	provider.Complete(payment.ProviderReference, "QWE123")
+	s.now = func() time.Time { return time.Now().Add(15 * time.Minute) }
+
+	settled, err = s.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, models.PaymentStatusSucceeded, paymentRepo.payments[0].Status)
+	assert.Equal(t, "QWE123", paymentRepo.payments[0].ProviderTransactionID)
+	assert.Equal(t, models.OrderStatusPaid, orderRepo.orders[1].Status)
+
+	// A late callback for the same attempt is ignored
+	err = s.HandlePaymentResult(ctx, "memory", &payments.Result{Reference: payment.ProviderReference, Status: payments.StatusFailed})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusSucceeded, paymentRepo.payments[0].Status)
+}
+
+func TestPaymentService_PartialAndFullRefunds(t *testing.T) {
+	s, provider, paymentRepo, orderRepo := newTestPaymentService()
+	ctx := context.Background()
+
+	payment, err := s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NoError(t, s.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123",
+	}))
+
+	_, err = s.RefundOrder(ctx, 1, &RefundCreateRequest{Reason: "changed mind"})
+	assert.ErrorIs(t, err, models.ErrOrderNotRefundable)
+
+	orderRepo.orders[1].Status = models.OrderStatusReturned
+
+	refund, err := s.RefundOrder(ctx, 1, &RefundCreateRequest{Amount: 250, Reason: "one item returned"})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusSucceeded, refund.Status)
+	assert.Equal(t, 250.0, paymentRepo.payments[0].RefundedAmount)
+
+	_, err = s.RefundOrder(ctx, 1, &RefundCreateRequest{Amount: 800, Reason: "too much"})
+	assert.ErrorIs(t, err, models.ErrRefundExceedsPayment)
+
+	// A failed refund gives the reserved amount back
+	provider.RefundStatus = payments.StatusFailed
+	refund, err = s.RefundOrder(ctx, 1, &RefundCreateRequest{Reason: "rest"})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusFailed, refund.Status)
+	assert.Equal(t, 250.0, paymentRepo.payments[0].RefundedAmount)
+
+	provider.RefundStatus = payments.StatusSucceeded
+	refund, err = s.RefundOrder(ctx, 1, &RefundCreateRequest{Reason: "rest"})
+	require.NoError(t, err)
+	assert.Equal(t, 750.0, refund.Amount)
+	assert.Equal(t, 1000.0, paymentRepo.payments[0].RefundedAmount)
+	assert.Len(t, provider.Refunds, 3)
+}
+
+func TestPaymentService_UnderpaymentIsHeldForReview(t *testing.T) {
+	s, _, paymentRepo, orderRepo := newTestPaymentService()
+	ctx := context.Background()
+
+	payment, err := s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NoError(t, s.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123", Amount: 400,
+	}))
+
+	assert.Equal(t, models.PaymentStatusAmountMismatch, paymentRepo.payments[0].Status)
+	assert.Equal(t, "paid 400.00 of 1000.00", paymentRepo.payments[0].ResultDesc)
+	assert.Equal(t, models.OrderStatusPending, orderRepo.orders[1].Status, "an underpaid order is not paid for")
+}
+
+func TestPaymentService_PaymentForCancelledOrderIsRefunded(t *testing.T) {
+	s, provider, paymentRepo, orderRepo := newTestPaymentService()
+	ctx := context.Background()
+
+	payment, err := s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	orderRepo.orders[1].Status = models.OrderStatusCancelled
+
+	require.NoError(t, s.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123",
+	}))
+
+	assert.Equal(t, models.OrderStatusCancelled, orderRepo.orders[1].Status, "a cancelled order stays cancelled")
+	assert.Equal(t, 1000.0, paymentRepo.payments[0].RefundedAmount)
+	require.Len(t, provider.Refunds, 1)
+	require.Len(t, paymentRepo.refunds, 1)
+	assert.Equal(t, models.PaymentStatusSucceeded, paymentRepo.refunds[0].Status)
+}
+
+func TestPaymentService_SecondSuccessfulPaymentIsRefunded(t *testing.T) {
+	s, provider, paymentRepo, orderRepo := newTestPaymentService()
+	ctx := context.Background()
+
+	first, err := s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	// The first prompt lapses unanswered and the customer asks for another
+	s.now = func() time.Time { return time.Now().Add(promptTTL + time.Minute) }
+	second, err := s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID)
+
+	// ...and then answers both
+	for i, payment := range []*models.Payment{first, second} {
+		require.NoError(t, s.HandlePaymentResult(ctx, "memory", &payments.Result{
+			Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: fmt.Sprintf("QWE%d", i),
+		}))
+	}
+
+	assert.Equal(t, models.OrderStatusPaid, orderRepo.orders[1].Status)
+	assert.Zero(t, paymentRepo.payments[0].RefundedAmount, "the payment that paid for the order is kept")
+	assert.Equal(t, 1000.0, paymentRepo.payments[1].RefundedAmount)
+	require.Len(t, provider.Refunds, 1)
+	require.Len(t, paymentRepo.refunds, 1)
+	assert.Equal(t, second.ID, paymentRepo.refunds[0].PaymentID)
+	assert.Equal(t, models.PaymentStatusSucceeded, paymentRepo.refunds[0].Status)
+}
+
+func TestPaymentService_RefundOfAmountProviderCannotMove(t *testing.T) {
+	s, provider, paymentRepo, orderRepo := newTestPaymentService()
+	ctx := context.Background()
+
+	payment, err := s.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NoError(t, s.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123",
+	}))
+	orderRepo.orders[1].Status = models.OrderStatusReturned
+
+	provider.RefundErr = payments.ErrInvalidAmount
+	_, err = s.RefundOrder(ctx, 1, &RefundCreateRequest{Amount: 99.5, Reason: "one item returned"})
+	assert.ErrorIs(t, err, payments.ErrInvalidAmount)
+	assert.Zero(t, paymentRepo.payments[0].RefundedAmount, "the reserved amount is given back")
+	assert.Equal(t, models.PaymentStatusFailed, paymentRepo.refunds[0].Status)
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 
+	"github.com/rs/zerolog/log"
+
 	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/repositories"
 )
@@ -10,6 +12,7 @@ import (
 type ProductService interface {
 	CreateProduct(ctx context.Context, req *ProductCreateRequest) (*models.Product, error)
 	GetProduct(ctx context.Context, id uint) (*models.Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*models.Product, error)
 	GetProducts(ctx context.Context, page, limit int) ([]models.Product, int64, error)
 	UpdateProduct(ctx context.Context, id uint, req *ProductUpdateRequest) (*models.Product, error)
 	DeleteProduct(ctx context.Context, id uint) error
@@ -20,23 +23,28 @@ type ProductCreateRequest struct {
 	Description string  `json:"description"`
 	Price       float64 `json:"price" binding:"required,gt=0"`
 	SKU         string  `json:"sku" binding:"required"`
+	WeightKg    float64 `json:"weight_kg" binding:"gte=0"`
+	Stock       *int    `json:"stock" binding:"omitempty,gte=0"` // omit to not track stock
 	CategoryID  uint    `json:"category_id" binding:"required"`
 }
 
 type ProductUpdateRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"gt=0"`
-	SKU         string  `json:"sku"`
-	CategoryID  uint    `json:"category_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       float64  `json:"price" binding:"gt=0"`
+	SKU         string   `json:"sku"`
+	WeightKg    *float64 `json:"weight_kg" binding:"omitempty,gte=0"`
+	Stock       *int     `json:"stock" binding:"omitempty,gte=0"`
+	CategoryID  uint     `json:"category_id"`
 }
 
 type productService struct {
 	productRepo repositories.ProductRepository
+	wishlists   WishlistService
 }
 
-func NewProductService(productRepo repositories.ProductRepository) ProductService {
-	return &productService{productRepo: productRepo}
+func NewProductService(productRepo repositories.ProductRepository, wishlists WishlistService) ProductService {
+	return &productService{productRepo: productRepo, wishlists: wishlists}
 }
 
 func (s *productService) CreateProduct(ctx context.Context, req *ProductCreateRequest) (*models.Product, error) {
@@ -45,6 +53,8 @@ func (s *productService) CreateProduct(ctx context.Context, req *ProductCreateRe
 		Description: req.Description,
 		Price:       req.Price,
 		SKU:         req.SKU,
+		WeightKg:    req.WeightKg,
+		Stock:       req.Stock,
 		CategoryID:  req.CategoryID,
 	}
 
@@ -59,6 +69,10 @@ func (s *productService) GetProduct(ctx context.Context, id uint) (*models.Produ
 	return s.productRepo.GetByID(ctx, id)
 }
 
+func (s *productService) GetProductBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	return s.productRepo.GetBySKU(ctx, sku)
+}
+
 func (s *productService) GetProducts(ctx context.Context, page, limit int) ([]models.Product, int64, error) {
 	return s.productRepo.GetAll(ctx, page, limit)
 }
@@ -68,6 +82,7 @@ func (s *productService) UpdateProduct(ctx context.Context, id uint, req *Produc
 	if err != nil {
 		return nil, err
 	}
+	wasOutOfStock := product.OutOfStock()
 
 	if req.Name != "" {
 		product.Name = req.Name
@@ -81,6 +96,12 @@ func (s *productService) UpdateProduct(ctx context.Context, id uint, req *Produc
 	if req.SKU != "" {
 		product.SKU = req.SKU
 	}
+	if req.WeightKg != nil {
+		product.WeightKg = *req.WeightKg
+	}
+	if req.Stock != nil {
+		product.Stock = req.Stock
+	}
 	if req.CategoryID > 0 {
 		product.CategoryID = req.CategoryID
 	}
@@ -89,6 +110,19 @@ func (s *productService) UpdateProduct(ctx context.Context, id uint, req *Produc
 		return nil, err
 	}
 
+	// Back in stock: tell the subscribers without holding up the response.
+	// Alerts missed here are picked up by the periodic run.
+	if wasOutOfStock && !product.OutOfStock() {
+		go func(productID uint) {
+			sent, err := s.wishlists.NotifyBackInStock(context.Background(), productID)
+			if err != nil {
+				log.Error().Err(err).Uint("productID", productID).Msg("Failed to send back-in-stock alerts")
+				return
+			}
+			log.Info().Uint("productID", productID).Int("sent", sent).Msg("Sent back-in-stock alerts")
+		}(product.ID)
+	}
+
 	return product, nil
 }
 
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+// ProfileService also verifies phone numbers, as the OTPVerifier for
+// models.OTPPurposePhoneVerification, and answers data-subject requests:
+// ExportData and EraseAccount.
+type ProfileService interface {
+	OTPVerifier
+	UpdateProfile(ctx context.Context, customerID uint, req *ProfileUpdateRequest) (*ProfileUpdateResult, error)
+	GetIdentities(ctx context.Context, customerID uint) ([]models.Identity, error)
+	ExportData(ctx context.Context, customerID uint) (*DataExport, error)
+	EraseAccount(ctx context.Context, customerID uint) error
+}
+
+// ProfileUpdateRequest changes only the fields that are present. An empty
+// phone removes the number; any other phone is texted a code and only
+// replaces the current number once verified.
+type ProfileUpdateRequest struct {
+	FirstName *string `json:"first_name" binding:"omitempty,min=1,max=100"`
+	LastName  *string `json:"last_name" binding:"omitempty,max=100"`
+	Phone     *string `json:"phone" binding:"omitempty,max=30"`
+	Address   *string `json:"address" binding:"omitempty,max=255"`
+}
+
+// ProfileUpdateResult.PhoneVerification is set when a code was sent to a new
+// phone number.
+type ProfileUpdateResult struct {
+	Customer          *models.Customer `json:"customer"`
+	PhoneVerification *OTPChallenge    `json:"phone_verification,omitempty"`
+}
+
+// DataExport is everything held about a customer, in the form they are
+// given it on request.
+type DataExport struct {
+	ExportedAt    time.Time             `json:"exported_at"`
+	Profile       *models.Customer      `json:"profile"`
+	Identities    []models.Identity     `json:"identities"`
+	Addresses     []models.Address      `json:"addresses"`
+	Orders        []models.Order        `json:"orders"`
+	Notifications []models.Notification `json:"notifications"`
+}
+
+type profileService struct {
+	customerRepo     repositories.CustomerRepository
+	identityRepo     repositories.IdentityRepository
+	orderRepo        repositories.OrderRepository
+	addressRepo      repositories.AddressRepository
+	notificationRepo repositories.NotificationRepository
+	otps             OTPService
+	now              func() time.Time
+}
+
+func NewProfileService(
+	customerRepo repositories.CustomerRepository,
+	identityRepo repositories.IdentityRepository,
+	orderRepo repositories.OrderRepository,
+	addressRepo repositories.AddressRepository,
+	notificationRepo repositories.NotificationRepository,
+	otps OTPService,
+) ProfileService {
+	return &profileService{
+		customerRepo:     customerRepo,
+		identityRepo:     identityRepo,
+		orderRepo:        orderRepo,
+		addressRepo:      addressRepo,
+		notificationRepo: notificationRepo,
+		otps:             otps,
+		now:              time.Now,
+	}
+}
+
+func (s *profileService) UpdateProfile(ctx context.Context, customerID uint, req *ProfileUpdateRequest) (*ProfileUpdateResult, error) {
+	customer, err := s.customerRepo.GetByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FirstName != nil {
+		customer.FirstName = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		customer.LastName = strings.TrimSpace(*req.LastName)
+	}
+	if req.Address != nil {
+		customer.Address = strings.TrimSpace(*req.Address)
+	}
+
+	// Work out the phone change before saving anything, so an invalid
+	// number leaves the profile untouched
+	var pendingPhone string
+	if req.Phone != nil {
+		if strings.TrimSpace(*req.Phone) == "" {
+			customer.Phone, customer.PhoneVerifiedAt = "", nil
+		} else {
+			phone, err := models.NormalizePhone(*req.Phone)
+			if err != nil {
+				return nil, err
+			}
+			if phone != customer.Phone || !customer.HasVerifiedPhone() {
+				pendingPhone = phone
+			}
+		}
+	}
+
+	if err := s.customerRepo.Update(ctx, customer); err != nil {
+		return nil, err
+	}
+
+	result := &ProfileUpdateResult{Customer: customer}
+	subject := models.CustomerOTPSubject(customerID)
+	switch {
+	case pendingPhone != "":
+		challenge, err := s.otps.Send(ctx, models.OTPPurposePhoneVerification, subject, pendingPhone)
+		if err != nil {
+			return nil, err
+		}
+		result.PhoneVerification = challenge
+	case req.Phone != nil:
+		// A cleared or unchanged number cancels any pending change
+		if err := s.otps.Cancel(ctx, models.OTPPurposePhoneVerification, subject); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// VerifyOTP checks the code texted to the customer's new number and, when
+// it matches, makes that number their phone.
+func (s *profileService) VerifyOTP(ctx context.Context, customerID uint, code string) (interface{}, error) {
+	otp, err := s.otps.Verify(ctx, models.OTPPurposePhoneVerification, models.CustomerOTPSubject(customerID), code)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.GetByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	customer.Phone, customer.PhoneVerifiedAt = otp.Phone, &now
+	if err := s.customerRepo.Update(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// GetIdentities lists the external accounts the customer can sign in with.
+func (s *profileService) GetIdentities(ctx context.Context, customerID uint) ([]models.Identity, error) {
+	return s.identityRepo.GetByCustomer(ctx, customerID)
+}
+
+// ExportData collects the customer's profile, linked sign-in identities,
+// address book, orders and the log of messages sent to them.
+func (s *profileService) ExportData(ctx context.Context, customerID uint) (*DataExport, error) {
+	customer, err := s.customerRepo.GetByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identityRepo.GetByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := s.addressRepo.GetByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	orders, _, err := s.orderRepo.Search(ctx, repositories.OrderFilter{CustomerID: customerID}, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := s.notificationRepo.GetByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataExport{
+		ExportedAt:    s.now(),
+		Profile:       customer,
+		Identities:    identities,
+		Addresses:     addresses,
+		Orders:        orders,
+		Notifications: notifications,
+	}, nil
+}
+
+// EraseAccount anonymises the customer and signs them out everywhere; see
+// CustomerRepository.Erase. Orders stay for accounting, so the account can
+// only be erased once none of them is still being paid for or delivered.
+func (s *profileService) EraseAccount(ctx context.Context, customerID uint) error {
+	orders, _, err := s.orderRepo.Search(ctx, repositories.OrderFilter{CustomerID: customerID}, 1, 0)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if order.Status.InProgress() {
+			return models.ErrErasureBlocked
+		}
+	}
+
+	return s.customerRepo.Erase(ctx, customerID, s.now())
+}
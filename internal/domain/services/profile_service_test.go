@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/config"
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	for raw, want := range map[string]string{
+		"0712 345 678":       "+254712345678",
+		"254712345678":       "+254712345678",
+		"+254 (712) 345-678": "+254712345678",
+		"0110-123-456":       "+254110123456",
+		"+44 20 7946 0958":   "+442079460958",
+		"0044 20 7946 0958":  "+442079460958",
+	} {
+		got, err := models.NormalizePhone(raw)
+		if assert.NoError(t, err, raw) {
+			assert.Equal(t, want, got, raw)
+		}
+	}
+	for _, raw := range []string{"", "712345678", "07123", "+2547123456789", "0712-345-67x", "+0123456789", "07+12345678"} {
+		_, err := models.NormalizePhone(raw)
+		assert.ErrorIs(t, err, models.ErrInvalidPhone, raw)
+	}
+}
+
+func TestProfileService_PhoneChangeNeedsCode(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{
+		7: {Model: gorm.Model{ID: 7}, FirstName: "Amina", Email: "a@example.com"},
+	}}
+	otps, notifier, _ := newTestOTPService()
+	s := &profileService{customerRepo: customers, otps: otps, now: time.Now}
+	ctx := context.Background()
+
+	phone, name := "0712 345 678", " Amina "
+	result, err := s.UpdateProfile(ctx, 7, &ProfileUpdateRequest{FirstName: &name, Phone: &phone})
+	require.NoError(t, err)
+	require.NotNil(t, result.PhoneVerification)
+	assert.Equal(t, "+254712345678", result.PhoneVerification.Phone)
+	assert.Equal(t, "Amina", customers.customers[7].FirstName)
+	assert.Empty(t, customers.customers[7].Phone, "the number is not saved until verified")
+
+	code := notifier.codes["+254712345678"]
+	_, err = s.VerifyOTP(ctx, 7, wrongCode(code))
+	assert.ErrorIs(t, err, models.ErrOTPInvalid)
+
+	verified, err := s.VerifyOTP(ctx, 7, code)
+	require.NoError(t, err)
+	customer := verified.(*models.Customer)
+	assert.Equal(t, "+254712345678", customer.Phone)
+	assert.True(t, customer.HasVerifiedPhone())
+
+	_, err = s.VerifyOTP(ctx, 7, code)
+	assert.ErrorIs(t, err, models.ErrOTPNotFound, "codes work once")
+
+	bad := "12345"
+	_, err = s.UpdateProfile(ctx, 7, &ProfileUpdateRequest{Phone: &bad})
+	assert.ErrorIs(t, err, models.ErrInvalidPhone)
+}
+
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestNotificationService_SkipsUnverifiedPhones(t *testing.T) {
+	log := &fakeNotificationRepo{}
+	s := &notificationService{config: &config.Config{}, notifications: log}
+	orderID := uint(3)
+
+	require.NoError(t, s.smsCustomer(&models.Customer{Model: gorm.Model{ID: 7}}, &orderID, "status_update", "hi"))
+	require.NoError(t, s.smsCustomer(&models.Customer{Model: gorm.Model{ID: 7}, Phone: "+254712345678"}, &orderID, "status_update", "hi"))
+
+	verified := time.Now()
+	err := s.smsCustomer(&models.Customer{Model: gorm.Model{ID: 7}, Phone: "+254712345678", PhoneVerifiedAt: &verified}, &orderID, "status_update", "hi")
+	assert.Error(t, err, "no SMS credentials configured")
+
+	require.Len(t, log.entries, 3)
+	assert.Equal(t, models.NotificationStatusSkipped, log.entries[0].Status)
+	assert.Equal(t, "no phone number", log.entries[0].Reason)
+	assert.Equal(t, "phone number not verified", log.entries[1].Reason)
+	assert.Equal(t, models.NotificationStatusFailed, log.entries[2].Status)
+	assert.Equal(t, &orderID, log.entries[2].OrderID)
+}
+
+func TestProfileService_EraseAccountWaitsForOpenOrders(t *testing.T) {
+	customers := &fakeCustomerRepo{customers: map[uint]*models.Customer{
+		7: {Model: gorm.Model{ID: 7}, FirstName: "Amina", Email: "a@example.com", Phone: "+254712345678"},
+	}}
+	orderRepo := newFakeOrderRepo()
+	s := &profileService{customerRepo: customers, orderRepo: orderRepo, now: time.Now}
+	ctx := context.Background()
+
+	err := s.EraseAccount(ctx, 7)
+	assert.ErrorIs(t, err, models.ErrErasureBlocked, "order 1 is still pending")
+	assert.Nil(t, customers.customers[7].ErasedAt)
+
+	orderRepo.orders[1].Status = models.OrderStatusCompleted
+	require.NoError(t, s.EraseAccount(ctx, 7))
+	erased := customers.customers[7]
+	require.NotNil(t, erased.ErasedAt)
+	assert.Equal(t, "erased-7@erased.invalid", erased.Email)
+	assert.Empty(t, erased.Phone)
+	assert.Contains(t, orderRepo.orders, uint(1), "orders are kept for accounting")
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type PromotionService interface {
+	CreateCoupon(ctx context.Context, req *CouponRequest) (*models.Coupon, error)
+	GetCoupon(ctx context.Context, id uint) (*models.Coupon, error)
+	GetCoupons(ctx context.Context, page, limit int) ([]models.Coupon, int64, error)
+	UpdateCoupon(ctx context.Context, id uint, req *CouponRequest) (*models.Coupon, error)
+	DeleteCoupon(ctx context.Context, id uint) error
+	ApplyCoupon(ctx context.Context, customerID uint, code string, order *models.Order) error
+}
+
+type CouponRequest struct {
+	Code             string              `json:"code" binding:"required,max=50"`
+	Description      string              `json:"description"`
+	DiscountType     models.DiscountType `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	Value            float64             `json:"value" binding:"required,gt=0"`
+	MaxDiscount      float64             `json:"max_discount" binding:"gte=0"`
+	MinBasket        float64             `json:"min_basket" binding:"gte=0"`
+	StartsAt         *time.Time          `json:"starts_at"`
+	ExpiresAt        *time.Time          `json:"expires_at"`
+	UsageLimit       int                 `json:"usage_limit" binding:"gte=0"`
+	PerCustomerLimit int                 `json:"per_customer_limit" binding:"gte=0"`
+	Active           *bool               `json:"active"`
+	CategoryIDs      []uint              `json:"category_ids"`
+	ProductIDs       []uint              `json:"product_ids"`
+}
+
+type promotionService struct {
+	couponRepo   repositories.CouponRepository
+	categoryRepo repositories.CategoryRepository
+	productRepo  repositories.ProductRepository
+	now          func() time.Time
+}
+
+func NewPromotionService(
+	couponRepo repositories.CouponRepository,
+	categoryRepo repositories.CategoryRepository,
+	productRepo repositories.ProductRepository,
+) PromotionService {
+	return &promotionService{
+		couponRepo:   couponRepo,
+		categoryRepo: categoryRepo,
+		productRepo:  productRepo,
+		now:          time.Now,
+	}
+}
+
+func (s *promotionService) CreateCoupon(ctx context.Context, req *CouponRequest) (*models.Coupon, error) {
+	coupon := &models.Coupon{Active: true}
+	if err := s.fillCoupon(ctx, coupon, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.couponRepo.Create(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (s *promotionService) GetCoupon(ctx context.Context, id uint) (*models.Coupon, error) {
+	return s.couponRepo.GetByID(ctx, id)
+}
+
+func (s *promotionService) GetCoupons(ctx context.Context, page, limit int) ([]models.Coupon, int64, error) {
+	return s.couponRepo.GetAll(ctx, page, limit)
+}
+
+func (s *promotionService) UpdateCoupon(ctx context.Context, id uint, req *CouponRequest) (*models.Coupon, error) {
+	coupon, err := s.couponRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fillCoupon(ctx, coupon, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.couponRepo.Update(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (s *promotionService) DeleteCoupon(ctx context.Context, id uint) error {
+	return s.couponRepo.Delete(ctx, id)
+}
+
+// ApplyCoupon validates the code for this customer and basket and writes the
+// discount onto the order: each eligible item gets its share in Discount and
+// the order gets a breakdown entry plus updated totals. The redemption itself
+// is counted when the order is saved.
+func (s *promotionService) ApplyCoupon(ctx context.Context, customerID uint, code string, order *models.Order) error {
+	coupon, err := s.couponRepo.GetByCode(ctx, code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrCouponNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkValidity(coupon); err != nil {
+		return err
+	}
+
+	if coupon.PerCustomerLimit > 0 {
+		used, err := s.couponRepo.CountCustomerRedemptions(ctx, coupon.ID, customerID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(coupon.PerCustomerLimit) {
+			return models.ErrCouponCustomerLimit
+		}
+	}
+
+	eligible, err := s.eligibility(coupon)
+	if err != nil {
+		return err
+	}
+
+	amount, err := calculateDiscount(coupon, order.OrderItems, eligible)
+	if err != nil {
+		return err
+	}
+
+	couponID := coupon.ID
+	order.Discounts = append(order.Discounts, models.OrderDiscount{
+		CouponID:    &couponID,
+		Code:        coupon.Code,
+		Description: coupon.Description,
+		Amount:      amount,
+	})
+	order.DiscountTotal = roundMoney(order.DiscountTotal + amount)
+	order.Total = roundMoney(order.Subtotal - order.DiscountTotal)
+
+	return nil
+}
+
+func (s *promotionService) checkValidity(coupon *models.Coupon) error {
+	now := s.now()
+	switch {
+	case !coupon.Active:
+		return models.ErrCouponInactive
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return models.ErrCouponNotStarted
+	case coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt):
+		return models.ErrCouponExpired
+	case coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit:
+		return models.ErrCouponExhausted
+	}
+	return nil
+}
+
+// eligibility returns a predicate telling whether an order item qualifies for
+// the coupon. Unrestricted coupons cover every item.
+func (s *promotionService) eligibility(coupon *models.Coupon) (func(item *models.OrderItem) bool, error) {
+	if len(coupon.Categories) == 0 && len(coupon.Products) == 0 {
+		return func(*models.OrderItem) bool { return true }, nil
+	}
+
+	products := make(map[uint]bool, len(coupon.Products))
+	for _, p := range coupon.Products {
+		products[p.ID] = true
+	}
+
+	categories := make(map[uint]bool)
+	for _, c := range coupon.Categories {
+		categories[c.ID] = true
+		descendants, err := s.categoryRepo.GetDescendantIDs(c.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range descendants {
+			categories[id] = true
+		}
+	}
+
+	return func(item *models.OrderItem) bool {
+		return products[item.ProductID] || categories[item.Product.CategoryID]
+	}, nil
+}
+
+// calculateDiscount works out the coupon discount for the items and spreads it
+// over the eligible ones in proportion to their value, in whole cents. The
+// minimum basket is measured against the whole order, not only eligible items.
+func calculateDiscount(coupon *models.Coupon, items []models.OrderItem, eligible func(item *models.OrderItem) bool) (float64, error) {
+	var basket, eligibleTotal float64
+	var lines []int
+	for i := range items {
+		lineTotal := items[i].Price * float64(items[i].Quantity)
+		basket += lineTotal
+		if eligible(&items[i]) {
+			eligibleTotal += lineTotal
+			lines = append(lines, i)
+		}
+	}
+
+	if basket < coupon.MinBasket {
+		return 0, models.ErrCouponMinBasket
+	}
+	if len(lines) == 0 || eligibleTotal <= 0 {
+		return 0, models.ErrCouponNotApplicable
+	}
+
+	var discount float64
+	switch coupon.DiscountType {
+	case models.DiscountTypePercentage:
+		discount = eligibleTotal * coupon.Value / 100
+		if coupon.MaxDiscount > 0 {
+			discount = math.Min(discount, coupon.MaxDiscount)
+		}
+	case models.DiscountTypeFixed:
+		discount = coupon.Value
+	}
+	discount = roundMoney(math.Min(discount, eligibleTotal))
+
+	// The last eligible line absorbs the rounding remainder
+	remaining := discount
+	for n, i := range lines {
+		share := remaining
+		if n < len(lines)-1 {
+			lineTotal := items[i].Price * float64(items[i].Quantity)
+			share = roundMoney(discount * lineTotal / eligibleTotal)
+		}
+		items[i].Discount = roundMoney(items[i].Discount + share)
+		remaining = roundMoney(remaining - share)
+	}
+
+	return discount, nil
+}
+
+func (s *promotionService) fillCoupon(ctx context.Context, coupon *models.Coupon, req *CouponRequest) error {
+	if req.DiscountType == models.DiscountTypePercentage && req.Value > 100 {
+		return models.ErrCouponInvalidValue
+	}
+
+	coupon.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	coupon.Description = req.Description
+	coupon.DiscountType = req.DiscountType
+	coupon.Value = req.Value
+	coupon.MaxDiscount = req.MaxDiscount
+	coupon.MinBasket = req.MinBasket
+	coupon.StartsAt = req.StartsAt
+	coupon.ExpiresAt = req.ExpiresAt
+	coupon.UsageLimit = req.UsageLimit
+	coupon.PerCustomerLimit = req.PerCustomerLimit
+	if req.Active != nil {
+		coupon.Active = *req.Active
+	}
+
+	coupon.Categories = nil
+	for _, id := range req.CategoryIDs {
+		category, err := s.categoryRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		coupon.Categories = append(coupon.Categories, *category)
+	}
+
+	coupon.Products = nil
+	for _, id := range req.ProductIDs {
+		product, err := s.productRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		coupon.Products = append(coupon.Products, *product)
+	}
+
+	return nil
+}
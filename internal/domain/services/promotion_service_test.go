@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func everyItem(*models.OrderItem) bool { return true }
+
+func TestCalculateDiscount_SplitsInCents(t *testing.T) {
+	items := []models.OrderItem{
+		{ProductID: 1, Price: 10, Quantity: 1},
+		{ProductID: 2, Price: 10, Quantity: 1},
+		{ProductID: 3, Price: 10, Quantity: 1},
+	}
+	coupon := &models.Coupon{DiscountType: models.DiscountTypeFixed, Value: 10}
+
+	amount, err := calculateDiscount(coupon, items, everyItem)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, amount)
+	assert.Equal(t, 3.33, items[0].Discount)
+	assert.Equal(t, 3.33, items[1].Discount)
+	assert.Equal(t, 3.34, items[2].Discount)
+}
+
+func TestCalculateDiscount_PercentageCappedAndRestricted(t *testing.T) {
+	items := []models.OrderItem{
+		{ProductID: 1, Price: 200, Quantity: 2},
+		{ProductID: 2, Price: 100, Quantity: 1},
+	}
+	coupon := &models.Coupon{DiscountType: models.DiscountTypePercentage, Value: 50, MaxDiscount: 150}
+	onlyFirst := func(item *models.OrderItem) bool { return item.ProductID == 1 }
+
+	amount, err := calculateDiscount(coupon, items, onlyFirst)
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, amount)
+	assert.Equal(t, 150.0, items[0].Discount)
+	assert.Zero(t, items[1].Discount)
+}
+
+func TestCalculateDiscount_FixedNeverExceedsEligibleValue(t *testing.T) {
+	items := []models.OrderItem{{ProductID: 1, Price: 40, Quantity: 1}}
+	coupon := &models.Coupon{DiscountType: models.DiscountTypeFixed, Value: 100}
+
+	amount, err := calculateDiscount(coupon, items, everyItem)
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, amount)
+}
+
+func TestCalculateDiscount_Rejections(t *testing.T) {
+	items := []models.OrderItem{{ProductID: 1, Price: 40, Quantity: 1}}
+
+	_, err := calculateDiscount(&models.Coupon{DiscountType: models.DiscountTypeFixed, Value: 5, MinBasket: 50}, items, everyItem)
+	assert.ErrorIs(t, err, models.ErrCouponMinBasket)
+
+	none := func(*models.OrderItem) bool { return false }
+	_, err = calculateDiscount(&models.Coupon{DiscountType: models.DiscountTypeFixed, Value: 5}, items, none)
+	assert.ErrorIs(t, err, models.ErrCouponNotApplicable)
+}
+
+func TestApplyCoupon(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	repo := &fakeCouponRepo{coupons: map[string]*models.Coupon{
+		"TENOFF":   {Model: gorm.Model{ID: 1}, Code: "TENOFF", DiscountType: models.DiscountTypePercentage, Value: 10, Active: true},
+		"OFF":      {Model: gorm.Model{ID: 2}, Code: "OFF", DiscountType: models.DiscountTypeFixed, Value: 5, Active: false},
+		"EXPIRED":  {Model: gorm.Model{ID: 3}, Code: "EXPIRED", DiscountType: models.DiscountTypeFixed, Value: 5, Active: true, ExpiresAt: &past},
+		"SOON":     {Model: gorm.Model{ID: 4}, Code: "SOON", DiscountType: models.DiscountTypeFixed, Value: 5, Active: true, StartsAt: &future},
+		"USEDUP":   {Model: gorm.Model{ID: 5}, Code: "USEDUP", DiscountType: models.DiscountTypeFixed, Value: 5, Active: true, UsageLimit: 3, UsedCount: 3},
+		"ONCEEACH": {Model: gorm.Model{ID: 6}, Code: "ONCEEACH", DiscountType: models.DiscountTypeFixed, Value: 5, Active: true, PerCustomerLimit: 1},
+	}}
+	svc := &promotionService{couponRepo: repo, now: func() time.Time { return now }}
+
+	newOrder := func() *models.Order {
+		return &models.Order{
+			Subtotal:   100,
+			Total:      100,
+			OrderItems: []models.OrderItem{{ProductID: 1, Price: 50, Quantity: 2}},
+		}
+	}
+
+	order := newOrder()
+	require.NoError(t, svc.ApplyCoupon(context.Background(), 7, "TENOFF", order))
+	assert.Equal(t, 10.0, order.DiscountTotal)
+	assert.Equal(t, 90.0, order.Total)
+	require.Len(t, order.Discounts, 1)
+	assert.Equal(t, uint(1), *order.Discounts[0].CouponID)
+
+	repo.redemptions = 1
+	cases := map[string]error{
+		"NOPE":     models.ErrCouponNotFound,
+		"OFF":      models.ErrCouponInactive,
+		"EXPIRED":  models.ErrCouponExpired,
+		"SOON":     models.ErrCouponNotStarted,
+		"USEDUP":   models.ErrCouponExhausted,
+		"ONCEEACH": models.ErrCouponCustomerLimit,
+	}
+	for code, want := range cases {
+		order := newOrder()
+		assert.ErrorIs(t, svc.ApplyCoupon(context.Background(), 7, code, order), want, code)
+		assert.Equal(t, 100.0, order.Total, code)
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type ReturnService interface {
+	RequestReturn(ctx context.Context, customerID, orderID uint, req *ReturnCreateRequest) (*models.ReturnRequest, error)
+	GetOrderReturns(ctx context.Context, customerID, orderID uint) ([]models.ReturnRequest, error)
+	GetReturns(ctx context.Context, status models.ReturnStatus, page, limit int) ([]models.ReturnRequest, int64, error)
+	ApproveReturn(ctx context.Context, id uint, req *ReturnReviewRequest) (*models.ReturnRequest, error)
+	RejectReturn(ctx context.Context, id uint, req *ReturnReviewRequest) (*models.ReturnRequest, error)
+	ReceiveReturn(ctx context.Context, id uint) (*models.ReturnRequest, error)
+}
+
+type ReturnCreateRequest struct {
+	Reason string              `json:"reason" binding:"required"`
+	Items  []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ReturnItemRequest struct {
+	OrderItemID uint   `json:"order_item_id" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+	Reason      string `json:"reason" binding:"max=255"`
+}
+
+type ReturnReviewRequest struct {
+	Note string `json:"note"`
+}
+
+type returnService struct {
+	returnRepo repositories.ReturnRepository
+	orderRepo  repositories.OrderRepository
+	payments   PaymentService
+	notifier   NotificationService
+}
+
+func NewReturnService(
+	returnRepo repositories.ReturnRepository,
+	orderRepo repositories.OrderRepository,
+	payments PaymentService,
+	notifier NotificationService,
+) ReturnService {
+	return &returnService{
+		returnRepo: returnRepo,
+		orderRepo:  orderRepo,
+		payments:   payments,
+		notifier:   notifier,
+	}
+}
+
+// RequestReturn opens a return for some or all of the lines of a shipped or
+// completed order.
+func (s *returnService) RequestReturn(ctx context.Context, customerID, orderID uint, req *ReturnCreateRequest) (*models.ReturnRequest, error) {
+	order, err := s.customerOrder(ctx, customerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !order.Status.Returnable() {
+		return nil, models.ErrOrderNotReturnable
+	}
+
+	rma := &models.ReturnRequest{
+		OrderID:    order.ID,
+		CustomerID: customerID,
+		Status:     models.ReturnStatusRequested,
+		Reason:     req.Reason,
+	}
+	for _, item := range req.Items {
+		rma.Items = append(rma.Items, models.ReturnItem{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Reason:      item.Reason,
+		})
+	}
+
+	if err := s.returnRepo.Create(ctx, rma); err != nil {
+		return nil, err
+	}
+
+	return s.reloadAndNotify(ctx, order, rma.ID)
+}
+
+func (s *returnService) GetOrderReturns(ctx context.Context, customerID, orderID uint) ([]models.ReturnRequest, error) {
+	if _, err := s.customerOrder(ctx, customerID, orderID); err != nil {
+		return nil, err
+	}
+	return s.returnRepo.GetByOrderID(ctx, orderID)
+}
+
+func (s *returnService) GetReturns(ctx context.Context, status models.ReturnStatus, page, limit int) ([]models.ReturnRequest, int64, error) {
+	return s.returnRepo.GetAll(ctx, status, page, limit)
+}
+
+func (s *returnService) ApproveReturn(ctx context.Context, id uint, req *ReturnReviewRequest) (*models.ReturnRequest, error) {
+	return s.review(ctx, id, models.ReturnStatusApproved, req.Note)
+}
+
+func (s *returnService) RejectReturn(ctx context.Context, id uint, req *ReturnReviewRequest) (*models.ReturnRequest, error) {
+	return s.review(ctx, id, models.ReturnStatusRejected, req.Note)
+}
+
+// ReceiveReturn confirms the goods of an approved return arrived. The items
+// go back in stock and the customer is refunded what they paid for them,
+// after discounts and including VAT; delivery is not refunded. A failed
+// refund is logged for staff to retry and does not undo the receipt.
+func (s *returnService) ReceiveReturn(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	rma, err := s.getReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rma.Status != models.ReturnStatusApproved {
+		return nil, models.ErrReturnStatus
+	}
+
+	amount := returnRefundAmount(rma)
+	received, err := s.returnRepo.MarkReceived(ctx, id, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !received {
+		return nil, models.ErrReturnStatus
+	}
+
+	if amount > 0 {
+		_, err := s.payments.RefundOrder(ctx, rma.OrderID, &RefundCreateRequest{
+			Amount: amount,
+			Reason: fmt.Sprintf("Return #%d", rma.ID),
+		})
+		if err != nil && !errors.Is(err, models.ErrNothingToRefund) {
+			log.Error().Err(err).Uint("returnID", rma.ID).Float64("amount", amount).Msg("Failed to refund received return")
+		}
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, rma.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reloadAndNotify(ctx, order, id)
+}
+
+func (s *returnService) review(ctx context.Context, id uint, status models.ReturnStatus, note string) (*models.ReturnRequest, error) {
+	rma, err := s.getReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewed, err := s.returnRepo.Review(ctx, id, status, note)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewed {
+		return nil, models.ErrReturnStatus
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, rma.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reloadAndNotify(ctx, order, id)
+}
+
+// reloadAndNotify fetches the return in its new state and tells the customer.
+func (s *returnService) reloadAndNotify(ctx context.Context, order *models.Order, id uint) (*models.ReturnRequest, error) {
+	rma, err := s.returnRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notifier.SendReturnUpdate(order, rma); err != nil {
+		log.Error().Err(err).Uint("returnID", rma.ID).Msg("Failed to send return notification")
+	}
+
+	return rma, nil
+}
+
+func (s *returnService) getReturn(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	rma, err := s.returnRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrReturnNotFound
+	}
+	return rma, err
+}
+
+func (s *returnService) customerOrder(ctx context.Context, customerID, orderID uint) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && order.CustomerID != customerID) {
+		return nil, models.ErrOrderNotFound
+	}
+	return order, err
+}
+
+// returnRefundAmount is the gross amount paid for the returned units. Each
+// line's gross amount is spread evenly over its units.
+func returnRefundAmount(rma *models.ReturnRequest) float64 {
+	var amount float64
+	for _, item := range rma.Items {
+		line := item.OrderItem
+		if line.Quantity == 0 {
+			continue
+		}
+		amount += line.GrossAmount * float64(item.Quantity) / float64(line.Quantity)
+	}
+	return roundMoney(amount)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/pkg/payments"
+)
+
+func TestReturnService_ApproveReceiveAndRefund(t *testing.T) {
+	paymentSvc, _, paymentRepo, orderRepo := newTestPaymentService()
+	notifier := &fakeNotifier{}
+	returnRepo := &fakeReturnRepo{orders: orderRepo}
+	s := NewReturnService(returnRepo, orderRepo, paymentSvc, notifier)
+	ctx := context.Background()
+
+	payment, err := paymentSvc.InitiatePayment(ctx, 7, 1, "memory", &PaymentInitiateRequest{})
+	require.NoError(t, err)
+	require.NoError(t, paymentSvc.HandlePaymentResult(ctx, "memory", &payments.Result{
+		Reference: payment.ProviderReference, Status: payments.StatusSucceeded, ProviderTransactionID: "QWE123",
+	}))
+
+	order := orderRepo.orders[1]
+	order.OrderItems = []models.OrderItem{
+		{Model: gorm.Model{ID: 11}, Quantity: 3, GrossAmount: 696},
+		{Model: gorm.Model{ID: 12}, Quantity: 1, GrossAmount: 304},
+	}
+
+	_, err = s.RequestReturn(ctx, 7, 1, &ReturnCreateRequest{Reason: "wrong size", Items: []ReturnItemRequest{{OrderItemID: 11, Quantity: 1}}})
+	assert.ErrorIs(t, err, models.ErrOrderNotReturnable, "paid but not shipped")
+
+	order.Status = models.OrderStatusCompleted
+	rma, err := s.RequestReturn(ctx, 7, 1, &ReturnCreateRequest{Reason: "wrong size", Items: []ReturnItemRequest{{OrderItemID: 11, Quantity: 1}}})
+	require.NoError(t, err)
+	assert.Equal(t, models.ReturnStatusRequested, rma.Status)
+
+	_, err = s.ReceiveReturn(ctx, rma.ID)
+	assert.ErrorIs(t, err, models.ErrReturnStatus, "goods can only be received for approved returns")
+
+	_, err = s.ApproveReturn(ctx, rma.ID, &ReturnReviewRequest{Note: "send it back"})
+	require.NoError(t, err)
+	_, err = s.RejectReturn(ctx, rma.ID, &ReturnReviewRequest{})
+	assert.ErrorIs(t, err, models.ErrReturnStatus)
+
+	rma, err = s.ReceiveReturn(ctx, rma.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReturnStatusReceived, rma.Status)
+	assert.Equal(t, 232.0, rma.RefundAmount, "one of three units")
+	assert.Equal(t, 232.0, paymentRepo.payments[0].RefundedAmount)
+
+	assert.Equal(t, []models.ReturnStatus{
+		models.ReturnStatusRequested, models.ReturnStatusApproved, models.ReturnStatusReceived,
+	}, notifier.returns)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type ReviewService interface {
+	CreateReview(ctx context.Context, customerID, productID uint, req *ReviewCreateRequest) (*models.Review, error)
+	GetProductReviews(ctx context.Context, productID uint, page, limit int) ([]models.Review, int64, error)
+	GetCustomerReviews(ctx context.Context, customerID uint) ([]models.Review, error)
+	DeleteReview(ctx context.Context, customerID, id uint) error
+	MarkHelpful(ctx context.Context, customerID, id uint) (*models.Review, error)
+
+	GetReviews(ctx context.Context, status models.ReviewStatus, page, limit int) ([]models.Review, int64, error)
+	ApproveReview(ctx context.Context, id uint, req *ReviewModerationRequest) (*models.Review, error)
+	RejectReview(ctx context.Context, id uint, req *ReviewModerationRequest) (*models.Review, error)
+}
+
+type ReviewCreateRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"max=120"`
+	Body   string `json:"body" binding:"max=5000"`
+}
+
+type ReviewModerationRequest struct {
+	Note string `json:"note"`
+}
+
+type reviewService struct {
+	reviewRepo  repositories.ReviewRepository
+	productRepo repositories.ProductRepository
+	orderRepo   repositories.OrderRepository
+}
+
+func NewReviewService(
+	reviewRepo repositories.ReviewRepository,
+	productRepo repositories.ProductRepository,
+	orderRepo repositories.OrderRepository,
+) ReviewService {
+	return &reviewService{
+		reviewRepo:  reviewRepo,
+		productRepo: productRepo,
+		orderRepo:   orderRepo,
+	}
+}
+
+// CreateReview submits a review for moderation. Only customers with a
+// completed order containing the product may review it, once.
+func (s *reviewService) CreateReview(ctx context.Context, customerID, productID uint, req *ReviewCreateRequest) (*models.Review, error) {
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	purchased, err := s.orderRepo.HasPurchased(ctx, customerID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !purchased {
+		return nil, models.ErrNotVerifiedPurchaser
+	}
+
+	review := &models.Review{
+		ProductID:  productID,
+		CustomerID: customerID,
+		Rating:     req.Rating,
+		Title:      req.Title,
+		Body:       req.Body,
+		Status:     models.ReviewStatusPending,
+	}
+	if err := s.reviewRepo.Create(ctx, review); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, models.ErrReviewExists
+		}
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *reviewService) GetProductReviews(ctx context.Context, productID uint, page, limit int) ([]models.Review, int64, error) {
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, 0, err
+	}
+	return s.reviewRepo.GetByProduct(ctx, productID, page, limit)
+}
+
+func (s *reviewService) GetCustomerReviews(ctx context.Context, customerID uint) ([]models.Review, error) {
+	return s.reviewRepo.GetByCustomer(ctx, customerID)
+}
+
+// DeleteReview withdraws the customer's own review, so they can write it
+// again.
+func (s *reviewService) DeleteReview(ctx context.Context, customerID, id uint) error {
+	review, err := s.getReview(ctx, id)
+	if err != nil {
+		return err
+	}
+	if review.CustomerID != customerID {
+		return models.ErrReviewNotFound
+	}
+	return s.reviewRepo.Delete(ctx, id)
+}
+
+// MarkHelpful counts the customer's helpful vote on an approved review. Each
+// customer votes once, and not on their own review.
+func (s *reviewService) MarkHelpful(ctx context.Context, customerID, id uint) (*models.Review, error) {
+	review, err := s.getReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != models.ReviewStatusApproved {
+		return nil, models.ErrReviewNotFound
+	}
+	if review.CustomerID == customerID {
+		return nil, models.ErrOwnReview
+	}
+
+	added, err := s.reviewRepo.AddVote(ctx, id, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return nil, models.ErrAlreadyVoted
+	}
+	return s.getReview(ctx, id)
+}
+
+func (s *reviewService) GetReviews(ctx context.Context, status models.ReviewStatus, page, limit int) ([]models.Review, int64, error) {
+	return s.reviewRepo.GetByStatus(ctx, status, page, limit)
+}
+
+// ApproveReview publishes a pending review, or one rejected earlier, and adds
+// it to the product's rating.
+func (s *reviewService) ApproveReview(ctx context.Context, id uint, req *ReviewModerationRequest) (*models.Review, error) {
+	return s.moderate(ctx, id, models.ReviewStatusApproved, req.Note)
+}
+
+// RejectReview hides a pending review, or takes down an approved one and
+// removes it from the product's rating.
+func (s *reviewService) RejectReview(ctx context.Context, id uint, req *ReviewModerationRequest) (*models.Review, error) {
+	return s.moderate(ctx, id, models.ReviewStatusRejected, req.Note)
+}
+
+func (s *reviewService) moderate(ctx context.Context, id uint, status models.ReviewStatus, note string) (*models.Review, error) {
+	moderated, err := s.reviewRepo.Moderate(ctx, id, status, note)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !moderated {
+		return nil, models.ErrReviewStatus
+	}
+	return s.getReview(ctx, id)
+}
+
+func (s *reviewService) getReview(ctx context.Context, id uint) (*models.Review, error) {
+	review, err := s.reviewRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrReviewNotFound
+	}
+	return review, err
+}
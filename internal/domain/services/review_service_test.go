@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestReviewService_VerifiedPurchasersModerationAndVotes(t *testing.T) {
+	orderRepo := newFakeOrderRepo()
+	orderRepo.orders[1].Status = models.OrderStatusCompleted
+	orderRepo.orders[1].OrderItems = []models.OrderItem{{ProductID: 1, Quantity: 1}}
+	orderRepo.orders[2] = &models.Order{Model: gorm.Model{ID: 2}, CustomerID: 8, Status: models.OrderStatusPaid,
+		OrderItems: []models.OrderItem{{ProductID: 1, Quantity: 1}}}
+	products := &fakeProductRepo{products: map[uint]*models.Product{1: {Model: gorm.Model{ID: 1}, Name: "Tea"}}}
+	repo := &fakeReviewRepo{reviews: map[uint]*models.Review{}, votes: map[[2]uint]bool{}}
+	s := &reviewService{reviewRepo: repo, productRepo: products, orderRepo: orderRepo}
+	ctx := context.Background()
+
+	_, err := s.CreateReview(ctx, 8, 1, &ReviewCreateRequest{Rating: 5})
+	assert.ErrorIs(t, err, models.ErrNotVerifiedPurchaser, "the order has not been completed")
+
+	review, err := s.CreateReview(ctx, 7, 1, &ReviewCreateRequest{Rating: 4, Title: "Good tea"})
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewStatusPending, review.Status)
+
+	_, err = s.CreateReview(ctx, 7, 1, &ReviewCreateRequest{Rating: 5})
+	assert.ErrorIs(t, err, models.ErrReviewExists)
+
+	_, err = s.MarkHelpful(ctx, 8, review.ID)
+	assert.ErrorIs(t, err, models.ErrReviewNotFound, "pending reviews are not visible")
+
+	_, err = s.ApproveReview(ctx, review.ID, &ReviewModerationRequest{})
+	require.NoError(t, err)
+	_, err = s.ApproveReview(ctx, review.ID, &ReviewModerationRequest{})
+	assert.ErrorIs(t, err, models.ErrReviewStatus)
+
+	_, err = s.MarkHelpful(ctx, 7, review.ID)
+	assert.ErrorIs(t, err, models.ErrOwnReview)
+	voted, err := s.MarkHelpful(ctx, 8, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, voted.HelpfulCount)
+	_, err = s.MarkHelpful(ctx, 8, review.ID)
+	assert.ErrorIs(t, err, models.ErrAlreadyVoted)
+
+	assert.ErrorIs(t, s.DeleteReview(ctx, 8, review.ID), models.ErrReviewNotFound, "only the author can delete")
+}
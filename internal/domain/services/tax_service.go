@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type TaxService interface {
+	CreateTaxRate(ctx context.Context, req *TaxRateRequest) (*models.TaxRate, error)
+	GetTaxRates(ctx context.Context) ([]models.TaxRate, error)
+	DeleteTaxRate(ctx context.Context, id uint) error
+	ApplyTax(ctx context.Context, order *models.Order) error
+}
+
+// TaxRateRequest adds a rate version. Leave CategoryID empty to set the
+// store-wide default, and EffectiveFrom empty for a rate that applies now.
+type TaxRateRequest struct {
+	CategoryID    *uint               `json:"category_id"`
+	Treatment     models.TaxTreatment `json:"treatment" binding:"required,oneof=standard zero_rated exempt"`
+	Rate          float64             `json:"rate" binding:"gte=0,lte=100"`
+	EffectiveFrom *time.Time          `json:"effective_from"`
+}
+
+type taxService struct {
+	taxRateRepo  repositories.TaxRateRepository
+	categoryRepo repositories.CategoryRepository
+	now          func() time.Time
+}
+
+func NewTaxService(taxRateRepo repositories.TaxRateRepository, categoryRepo repositories.CategoryRepository) TaxService {
+	return &taxService{
+		taxRateRepo:  taxRateRepo,
+		categoryRepo: categoryRepo,
+		now:          time.Now,
+	}
+}
+
+func (s *taxService) CreateTaxRate(ctx context.Context, req *TaxRateRequest) (*models.TaxRate, error) {
+	if req.Treatment != models.TaxTreatmentStandard && req.Rate != 0 {
+		return nil, models.ErrTaxRateTreatment
+	}
+
+	if req.CategoryID != nil {
+		if _, err := s.categoryRepo.GetByID(*req.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	rate := &models.TaxRate{
+		CategoryID:    req.CategoryID,
+		Treatment:     req.Treatment,
+		Rate:          req.Rate,
+		EffectiveFrom: s.now(),
+	}
+	if req.EffectiveFrom != nil {
+		rate.EffectiveFrom = *req.EffectiveFrom
+	}
+
+	if err := s.taxRateRepo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (s *taxService) GetTaxRates(ctx context.Context) ([]models.TaxRate, error) {
+	return s.taxRateRepo.GetAll(ctx)
+}
+
+// DeleteTaxRate withdraws a scheduled rate. Rates already in effect may have
+// been charged on orders, so they can only be superseded by a newer version.
+func (s *taxService) DeleteTaxRate(ctx context.Context, id uint) error {
+	rate, err := s.taxRateRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrTaxRateNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if !rate.EffectiveFrom.After(s.now()) {
+		return models.ErrTaxRateInEffect
+	}
+
+	return s.taxRateRepo.Delete(ctx, id)
+}
+
+// ApplyTax charges VAT on each order line after discounts, at the rate in
+// effect now for the product's category, and fills in the line and order
+// net/tax/gross amounts. Order items need Product loaded for its category.
+func (s *taxService) ApplyTax(ctx context.Context, order *models.Order) error {
+	at := s.now()
+	resolved := make(map[uint]models.TaxRate)
+
+	var netTotal, taxTotal float64
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+
+		categoryID := item.Product.CategoryID
+		rate, ok := resolved[categoryID]
+		if !ok {
+			var err error
+			if rate, err = s.effectiveRate(ctx, categoryID, at); err != nil {
+				return err
+			}
+			resolved[categoryID] = rate
+		}
+
+		item.TaxTreatment = rate.Treatment
+		item.TaxRate = rate.Rate
+		item.NetAmount = roundMoney(item.Price*float64(item.Quantity) - item.Discount)
+		item.TaxAmount = roundMoney(item.NetAmount * rate.Rate / 100)
+		item.GrossAmount = roundMoney(item.NetAmount + item.TaxAmount)
+
+		netTotal += item.NetAmount
+		taxTotal += item.TaxAmount
+	}
+
+	order.NetTotal = roundMoney(netTotal)
+	order.TaxTotal = roundMoney(taxTotal)
+	order.Total = roundMoney(order.NetTotal + order.TaxTotal)
+
+	return nil
+}
+
+func (s *taxService) effectiveRate(ctx context.Context, categoryID uint, at time.Time) (models.TaxRate, error) {
+	var ancestors []uint
+	if categoryID != 0 {
+		var err error
+		if ancestors, err = s.categoryRepo.GetAncestorIDs(categoryID); err != nil {
+			return models.TaxRate{}, err
+		}
+	}
+
+	rates, err := s.taxRateRepo.GetEffective(ctx, ancestors, at)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+
+	return resolveTaxRate(ancestors, rates), nil
+}
+
+// resolveTaxRate picks the rate for the first category in ancestors (nearest
+// first) that has one, else the store-wide default, else the standard rate.
+// rates must be sorted newest first, so the first row per category is the
+// version in effect.
+func resolveTaxRate(ancestors []uint, rates []models.TaxRate) models.TaxRate {
+	var fallback *models.TaxRate
+	current := make(map[uint]models.TaxRate)
+	for i := range rates {
+		if rates[i].CategoryID == nil {
+			if fallback == nil {
+				fallback = &rates[i]
+			}
+			continue
+		}
+		if _, seen := current[*rates[i].CategoryID]; !seen {
+			current[*rates[i].CategoryID] = rates[i]
+		}
+	}
+
+	for _, id := range ancestors {
+		if rate, ok := current[id]; ok {
+			return rate
+		}
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return models.TaxRate{Treatment: models.TaxTreatmentStandard, Rate: models.StandardVATRate}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestApplyTax(t *testing.T) {
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jul := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// 1 Food > 2 Fresh produce > 3 Vegetables; 4 Electronics; 5 Books
+	categories := &fakeCategoryTree{parents: map[uint]uint{2: 1, 3: 2}}
+	rates := &fakeTaxRateRepo{rates: []models.TaxRate{
+		{CategoryID: nil, Treatment: models.TaxTreatmentStandard, Rate: 16, EffectiveFrom: jan},
+		{CategoryID: uintPtr(2), Treatment: models.TaxTreatmentZeroRated, Rate: 0, EffectiveFrom: jan},
+		{CategoryID: uintPtr(5), Treatment: models.TaxTreatmentExempt, Rate: 0, EffectiveFrom: jan},
+		// Scheduled change of the default rate
+		{CategoryID: nil, Treatment: models.TaxTreatmentStandard, Rate: 14, EffectiveFrom: jul},
+	}}
+	now := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+	svc := &taxService{taxRateRepo: rates, categoryRepo: categories, now: func() time.Time { return now }}
+
+	newOrder := func() *models.Order {
+		return &models.Order{
+			Subtotal:      400,
+			DiscountTotal: 10,
+			OrderItems: []models.OrderItem{
+				{ProductID: 1, Product: models.Product{CategoryID: 3}, Price: 50, Quantity: 2},
+				{ProductID: 2, Product: models.Product{CategoryID: 4}, Price: 100, Quantity: 2, Discount: 10},
+				{ProductID: 3, Product: models.Product{CategoryID: 5}, Price: 100, Quantity: 1},
+			},
+		}
+	}
+
+	order := newOrder()
+	require.NoError(t, svc.ApplyTax(context.Background(), order))
+
+	veg, phone, book := order.OrderItems[0], order.OrderItems[1], order.OrderItems[2]
+	assert.Equal(t, models.TaxTreatmentZeroRated, veg.TaxTreatment, "inherited from the grandparent category")
+	assert.Equal(t, 100.0, veg.GrossAmount)
+
+	assert.Equal(t, models.TaxTreatmentStandard, phone.TaxTreatment)
+	assert.Equal(t, 16.0, phone.TaxRate)
+	assert.Equal(t, 190.0, phone.NetAmount, "VAT is charged after the discount")
+	assert.Equal(t, 30.4, phone.TaxAmount)
+	assert.Equal(t, 220.4, phone.GrossAmount)
+
+	assert.Equal(t, models.TaxTreatmentExempt, book.TaxTreatment)
+	assert.Zero(t, book.TaxAmount)
+
+	assert.Equal(t, 390.0, order.NetTotal)
+	assert.Equal(t, 30.4, order.TaxTotal)
+	assert.Equal(t, 420.4, order.Total)
+
+	// After the scheduled change new orders pick up the new rate
+	now = jul.Add(time.Hour)
+	order = newOrder()
+	require.NoError(t, svc.ApplyTax(context.Background(), order))
+	assert.Equal(t, 14.0, order.OrderItems[1].TaxRate)
+	assert.Equal(t, 26.6, order.TaxTotal)
+}
+
+func TestResolveTaxRate_FallsBackToStandardRate(t *testing.T) {
+	rate := resolveTaxRate([]uint{7, 1}, nil)
+	assert.Equal(t, models.TaxTreatmentStandard, rate.Treatment)
+	assert.Equal(t, models.StandardVATRate, rate.Rate)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/repositories"
+)
+
+type WishlistService interface {
+	GetWishlist(ctx context.Context, customerID uint) ([]models.WishlistItem, error)
+	AddToWishlist(ctx context.Context, customerID uint, req *WishlistItemRequest) ([]models.WishlistItem, error)
+	RemoveFromWishlist(ctx context.Context, customerID, productID uint) error
+
+	GetStockAlerts(ctx context.Context, customerID uint) ([]models.StockAlert, error)
+	SubscribeStockAlert(ctx context.Context, customerID uint, req *StockAlertRequest) (*models.StockAlert, error)
+	UnsubscribeStockAlert(ctx context.Context, customerID, productID uint) error
+	NotifyBackInStock(ctx context.Context, productID uint) (int, error)
+}
+
+type WishlistItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+}
+
+// StockAlertRequest.Channel is "sms" or "email" (the default).
+type StockAlertRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Channel   string `json:"channel" binding:"omitempty,oneof=sms email"`
+}
+
+type wishlistService struct {
+	wishlistRepo repositories.WishlistRepository
+	productRepo  repositories.ProductRepository
+	notifier     NotificationService
+	now          func() time.Time
+}
+
+func NewWishlistService(
+	wishlistRepo repositories.WishlistRepository,
+	productRepo repositories.ProductRepository,
+	notifier NotificationService,
+) WishlistService {
+	return &wishlistService{
+		wishlistRepo: wishlistRepo,
+		productRepo:  productRepo,
+		notifier:     notifier,
+		now:          time.Now,
+	}
+}
+
+func (s *wishlistService) GetWishlist(ctx context.Context, customerID uint) ([]models.WishlistItem, error) {
+	return s.wishlistRepo.GetItems(ctx, customerID)
+}
+
+func (s *wishlistService) AddToWishlist(ctx context.Context, customerID uint, req *WishlistItemRequest) ([]models.WishlistItem, error) {
+	if _, err := s.productRepo.GetByID(ctx, req.ProductID); err != nil {
+		return nil, err
+	}
+
+	if err := s.wishlistRepo.AddItem(ctx, &models.WishlistItem{
+		CustomerID: customerID,
+		ProductID:  req.ProductID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.wishlistRepo.GetItems(ctx, customerID)
+}
+
+func (s *wishlistService) RemoveFromWishlist(ctx context.Context, customerID, productID uint) error {
+	removed, err := s.wishlistRepo.RemoveItem(ctx, customerID, productID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return models.ErrWishlistItemNotFound
+	}
+	return nil
+}
+
+func (s *wishlistService) GetStockAlerts(ctx context.Context, customerID uint) ([]models.StockAlert, error) {
+	return s.wishlistRepo.GetAlerts(ctx, customerID)
+}
+
+// SubscribeStockAlert opts the customer in to one message when the product
+// comes back in stock. Only products that are out of stock can be watched.
+func (s *wishlistService) SubscribeStockAlert(ctx context.Context, customerID uint, req *StockAlertRequest) (*models.StockAlert, error) {
+	product, err := s.productRepo.GetByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.OutOfStock() {
+		return nil, models.ErrProductInStock
+	}
+
+	channel := req.Channel
+	if channel == "" {
+		channel = models.AlertChannelEmail
+	}
+
+	alert := &models.StockAlert{
+		CustomerID: customerID,
+		ProductID:  product.ID,
+		Channel:    channel,
+	}
+	if err := s.wishlistRepo.SubscribeAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+	alert.Product = *product
+	return alert, nil
+}
+
+func (s *wishlistService) UnsubscribeStockAlert(ctx context.Context, customerID, productID uint) error {
+	removed, err := s.wishlistRepo.UnsubscribeAlert(ctx, customerID, productID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return models.ErrStockAlertNotFound
+	}
+	return nil
+}
+
+// NotifyBackInStock sends the alerts that are due for a product that is back
+// in stock, or for every such product when productID is zero, and returns how
+// many went out. Each alert is claimed before its message is sent, so
+// concurrent runs never send it twice; a failed send is released to be tried
+// again on the next run.
+func (s *wishlistService) NotifyBackInStock(ctx context.Context, productID uint) (int, error) {
+	alerts, err := s.wishlistRepo.GetDueAlerts(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range alerts {
+		alert := &alerts[i]
+		claimed, err := s.wishlistRepo.ClaimAlert(ctx, alert.ID, s.now())
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.notifier.SendBackInStock(alert); err != nil {
+			log.Error().Err(err).Uint("alertID", alert.ID).Uint("productID", alert.ProductID).Msg("Failed to send back-in-stock alert")
+			if err := s.wishlistRepo.ReleaseAlert(ctx, alert.ID); err != nil {
+				log.Error().Err(err).Uint("alertID", alert.ID).Msg("Failed to release back-in-stock alert")
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+)
+
+func TestWishlistService_BackInStockAlertsFireOnce(t *testing.T) {
+	products := &fakeProductRepo{products: map[uint]*models.Product{
+		1: {Model: gorm.Model{ID: 1}, Name: "Tea", Stock: intPtr(0)},
+		2: {Model: gorm.Model{ID: 2}, Name: "Coffee"},
+	}}
+	repo := &fakeWishlistRepo{products: products}
+	notifier := &fakeNotifier{}
+	s := &wishlistService{wishlistRepo: repo, productRepo: products, notifier: notifier, now: time.Now}
+	ctx := context.Background()
+
+	_, err := s.SubscribeStockAlert(ctx, 7, &StockAlertRequest{ProductID: 2})
+	assert.ErrorIs(t, err, models.ErrProductInStock)
+
+	alert, err := s.SubscribeStockAlert(ctx, 7, &StockAlertRequest{ProductID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertChannelEmail, alert.Channel, "email by default")
+	_, err = s.SubscribeStockAlert(ctx, 8, &StockAlertRequest{ProductID: 1, Channel: models.AlertChannelSMS})
+	require.NoError(t, err)
+
+	sent, err := s.NotifyBackInStock(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "still out of stock")
+
+	products.products[1].Stock = intPtr(3)
+	notifier.failBackInStock = true
+	sent, err = s.NotifyBackInStock(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Nil(t, repo.alerts[0].NotifiedAt, "failed sends are retried later")
+
+	notifier.failBackInStock = false
+	sent, err = s.NotifyBackInStock(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{models.AlertChannelEmail, models.AlertChannelSMS}, notifier.backInStock)
+
+	sent, err = s.NotifyBackInStock(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "each alert is sent once")
+}
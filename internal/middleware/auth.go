@@ -1,17 +1,49 @@
 package middleware
 
 import (
+	stderrors "errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/services"
 	"github.com/Mutonya/Savanah/internal/utils/errors"
 )
 
+// apiKeyRoutes lists the endpoints service accounts can call with an API key
+// and the scope each one needs, keyed by method and route. API keys are
+// refused on every other endpoint.
+var apiKeyRoutes = map[string]string{
+	"GET /api/v1/products":                     models.ScopeProductsRead,
+	"GET /api/v1/products/:id":                 models.ScopeProductsRead,
+	"POST /api/v1/products":                    models.ScopeProductsWrite,
+	"PUT /api/v1/products/:id":                 models.ScopeProductsWrite,
+	"DELETE /api/v1/products/:id":              models.ScopeProductsWrite,
+	"GET /api/v1/categories":                   models.ScopeCategoriesRead,
+	"GET /api/v1/categories/:id":               models.ScopeCategoriesRead,
+	"GET /api/v1/categories/:id/products":      models.ScopeCategoriesRead,
+	"GET /api/v1/categories/:id/average-price": models.ScopeCategoriesRead,
+	"POST /api/v1/categories":                  models.ScopeCategoriesWrite,
+	"PUT /api/v1/categories/:id":               models.ScopeCategoriesWrite,
+	"DELETE /api/v1/categories/:id":            models.ScopeCategoriesWrite,
+	"GET /api/v1/admin/orders":                 models.ScopeOrdersRead,
+	"GET /api/v1/admin/orders/export":          models.ScopeOrdersRead,
+	"POST /api/v1/admin/orders/status":         models.ScopeOrdersWrite,
+	"PUT /api/v1/orders/:id/status":            models.ScopeOrdersWrite,
+}
+
+// AuthMiddleware accepts either a customer session as a bearer token or a
+// service account's X-API-Key. A session puts customerID and customerRole on
+// the context; an API key puts apiKeyID and the service role.
 func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		if key := ctx.GetHeader("X-API-Key"); key != "" {
+			authenticateAPIKey(ctx, authService, key)
+			return
+		}
+
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errors.NewAPIError(http.StatusUnauthorized, "authorization header is required"))
@@ -27,11 +59,68 @@ func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 		token := parts[1]
 		customer, err := authService.ValidateToken(ctx.Request.Context(), token)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errors.NewAPIError(http.StatusUnauthorized, "invalid token"))
+			message := "invalid token"
+			if stderrors.Is(err, models.ErrSessionExpired) {
+				message = err.Error()
+			}
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errors.NewAPIError(http.StatusUnauthorized, message))
+			return
+		}
+		if customer.Suspended() {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errors.NewAPIError(http.StatusForbidden, "account is suspended"))
 			return
 		}
 
 		ctx.Set("customerID", customer.ID)
+		ctx.Set("customerRole", customer.Role)
 		ctx.Next()
 	}
 }
+
+func authenticateAPIKey(ctx *gin.Context, authService services.AuthService, key string) {
+	apiKey, err := authService.ValidateAPIKey(ctx.Request.Context(), key)
+	if err != nil {
+		message := "invalid API key"
+		if stderrors.Is(err, models.ErrAPIKeyExpired) || stderrors.Is(err, models.ErrAPIKeyRevoked) {
+			message = err.Error()
+		}
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errors.NewAPIError(http.StatusUnauthorized, message))
+		return
+	}
+
+	scope, ok := apiKeyRoutes[ctx.Request.Method+" "+ctx.FullPath()]
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errors.NewAPIError(http.StatusForbidden, "endpoint is not available to API keys"))
+		return
+	}
+	if !apiKey.HasScope(scope) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errors.NewAPIError(http.StatusForbidden, "API key is missing the "+scope+" scope"))
+		return
+	}
+
+	ctx.Set("apiKeyID", apiKey.ID)
+	ctx.Set("customerRole", models.RoleService)
+	ctx.Next()
+}
+
+// RequireRole lets the request through only when AuthMiddleware has put one of
+// the given roles on the context. It must run after AuthMiddleware. API keys
+// pass, as AuthMiddleware has already checked their scope for the endpoint.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := ctx.Get("apiKeyID"); ok {
+			ctx.Next()
+			return
+		}
+
+		role := ctx.GetString("customerRole")
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errors.NewAPIError(http.StatusForbidden, "insufficient permissions"))
+	}
+}
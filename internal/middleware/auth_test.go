@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/Mutonya/Savanah/internal/domain/models"
+	"github.com/Mutonya/Savanah/internal/domain/services"
+)
+
+// fakeAuthService resolves each bearer token to its own customer.
+type fakeAuthService struct {
+	services.AuthService
+	sessions map[string]*models.Customer
+}
+
+func (s *fakeAuthService) ValidateToken(ctx context.Context, token string) (*models.Customer, error) {
+	customer, ok := s.sessions[token]
+	if !ok {
+		return nil, models.ErrSessionInvalid
+	}
+	return customer, nil
+}
+
+func TestAuthMiddleware_SuspendsOnlyTheSuspendedCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	suspendedAt := time.Now()
+	auth := &fakeAuthService{sessions: map[string]*models.Customer{
+		"amina-token":  {Model: gorm.Model{ID: 7}, Role: models.RoleCustomer},
+		"baraka-token": {Model: gorm.Model{ID: 8}, Role: models.RoleCustomer, SuspendedAt: &suspendedAt},
+	}}
+	router := gin.New()
+	router.GET("/me", AuthMiddleware(auth), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"customer_id": ctx.GetUint("customerID")})
+	})
+
+	call := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("amina-token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"customer_id": 7}`, rec.Body.String(), "the caller is the token's customer")
+
+	rec = call("baraka-token")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "account is suspended")
+
+	assert.Equal(t, http.StatusUnauthorized, call("made-up").Code)
+
+	// A reactivated account gets in again, as the same customer
+	auth.sessions["baraka-token"].SuspendedAt = nil
+	rec = call("baraka-token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"customer_id": 8}`, rec.Body.String())
+}
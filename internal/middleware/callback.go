@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Mutonya/Savanah/internal/utils/errors"
+)
+
+// CallbackGuard protects unauthenticated provider callbacks. A request is let
+// through when its client IP is in allowedIPs (plain IPs or CIDRs), or when it
+// carries the shared secret in the "token" query parameter. With neither
+// configured every callback is refused.
+func CallbackGuard(allowedIPs []string, token string) gin.HandlerFunc {
+	var networks []*net.IPNet
+	for _, entry := range allowedIPs {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Warn().Str("entry", entry).Msg("Ignoring invalid callback allowlist entry")
+			continue
+		}
+		networks = append(networks, network)
+	}
+
+	return func(ctx *gin.Context) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(ctx.Query("token")), []byte(token)) == 1 {
+			ctx.Next()
+			return
+		}
+
+		if ip := net.ParseIP(ctx.ClientIP()); ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					ctx.Next()
+					return
+				}
+			}
+		}
+
+		log.Warn().Str("ip", ctx.ClientIP()).Str("path", ctx.Request.URL.Path).Msg("Rejected callback from untrusted source")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errors.NewAPIError(http.StatusForbidden, "forbidden"))
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validRequestID is what an incoming X-Request-ID must look like to be kept.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID puts an ID for the request on the context as requestID and
+// returns it in the X-Request-ID header. A well-formed X-Request-ID from the
+// client or a proxy is kept, so the request can be followed across
+// services; otherwise a random one is made up.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+
+		ctx.Set("requestID", id)
+		ctx.Header("X-Request-ID", id)
+		ctx.Next()
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/Mutonya/Savanah/internal/controllers"
+	"github.com/Mutonya/Savanah/internal/domain/models"
 	"github.com/Mutonya/Savanah/internal/domain/services"
 	"github.com/Mutonya/Savanah/internal/middleware"
+	"github.com/Mutonya/Savanah/pkg/oauth2"
 )
 
 func SetupHealthRoute(router *gin.Engine) {
@@ -19,11 +21,19 @@ func SetupHealthRoute(router *gin.Engine) {
 func SetupAuthRoutes(router *gin.Engine, authController *controllers.AuthController) {
 	auth := router.Group("/auth")
 	{
+		auth.GET("/providers", authController.Providers)
 		auth.GET("/login", authController.Login)
+		auth.GET("/login/:provider", authController.Login)
 		auth.GET("/callback", authController.Callback)
+		auth.GET("/callback/:provider", authController.Callback)
 	}
 }
 
+// SetupDevOIDCRoutes serves the mock OIDC issuer used in dev mode.
+func SetupDevOIDCRoutes(router *gin.Engine, issuer *oauth2.MockIssuer) {
+	router.Any("/dev/oidc/*path", gin.WrapH(issuer))
+}
+
 func SetupAPIRoutes(
 	router *gin.Engine,
 	authService services.AuthService,
@@ -58,6 +68,228 @@ func SetupAPIRoutes(
 		api.POST("/orders", orderController.CreateOrder)
 		api.GET("/orders", orderController.GetOrders)
 		api.GET("/orders/:id", orderController.GetOrder)
-		api.PUT("/orders/:id/status", orderController.UpdateOrderStatus)
+		api.GET("/orders/ref/:reference", orderController.GetOrderByReference)
+		api.PUT("/orders/:id/status", middleware.RequireRole(models.RoleStaff, models.RoleAdmin), orderController.UpdateOrderStatus)
+	}
+}
+
+func SetupCartRoutes(router *gin.Engine, authService services.AuthService, cartController *controllers.CartController) {
+	cart := router.Group("/api/v1/cart")
+	cart.Use(middleware.AuthMiddleware(authService))
+	{
+		cart.GET("", cartController.GetCart)
+		cart.DELETE("", cartController.ClearCart)
+		cart.POST("/items", cartController.AddItem)
+		cart.PUT("/items/:itemId", cartController.UpdateItem)
+		cart.DELETE("/items/:itemId", cartController.RemoveItem)
+		cart.POST("/checkout", cartController.Checkout)
+	}
+
+	orders := router.Group("/api/v1/orders")
+	orders.Use(middleware.AuthMiddleware(authService))
+	{
+		orders.POST("/:id/reorder", cartController.Reorder)
+	}
+}
+
+func SetupPaymentRoutes(router *gin.Engine, authService services.AuthService, paymentController *controllers.PaymentController, callbackGuard gin.HandlerFunc) {
+	api := router.Group("/api/v1")
+	api.Use(middleware.AuthMiddleware(authService))
+	{
+		api.POST("/orders/:id/payments/:provider", paymentController.InitiatePayment)
+		api.GET("/orders/:id/payments", paymentController.GetOrderPayments)
+	}
+
+	// Provider callbacks carry no customer token
+	callbacks := router.Group("/payments")
+	callbacks.Use(callbackGuard)
+	{
+		callbacks.POST("/mpesa/callback", paymentController.MpesaCallback)
+		callbacks.POST("/mpesa/reversal/result", paymentController.MpesaReversalResult)
+	}
+}
+
+func SetupPromotionRoutes(router *gin.Engine, authService services.AuthService, promotionController *controllers.PromotionController) {
+	coupons := router.Group("/api/v1/admin/coupons")
+	coupons.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		coupons.POST("", promotionController.CreateCoupon)
+		coupons.GET("", promotionController.GetCoupons)
+		coupons.GET("/:id", promotionController.GetCoupon)
+		coupons.PUT("/:id", promotionController.UpdateCoupon)
+		coupons.DELETE("/:id", promotionController.DeleteCoupon)
+	}
+}
+
+func SetupTaxRoutes(router *gin.Engine, authService services.AuthService, taxController *controllers.TaxController) {
+	rates := router.Group("/api/v1/admin/tax-rates")
+	rates.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		rates.POST("", taxController.CreateTaxRate)
+		rates.GET("", taxController.GetTaxRates)
+		rates.DELETE("/:id", taxController.DeleteTaxRate)
+	}
+}
+
+func SetupAddressRoutes(router *gin.Engine, authService services.AuthService, addressController *controllers.AddressController) {
+	addresses := router.Group("/api/v1/addresses")
+	addresses.Use(middleware.AuthMiddleware(authService))
+	{
+		addresses.GET("", addressController.GetAddresses)
+		addresses.POST("", addressController.CreateAddress)
+		addresses.PUT("/:id", addressController.UpdateAddress)
+		addresses.DELETE("/:id", addressController.DeleteAddress)
+		addresses.POST("/:id/default", addressController.SetDefaultAddress)
+		addresses.GET("/:id/delivery-quote", addressController.QuoteDelivery)
+	}
+}
+
+func SetupDeliveryRoutes(router *gin.Engine, authService services.AuthService, deliveryController *controllers.DeliveryController) {
+	zones := router.Group("/api/v1/admin/delivery-zones")
+	zones.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		zones.POST("", deliveryController.CreateZone)
+		zones.GET("", deliveryController.GetZones)
+		zones.GET("/:id", deliveryController.GetZone)
+		zones.PUT("/:id", deliveryController.UpdateZone)
+		zones.DELETE("/:id", deliveryController.DeleteZone)
+	}
+}
+
+func SetupReturnRoutes(
+	router *gin.Engine,
+	authService services.AuthService,
+	orderController *controllers.OrderController,
+	returnController *controllers.ReturnController,
+	paymentController *controllers.PaymentController,
+) {
+	api := router.Group("/api/v1")
+	api.Use(middleware.AuthMiddleware(authService))
+	{
+		api.POST("/orders/:id/cancel", orderController.CancelOrder)
+		api.POST("/orders/:id/returns", returnController.RequestReturn)
+		api.GET("/orders/:id/returns", returnController.GetOrderReturns)
+	}
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		admin.GET("/returns", returnController.GetReturns)
+		admin.POST("/returns/:id/approve", returnController.ApproveReturn)
+		admin.POST("/returns/:id/reject", returnController.RejectReturn)
+		admin.POST("/returns/:id/receive", returnController.ReceiveReturn)
+		admin.POST("/orders/:id/refunds", paymentController.RefundOrder)
+	}
+}
+
+func SetupInvoiceRoutes(router *gin.Engine, authService services.AuthService, invoiceController *controllers.InvoiceController) {
+	api := router.Group("/api/v1")
+	api.Use(middleware.AuthMiddleware(authService))
+	{
+		api.GET("/orders/:id/invoice.pdf", invoiceController.GetOrderInvoice)
+	}
+}
+
+func SetupAdminOrderRoutes(router *gin.Engine, authService services.AuthService, orderController *controllers.OrderController) {
+	orders := router.Group("/api/v1/admin/orders")
+	orders.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		orders.GET("", orderController.SearchOrders)
+		orders.GET("/export", orderController.ExportOrders)
+		orders.POST("/status", orderController.BulkUpdateOrderStatus)
+	}
+}
+
+func SetupWishlistRoutes(router *gin.Engine, authService services.AuthService, wishlistController *controllers.WishlistController) {
+	api := router.Group("/api/v1")
+	api.Use(middleware.AuthMiddleware(authService))
+	{
+		api.GET("/wishlist", wishlistController.GetWishlist)
+		api.POST("/wishlist", wishlistController.AddToWishlist)
+		api.DELETE("/wishlist/:productId", wishlistController.RemoveFromWishlist)
+		api.GET("/stock-alerts", wishlistController.GetStockAlerts)
+		api.POST("/stock-alerts", wishlistController.SubscribeStockAlert)
+		api.DELETE("/stock-alerts/:productId", wishlistController.UnsubscribeStockAlert)
+	}
+}
+
+func SetupReviewRoutes(router *gin.Engine, authService services.AuthService, reviewController *controllers.ReviewController) {
+	api := router.Group("/api/v1")
+	api.Use(middleware.AuthMiddleware(authService))
+	{
+		api.GET("/products/:id/reviews", reviewController.GetProductReviews)
+		api.POST("/products/:id/reviews", reviewController.CreateReview)
+		api.GET("/reviews", reviewController.GetMyReviews)
+		api.DELETE("/reviews/:id", reviewController.DeleteReview)
+		api.POST("/reviews/:id/helpful", reviewController.MarkHelpful)
+	}
+
+	admin := router.Group("/api/v1/admin/reviews")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		admin.GET("", reviewController.GetReviews)
+		admin.POST("/:id/approve", reviewController.ApproveReview)
+		admin.POST("/:id/reject", reviewController.RejectReview)
+	}
+}
+
+func SetupProfileRoutes(router *gin.Engine, authService services.AuthService, profileController *controllers.ProfileController) {
+	profile := router.Group("/api/v1/profile")
+	profile.Use(middleware.AuthMiddleware(authService))
+	{
+		profile.PATCH("", profileController.UpdateProfile)
+		profile.DELETE("", profileController.EraseAccount)
+		profile.GET("/export", profileController.ExportData)
+		profile.GET("/identities", profileController.GetIdentities)
+	}
+}
+
+func SetupOTPRoutes(router *gin.Engine, authService services.AuthService, otpController *controllers.OTPController) {
+	otp := router.Group("/api/v1/otp")
+	otp.Use(middleware.AuthMiddleware(authService))
+	{
+		otp.POST("/:purpose/verify", otpController.Verify)
+		otp.POST("/:purpose/resend", otpController.Resend)
+	}
+}
+
+func SetupAdminCustomerRoutes(router *gin.Engine, authService services.AuthService, customerController *controllers.CustomerController) {
+	customers := router.Group("/api/v1/admin/customers")
+	customers.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		customers.GET("", customerController.SearchCustomers)
+		customers.GET("/:id", customerController.GetCustomer)
+		customers.POST("/:id/suspend", customerController.SuspendCustomer)
+		customers.POST("/:id/reactivate", customerController.ReactivateCustomer)
+		customers.PUT("/:id/role", middleware.RequireRole(models.RoleAdmin), customerController.ChangeRole)
+	}
+}
+
+func SetupAdminAuthEventRoutes(router *gin.Engine, authService services.AuthService, auditController *controllers.AuthAuditController) {
+	events := router.Group("/api/v1/admin/auth-events")
+	events.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		events.GET("", auditController.SearchEvents)
+	}
+}
+
+// SetupAdminAuditRoutes is admin only, as the trail holds every change to
+// customer records.
+func SetupAdminAuditRoutes(router *gin.Engine, authService services.AuthService, auditController *controllers.AuditController) {
+	audit := router.Group("/api/v1/admin/audit")
+	audit.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleAdmin))
+	{
+		audit.GET("", auditController.SearchEvents)
+	}
+}
+
+func SetupAdminAPIKeyRoutes(router *gin.Engine, authService services.AuthService, apiKeyController *controllers.APIKeyController) {
+	keys := router.Group("/api/v1/admin/api-keys")
+	keys.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	{
+		keys.GET("", apiKeyController.ListKeys)
+		keys.POST("", apiKeyController.CreateKey)
+		keys.POST("/:id/rotate", apiKeyController.RotateKey)
+		keys.POST("/:id/revoke", apiKeyController.RevokeKey)
 	}
 }
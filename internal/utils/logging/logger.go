@@ -93,6 +93,7 @@ func LoggingMiddleware(logger zerolog.Logger) gin.HandlerFunc {
 			Int("status", lrw.statusCode).
 			Str("client_ip", c.ClientIP()).
 			Str("user_agent", c.Request.UserAgent()).
+			Str("request_id", c.GetString("requestID")).
 			Dur("duration", duration).
 			Msg("request processed")
 	}
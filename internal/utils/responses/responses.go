@@ -26,6 +26,19 @@ func ErrorResponse(ctx *gin.Context, statusCode int, message string) {
 	})
 }
 
+// ReasonErrorResponse sends a standardized error response that also carries
+// a machine-readable reason, for errors clients need to tell apart
+func ReasonErrorResponse(ctx *gin.Context, statusCode int, reason, message string) {
+	ctx.JSON(statusCode, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    statusCode,
+			"reason":  reason,
+			"message": message,
+		},
+	})
+}
+
 // PaginatedResponse sends a standardized paginated response
 func PaginatedResponse(ctx *gin.Context, statusCode int, data interface{}, total int64, page, limit int) {
 	ctx.JSON(statusCode, gin.H{
@@ -37,7 +37,7 @@ func GetEmailTemplate(templateName string) (*EmailTemplate, error) {
         </div>
         <div class="content">
             <p>Hello {{.Order.Customer.FirstName}},</p>
-            <p>Your order <strong>#{{.Order.ID}}</strong> has been received.</p>
+            <p>Your order <strong>{{.Order.Reference}}</strong> has been received.</p>
             
             <h2>Order Summary</h2>
             <p><strong>Total:</strong> {{printf "%.2f" .Order.Total}} {{.Config.Currency}}</p>
@@ -59,7 +59,7 @@ func GetEmailTemplate(templateName string) (*EmailTemplate, error) {
 <!DOCTYPE html>
 <html>
 <head>
-    <title>New Order #{{.Order.ID}}</title>
+    <title>New Order {{.Order.Reference}}</title>
     <style>
         body { font-family: Arial, sans-serif; line-height: 1.6; }
         .container { max-width: 600px; margin: 0 auto; padding: 20px; }
@@ -81,7 +81,7 @@ func GetEmailTemplate(templateName string) (*EmailTemplate, error) {
             <p><strong>Phone:</strong> {{.Order.Customer.Phone}}</p>
             
             <h2>Order Details</h2>
-            <p><strong>Order ID:</strong> {{.Order.ID}}</p>
+            <p><strong>Order reference:</strong> {{.Order.Reference}}</p>
             <p><strong>Total:</strong> {{printf "%.2f" .Order.Total}} {{.Config.Currency}}</p>
         </div>
     </div>
@@ -110,13 +110,169 @@ func GetEmailTemplate(templateName string) (*EmailTemplate, error) {
         </div>
         <div class="content">
             <p>Hello {{.Order.Customer.FirstName}},</p>
-            <p>The status of your order <strong>#{{.Order.ID}}</strong> has been updated to:</p>
+            <p>The status of your order <strong>{{.Order.Reference}}</strong> has been updated to:</p>
             <p style="font-size: 1.2em; font-weight: bold; color: #2c3e50;">{{.Order.Status}}</p>
             <p>Thank you for shopping with us!</p>
         </div>
     </div>
 </body>
 </html>
+`,
+		},
+		"order_cancelled": {
+			Subject: "Order Cancelled",
+			Body: `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Order Cancelled</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f8f8; padding: 10px; text-align: center; }
+        .content { padding: 20px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Order Cancelled</h1>
+        </div>
+        <div class="content">
+            <p>Hello {{.Order.Customer.FirstName}},</p>
+            <p>Your order <strong>{{.Order.Reference}}</strong> has been cancelled.</p>
+            {{if .Order.CancelReason}}<p><strong>Reason:</strong> {{.Order.CancelReason}}</p>{{end}}
+            {{if .Refund}}<p>A refund of <strong>{{printf "%.2f" .Refund.Amount}} {{.Config.Currency}}</strong> has been issued to your original payment method.</p>{{end}}
+            <p>Thank you for shopping with us!</p>
+        </div>
+    </div>
+</body>
+</html>
+`,
+		},
+		"return_update": {
+			Subject: "Return Update",
+			Body: `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Return Update</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f8f8; padding: 10px; text-align: center; }
+        .content { padding: 20px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Return Update</h1>
+        </div>
+        <div class="content">
+            <p>Hello {{.Order.Customer.FirstName}},</p>
+            {{if eq .Return.Status "requested"}}<p>We have received your return request <strong>#{{.Return.ID}}</strong> for order <strong>{{.Order.Reference}}</strong> and will review it shortly.</p>{{end}}
+            {{if eq .Return.Status "approved"}}<p>Your return request <strong>#{{.Return.ID}}</strong> has been approved. Please send the items back to us.</p>{{end}}
+            {{if eq .Return.Status "rejected"}}<p>Unfortunately your return request <strong>#{{.Return.ID}}</strong> has been declined.</p>{{end}}
+            {{if eq .Return.Status "received"}}<p>We have received the items from return <strong>#{{.Return.ID}}</strong>.{{if .Return.RefundAmount}} A refund of <strong>{{printf "%.2f" .Return.RefundAmount}} {{.Config.Currency}}</strong> has been issued.{{end}}</p>{{end}}
+            {{if .Return.StaffNote}}<p><strong>Note:</strong> {{.Return.StaffNote}}</p>{{end}}
+            <p>Thank you for shopping with us!</p>
+        </div>
+    </div>
+</body>
+</html>
+`,
+		},
+		"back_in_stock": {
+			Subject: "Back in Stock",
+			Body: `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Back in Stock</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f8f8; padding: 10px; text-align: center; }
+        .content { padding: 20px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Back in Stock</h1>
+        </div>
+        <div class="content">
+            <p>Hello {{.Customer.FirstName}},</p>
+            <p>Good news: <strong>{{.Product.Name}}</strong> is available again at <strong>{{printf "%.2f" .Product.Price}} {{.Config.Currency}}</strong>.</p>
+            <p>Stock is limited, so order soon. You asked us to tell you once; we won't email you about this product again unless you sign up for another alert.</p>
+            <p>Thank you for shopping with us!</p>
+        </div>
+    </div>
+</body>
+</html>
+`,
+		},
+		"new_country_sign_in": {
+			Subject: "New Sign-in",
+			Body: `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>New Sign-in</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f8f8; padding: 10px; text-align: center; }
+        .content { padding: 20px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>New Sign-in</h1>
+        </div>
+        <div class="content">
+            <p>Hello {{.Customer.FirstName}},</p>
+            <p>Your {{.Config.MerchantName}} account was signed in to from <strong>{{.Event.Country}}</strong>, a country you have not signed in from before, on {{.Event.CreatedAt.Format "2 Jan 2006 at 15:04 MST"}}.</p>
+            <p>IP address: {{.Event.IP}}<br>Device: {{.Event.UserAgent}}</p>
+            <p>If this was you, there is nothing to do. If not, sign in to your identity provider and change your password, then contact us.</p>
+        </div>
+    </div>
+</body>
+</html>
+`,
+		},
+		"security_alert": {
+			Subject: "Security Alert",
+			Body: `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Security Alert</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f8f8; padding: 10px; text-align: center; }
+        .content { padding: 20px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Security Alert</h1>
+        </div>
+        <div class="content">
+            <p>{{.Summary}}</p>
+            <p><strong>Event:</strong> {{.Event.Type}}{{if .Event.Reason}} ({{.Event.Reason}}){{end}}</p>
+            <p><strong>IP address:</strong> {{.Event.IP}}{{if .Event.Country}} ({{.Event.Country}}){{end}}</p>
+            <p><strong>User agent:</strong> {{.Event.UserAgent}}</p>
+            <p><strong>Time:</strong> {{.Event.CreatedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+            <p>See the full history at /api/v1/admin/auth-events?ip={{.Event.IP}}.</p>
+        </div>
+    </div>
+</body>
+</html>
 `,
 		},
 	}
@@ -0,0 +1,6 @@
+DROP TABLE order_items;
+DROP TABLE orders;
+DROP TABLE products;
+DROP TABLE categories;
+DROP TABLE customers;
+DROP TYPE order_status;
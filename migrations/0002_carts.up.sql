@@ -0,0 +1,24 @@
+-- Create carts table, one cart per customer
+CREATE TABLE carts (
+                       id SERIAL PRIMARY KEY,
+                       created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                       updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                       deleted_at TIMESTAMP WITH TIME ZONE,
+                       customer_id INTEGER NOT NULL UNIQUE REFERENCES customers(id) ON DELETE CASCADE,
+                       last_activity_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create cart_items table
+CREATE TABLE cart_items (
+                            id SERIAL PRIMARY KEY,
+                            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                            deleted_at TIMESTAMP WITH TIME ZONE,
+                            cart_id INTEGER NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+                            product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                            quantity INTEGER NOT NULL CHECK (quantity > 0),
+                            unit_price DECIMAL(10,2) NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_cart_items_cart_product ON cart_items(cart_id, product_id);
+CREATE INDEX idx_carts_last_activity_at ON carts(last_activity_at);
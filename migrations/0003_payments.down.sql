@@ -0,0 +1,2 @@
+-- Postgres cannot remove a value from an enum, so order_status keeps 'paid'
+DROP TABLE payments;
@@ -0,0 +1,23 @@
+-- Orders can now be paid
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'paid' AFTER 'pending';
+
+-- Create payments table, one row per payment attempt
+CREATE TABLE payments (
+                          id SERIAL PRIMARY KEY,
+                          created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                          updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                          deleted_at TIMESTAMP WITH TIME ZONE,
+                          order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+                          provider VARCHAR(20) NOT NULL,
+                          amount DECIMAL(10,2) NOT NULL,
+                          phone VARCHAR(20),
+                          status VARCHAR(20) NOT NULL DEFAULT 'pending',
+                          checkout_request_id VARCHAR(100) UNIQUE,
+                          merchant_request_id VARCHAR(100),
+                          receipt_number VARCHAR(50),
+                          result_code INTEGER,
+                          result_desc VARCHAR(255)
+);
+
+CREATE INDEX idx_payments_order_id ON payments(order_id);
+CREATE INDEX idx_payments_status ON payments(status);
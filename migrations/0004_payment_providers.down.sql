@@ -0,0 +1,11 @@
+-- Postgres cannot remove a value from an enum, so order_status keeps
+-- 'returned'
+DROP TABLE refunds;
+
+ALTER TABLE payments DROP CONSTRAINT chk_payments_refunded_amount;
+ALTER TABLE payments DROP COLUMN refunded_amount;
+ALTER TABLE payments ADD COLUMN merchant_request_id VARCHAR(100);
+ALTER TABLE payments ALTER COLUMN result_code TYPE INTEGER USING NULLIF(result_code, '')::INTEGER;
+ALTER TABLE payments ALTER COLUMN provider_transaction_id TYPE VARCHAR(50);
+ALTER TABLE payments RENAME COLUMN provider_transaction_id TO receipt_number;
+ALTER TABLE payments RENAME COLUMN provider_reference TO checkout_request_id;
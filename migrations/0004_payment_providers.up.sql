@@ -0,0 +1,32 @@
+-- Orders can be returned, which makes them refundable
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'returned';
+
+-- Payments are no longer M-Pesa specific
+ALTER TABLE payments RENAME COLUMN checkout_request_id TO provider_reference;
+ALTER TABLE payments RENAME COLUMN receipt_number TO provider_transaction_id;
+ALTER TABLE payments ALTER COLUMN provider_transaction_id TYPE VARCHAR(100);
+ALTER TABLE payments ALTER COLUMN result_code TYPE VARCHAR(20) USING result_code::VARCHAR;
+ALTER TABLE payments DROP COLUMN merchant_request_id;
+ALTER TABLE payments ADD COLUMN refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_refunded_amount CHECK (refunded_amount <= amount);
+
+-- Create refunds table, full or partial refunds of a settled payment
+CREATE TABLE refunds (
+                         id SERIAL PRIMARY KEY,
+                         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                         deleted_at TIMESTAMP WITH TIME ZONE,
+                         payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE RESTRICT,
+                         order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+                         amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+                         reason VARCHAR(255),
+                         status VARCHAR(20) NOT NULL DEFAULT 'pending',
+                         provider_reference VARCHAR(100),
+                         provider_transaction_id VARCHAR(100),
+                         result_desc VARCHAR(255)
+);
+
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX idx_refunds_order_id ON refunds(order_id);
+CREATE INDEX idx_refunds_status ON refunds(status);
+CREATE INDEX idx_refunds_provider_reference ON refunds(provider_reference);
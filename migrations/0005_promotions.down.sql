@@ -0,0 +1,9 @@
+DROP TABLE order_discounts;
+DROP TABLE coupon_products;
+DROP TABLE coupon_categories;
+DROP TABLE coupons;
+
+ALTER TABLE order_items DROP COLUMN discount;
+ALTER TABLE orders DROP COLUMN discount_total;
+ALTER TABLE orders DROP COLUMN subtotal;
+ALTER TABLE customers DROP COLUMN role;
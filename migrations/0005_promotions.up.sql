@@ -0,0 +1,61 @@
+-- Customers carry a role; staff and admins manage promotions
+ALTER TABLE customers ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer';
+
+-- Orders keep the pre-discount subtotal; total = subtotal - discount_total
+ALTER TABLE orders ADD COLUMN subtotal DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN discount_total DECIMAL(10,2) NOT NULL DEFAULT 0;
+UPDATE orders SET subtotal = total;
+ALTER TABLE order_items ADD COLUMN discount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Create coupons table
+CREATE TABLE coupons (
+                         id SERIAL PRIMARY KEY,
+                         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                         deleted_at TIMESTAMP WITH TIME ZONE,
+                         code VARCHAR(50) NOT NULL,
+                         description VARCHAR(255),
+                         discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+                         value DECIMAL(10,2) NOT NULL CHECK (value > 0),
+                         max_discount DECIMAL(10,2) NOT NULL DEFAULT 0,
+                         min_basket DECIMAL(10,2) NOT NULL DEFAULT 0,
+                         starts_at TIMESTAMP WITH TIME ZONE,
+                         expires_at TIMESTAMP WITH TIME ZONE,
+                         usage_limit INTEGER NOT NULL DEFAULT 0,
+                         per_customer_limit INTEGER NOT NULL DEFAULT 0,
+                         used_count INTEGER NOT NULL DEFAULT 0,
+                         active BOOLEAN NOT NULL DEFAULT TRUE,
+                         CONSTRAINT chk_coupons_usage CHECK (usage_limit = 0 OR used_count <= usage_limit)
+);
+
+CREATE UNIQUE INDEX idx_coupons_code ON coupons(code);
+CREATE INDEX idx_coupons_deleted_at ON coupons(deleted_at);
+
+-- Coupon restrictions; a coupon without rows here applies to every product
+CREATE TABLE coupon_categories (
+                                   coupon_id INTEGER NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+                                   category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+                                   PRIMARY KEY (coupon_id, category_id)
+);
+
+CREATE TABLE coupon_products (
+                                 coupon_id INTEGER NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+                                 product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                 PRIMARY KEY (coupon_id, product_id)
+);
+
+-- Discount breakdown per order; coupon rows are also the redemption record
+CREATE TABLE order_discounts (
+                                 id SERIAL PRIMARY KEY,
+                                 created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                 updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                 deleted_at TIMESTAMP WITH TIME ZONE,
+                                 order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+                                 coupon_id INTEGER REFERENCES coupons(id) ON DELETE SET NULL,
+                                 code VARCHAR(50),
+                                 description VARCHAR(255),
+                                 amount DECIMAL(10,2) NOT NULL CHECK (amount >= 0)
+);
+
+CREATE INDEX idx_order_discounts_order_id ON order_discounts(order_id);
+CREATE INDEX idx_order_discounts_coupon_id ON order_discounts(coupon_id);
@@ -0,0 +1,9 @@
+ALTER TABLE order_items DROP COLUMN gross_amount;
+ALTER TABLE order_items DROP COLUMN tax_amount;
+ALTER TABLE order_items DROP COLUMN net_amount;
+ALTER TABLE order_items DROP COLUMN tax_rate;
+ALTER TABLE order_items DROP COLUMN tax_treatment;
+ALTER TABLE orders DROP COLUMN tax_total;
+ALTER TABLE orders DROP COLUMN net_total;
+
+DROP TABLE tax_rates;
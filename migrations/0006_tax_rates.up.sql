@@ -0,0 +1,36 @@
+-- VAT rate versions per category; category_id NULL is the store-wide default
+CREATE TABLE tax_rates (
+                           id SERIAL PRIMARY KEY,
+                           created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                           updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                           deleted_at TIMESTAMP WITH TIME ZONE,
+                           category_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT,
+                           treatment VARCHAR(20) NOT NULL CHECK (treatment IN ('standard', 'zero_rated', 'exempt')),
+                           rate DECIMAL(5,2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+                           effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+                           CONSTRAINT chk_tax_rates_untaxed CHECK (treatment = 'standard' OR rate = 0)
+);
+
+CREATE INDEX idx_tax_rates_category_id ON tax_rates(category_id);
+CREATE INDEX idx_tax_rates_effective_from ON tax_rates(effective_from);
+CREATE INDEX idx_tax_rates_deleted_at ON tax_rates(deleted_at);
+
+-- Kenyan standard rate history (VAT Act 2013, Tax Laws (Amendment) Act 2020)
+INSERT INTO tax_rates (treatment, rate, effective_from) VALUES
+    ('standard', 16.00, '2013-09-02 00:00:00+03'),
+    ('standard', 14.00, '2020-04-01 00:00:00+03'),
+    ('standard', 16.00, '2021-01-01 00:00:00+03');
+
+-- Orders and lines store net, tax and gross amounts; total is the gross
+ALTER TABLE orders ADD COLUMN net_total DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN tax_total DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items ADD COLUMN tax_treatment VARCHAR(20) NOT NULL DEFAULT 'standard';
+ALTER TABLE order_items ADD COLUMN tax_rate DECIMAL(5,2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN net_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN gross_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Orders placed before VAT was tracked carried no tax
+UPDATE orders SET net_total = total;
+UPDATE order_items SET net_amount = price * quantity - discount, gross_amount = price * quantity - discount;
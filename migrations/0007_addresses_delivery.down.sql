@@ -0,0 +1,18 @@
+ALTER TABLE orders DROP COLUMN delivery_fee;
+ALTER TABLE orders DROP COLUMN shipping_weight_kg;
+ALTER TABLE orders DROP COLUMN delivery_zone;
+ALTER TABLE orders DROP COLUMN shipping_postal_code;
+ALTER TABLE orders DROP COLUMN shipping_county;
+ALTER TABLE orders DROP COLUMN shipping_town;
+ALTER TABLE orders DROP COLUMN shipping_line2;
+ALTER TABLE orders DROP COLUMN shipping_line1;
+ALTER TABLE orders DROP COLUMN shipping_phone;
+ALTER TABLE orders DROP COLUMN shipping_recipient_name;
+ALTER TABLE orders DROP COLUMN shipping_address_id;
+
+DROP TABLE delivery_rates;
+DROP TABLE delivery_areas;
+DROP TABLE delivery_zones;
+DROP TABLE addresses;
+
+ALTER TABLE products DROP COLUMN weight_kg;
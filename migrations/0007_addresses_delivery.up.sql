@@ -0,0 +1,70 @@
+-- Products carry a shipping weight
+ALTER TABLE products ADD COLUMN weight_kg DECIMAL(10,3) NOT NULL DEFAULT 0;
+
+-- Create addresses table, the customer address book
+CREATE TABLE addresses (
+                           id SERIAL PRIMARY KEY,
+                           created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                           updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                           deleted_at TIMESTAMP WITH TIME ZONE,
+                           customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+                           label VARCHAR(50) NOT NULL,
+                           recipient_name VARCHAR(200) NOT NULL,
+                           phone VARCHAR(20) NOT NULL,
+                           line1 VARCHAR(255) NOT NULL,
+                           line2 VARCHAR(255),
+                           town VARCHAR(100) NOT NULL,
+                           county VARCHAR(50) NOT NULL,
+                           postal_code VARCHAR(20),
+                           is_default BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX idx_addresses_customer_id ON addresses(customer_id);
+CREATE INDEX idx_addresses_deleted_at ON addresses(deleted_at);
+-- At most one default address per customer
+CREATE UNIQUE INDEX idx_addresses_default ON addresses(customer_id) WHERE is_default AND deleted_at IS NULL;
+
+-- Create delivery zones with the places they cover and their weight bands
+CREATE TABLE delivery_zones (
+                                id SERIAL PRIMARY KEY,
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                deleted_at TIMESTAMP WITH TIME ZONE,
+                                name VARCHAR(100) NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_delivery_zones_name ON delivery_zones(name);
+CREATE INDEX idx_delivery_zones_deleted_at ON delivery_zones(deleted_at);
+
+CREATE TABLE delivery_areas (
+                                id SERIAL PRIMARY KEY,
+                                zone_id INTEGER NOT NULL REFERENCES delivery_zones(id) ON DELETE CASCADE,
+                                county VARCHAR(50) NOT NULL,
+                                town VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_delivery_areas_zone_id ON delivery_areas(zone_id);
+-- A place belongs to one zone
+CREATE UNIQUE INDEX idx_delivery_areas_place ON delivery_areas(county, town);
+
+CREATE TABLE delivery_rates (
+                                id SERIAL PRIMARY KEY,
+                                zone_id INTEGER NOT NULL REFERENCES delivery_zones(id) ON DELETE CASCADE,
+                                max_weight_kg DECIMAL(10,3) NOT NULL CHECK (max_weight_kg > 0),
+                                fee DECIMAL(10,2) NOT NULL CHECK (fee >= 0)
+);
+
+CREATE INDEX idx_delivery_rates_zone_id ON delivery_rates(zone_id);
+
+-- Orders snapshot the shipping address and record the delivery charge
+ALTER TABLE orders ADD COLUMN shipping_address_id INTEGER REFERENCES addresses(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN shipping_recipient_name VARCHAR(200);
+ALTER TABLE orders ADD COLUMN shipping_phone VARCHAR(20);
+ALTER TABLE orders ADD COLUMN shipping_line1 VARCHAR(255);
+ALTER TABLE orders ADD COLUMN shipping_line2 VARCHAR(255);
+ALTER TABLE orders ADD COLUMN shipping_town VARCHAR(100);
+ALTER TABLE orders ADD COLUMN shipping_county VARCHAR(50);
+ALTER TABLE orders ADD COLUMN shipping_postal_code VARCHAR(20);
+ALTER TABLE orders ADD COLUMN delivery_zone VARCHAR(100);
+ALTER TABLE orders ADD COLUMN shipping_weight_kg DECIMAL(10,3) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN delivery_fee DECIMAL(10,2) NOT NULL DEFAULT 0;
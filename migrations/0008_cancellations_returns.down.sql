@@ -0,0 +1,8 @@
+-- Postgres cannot remove a value from an enum, so order_status keeps
+-- 'shipped'
+DROP TABLE return_items;
+DROP TABLE return_requests;
+
+ALTER TABLE orders DROP COLUMN cancel_reason;
+ALTER TABLE orders DROP COLUMN cancelled_at;
+ALTER TABLE products DROP COLUMN stock;
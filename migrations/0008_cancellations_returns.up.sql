@@ -0,0 +1,43 @@
+-- Orders can be shipped before they complete
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'shipped' AFTER 'paid';
+
+-- Products carry stock, taken when an order is placed and put back on
+-- cancellation or return. Null means stock is not tracked, which is where
+-- existing products start: they stay orderable until a level is set.
+ALTER TABLE products ADD COLUMN stock INTEGER CHECK (stock >= 0);
+
+-- Record why and when an order was cancelled
+ALTER TABLE orders ADD COLUMN cancelled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE orders ADD COLUMN cancel_reason VARCHAR(255);
+
+-- Create return_requests table, one per customer return (RMA)
+CREATE TABLE return_requests (
+                                 id SERIAL PRIMARY KEY,
+                                 created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                 updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                 deleted_at TIMESTAMP WITH TIME ZONE,
+                                 order_id INTEGER NOT NULL REFERENCES orders(id),
+                                 customer_id INTEGER NOT NULL REFERENCES customers(id),
+                                 status VARCHAR(20) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'approved', 'rejected', 'received')),
+                                 reason TEXT,
+                                 staff_note TEXT,
+                                 refund_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+                                 reviewed_at TIMESTAMP WITH TIME ZONE,
+                                 received_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_return_requests_order_id ON return_requests(order_id);
+CREATE INDEX idx_return_requests_customer_id ON return_requests(customer_id);
+CREATE INDEX idx_return_requests_status ON return_requests(status);
+CREATE INDEX idx_return_requests_deleted_at ON return_requests(deleted_at);
+
+CREATE TABLE return_items (
+                              id SERIAL PRIMARY KEY,
+                              return_request_id INTEGER NOT NULL REFERENCES return_requests(id) ON DELETE CASCADE,
+                              order_item_id INTEGER NOT NULL REFERENCES order_items(id),
+                              quantity INTEGER NOT NULL CHECK (quantity > 0),
+                              reason VARCHAR(255)
+);
+
+CREATE INDEX idx_return_items_return_request_id ON return_items(return_request_id);
+CREATE INDEX idx_return_items_order_item_id ON return_items(order_item_id);
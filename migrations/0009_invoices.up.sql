@@ -0,0 +1,27 @@
+-- Create invoice_counters table, the last number issued in each yearly series.
+-- Numbers are taken under the counter's row lock in the invoice's own
+-- transaction, so they run without gaps (unlike a sequence).
+CREATE TABLE invoice_counters (
+                                  series VARCHAR(20) PRIMARY KEY,
+                                  last_number INTEGER NOT NULL DEFAULT 0
+);
+
+-- Create invoices table, at most one tax invoice per order
+CREATE TABLE invoices (
+                          id SERIAL PRIMARY KEY,
+                          created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                          updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                          deleted_at TIMESTAMP WITH TIME ZONE,
+                          order_id INTEGER NOT NULL REFERENCES orders(id),
+                          number VARCHAR(30) NOT NULL,
+                          year INTEGER NOT NULL,
+                          sequence INTEGER NOT NULL,
+                          issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                          seller_name VARCHAR(255) NOT NULL,
+                          seller_kra_pin VARCHAR(20) NOT NULL,
+                          seller_address VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX idx_invoices_order_id ON invoices(order_id);
+CREATE UNIQUE INDEX idx_invoices_number ON invoices(number);
+CREATE INDEX idx_invoices_deleted_at ON invoices(deleted_at);
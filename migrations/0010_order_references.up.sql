@@ -0,0 +1,24 @@
+-- Orders get a random, human-readable reference such as SAV-7K3Q-92XD
+ALTER TABLE orders ADD COLUMN reference VARCHAR(20);
+
+-- Give existing orders a reference from the same alphabet the application
+-- uses (no 0/O or 1/I/L)
+CREATE FUNCTION pg_temp.new_order_reference() RETURNS TEXT AS $$
+DECLARE
+    alphabet TEXT := '23456789ABCDEFGHJKMNPQRSTUVWXYZ';
+    ref TEXT := 'SAV-';
+BEGIN
+    FOR i IN 1..8 LOOP
+        IF i = 5 THEN
+            ref := ref || '-';
+        END IF;
+        ref := ref || substr(alphabet, 1 + floor(random() * length(alphabet))::INTEGER, 1);
+    END LOOP;
+    RETURN ref;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE orders SET reference = pg_temp.new_order_reference() WHERE reference IS NULL;
+
+ALTER TABLE orders ALTER COLUMN reference SET NOT NULL;
+CREATE UNIQUE INDEX idx_orders_reference ON orders(reference);
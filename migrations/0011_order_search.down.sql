@@ -0,0 +1,2 @@
+DROP INDEX idx_orders_status_created_at;
+DROP INDEX idx_orders_created_at;
@@ -0,0 +1,3 @@
+-- Support the staff order listing's filters and default newest-first sort
+CREATE INDEX idx_orders_created_at ON orders(created_at);
+CREATE INDEX idx_orders_status_created_at ON orders(status, created_at);
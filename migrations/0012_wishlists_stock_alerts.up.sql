@@ -0,0 +1,24 @@
+-- Create wishlist_items table, products customers saved for later
+CREATE TABLE wishlist_items (
+                                id SERIAL PRIMARY KEY,
+                                customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+                                product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_wishlist_items_customer_product ON wishlist_items(customer_id, product_id);
+
+-- Create stock_alerts table, one-time back-in-stock subscriptions.
+-- notified_at is set when the alert is sent; NULL means it is still pending.
+CREATE TABLE stock_alerts (
+                              id SERIAL PRIMARY KEY,
+                              customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+                              product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                              channel VARCHAR(10) NOT NULL CHECK (channel IN ('sms', 'email')),
+                              created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                              updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                              notified_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_stock_alerts_customer_product ON stock_alerts(customer_id, product_id);
+CREATE INDEX idx_stock_alerts_notified_at ON stock_alerts(notified_at);
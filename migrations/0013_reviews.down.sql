@@ -0,0 +1,6 @@
+DROP TABLE review_votes;
+DROP TABLE reviews;
+
+ALTER TABLE products DROP COLUMN rating_total;
+ALTER TABLE products DROP COLUMN rating_count;
+ALTER TABLE products DROP COLUMN rating_average;
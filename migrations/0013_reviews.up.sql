@@ -0,0 +1,33 @@
+-- Rating aggregates of each product's approved reviews. rating_total is the
+-- sum of the ratings, so the average can be updated without rescanning.
+ALTER TABLE products ADD COLUMN rating_average DECIMAL(3,2) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_total INTEGER NOT NULL DEFAULT 0;
+
+-- Create reviews table, one per customer per product
+CREATE TABLE reviews (
+                         id SERIAL PRIMARY KEY,
+                         product_id INTEGER NOT NULL REFERENCES products(id),
+                         customer_id INTEGER NOT NULL REFERENCES customers(id),
+                         rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+                         title VARCHAR(120),
+                         body TEXT,
+                         status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+                         helpful_count INTEGER NOT NULL DEFAULT 0,
+                         moderation_note TEXT,
+                         moderated_at TIMESTAMP WITH TIME ZONE,
+                         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_reviews_product_customer ON reviews(product_id, customer_id);
+CREATE INDEX idx_reviews_customer_id ON reviews(customer_id);
+CREATE INDEX idx_reviews_status ON reviews(status);
+
+-- Create review_votes table, one helpful vote per customer per review
+CREATE TABLE review_votes (
+                              review_id INTEGER NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+                              customer_id INTEGER NOT NULL REFERENCES customers(id),
+                              created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                              PRIMARY KEY (review_id, customer_id)
+);
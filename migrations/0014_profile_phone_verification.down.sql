@@ -0,0 +1,4 @@
+DROP TABLE notifications;
+DROP TABLE phone_verifications;
+
+ALTER TABLE customers DROP COLUMN phone_verified_at;
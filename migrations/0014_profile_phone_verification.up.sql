@@ -0,0 +1,30 @@
+-- Phone numbers must be verified before SMS is sent to them
+ALTER TABLE customers ADD COLUMN phone_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Create phone_verifications table, a phone number change waiting for the
+-- code texted to it. Only a hash of the code is stored.
+CREATE TABLE phone_verifications (
+                                     customer_id INTEGER PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+                                     phone VARCHAR(20) NOT NULL,
+                                     code_hash VARCHAR(64) NOT NULL,
+                                     attempts INTEGER NOT NULL DEFAULT 0,
+                                     expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create notifications table, a log of the SMS and email sent to each
+-- customer, including those skipped and why
+CREATE TABLE notifications (
+                               id SERIAL PRIMARY KEY,
+                               customer_id INTEGER NOT NULL REFERENCES customers(id),
+                               order_id INTEGER REFERENCES orders(id),
+                               channel VARCHAR(10) NOT NULL,
+                               kind VARCHAR(50) NOT NULL,
+                               recipient VARCHAR(255),
+                               status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed', 'skipped')),
+                               reason VARCHAR(255),
+                               created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_customer_id ON notifications(customer_id);
+CREATE INDEX idx_notifications_order_id ON notifications(order_id);
@@ -0,0 +1,11 @@
+-- Pending phone changes are lost, as they were going forward
+DROP TABLE otps;
+
+CREATE TABLE phone_verifications (
+                                     customer_id INTEGER PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+                                     phone VARCHAR(20) NOT NULL,
+                                     code_hash VARCHAR(64) NOT NULL,
+                                     attempts INTEGER NOT NULL DEFAULT 0,
+                                     expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
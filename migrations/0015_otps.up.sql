@@ -0,0 +1,22 @@
+-- One-time codes replace the phone-only verifications; pending phone
+-- changes need a new code
+DROP TABLE phone_verifications;
+
+-- Create otps table, the live one-time code for each purpose and subject.
+-- Only an HMAC of the code is stored.
+CREATE TABLE otps (
+                      id SERIAL PRIMARY KEY,
+                      purpose VARCHAR(30) NOT NULL,
+                      subject VARCHAR(100) NOT NULL,
+                      phone VARCHAR(20) NOT NULL,
+                      code_hash VARCHAR(64) NOT NULL,
+                      attempts INTEGER NOT NULL DEFAULT 0,
+                      sends INTEGER NOT NULL DEFAULT 0,
+                      window_started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                      last_sent_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                      expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                      created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_otps_purpose_subject ON otps(purpose, subject);
+CREATE INDEX idx_otps_expires_at ON otps(expires_at);
@@ -0,0 +1,3 @@
+-- Customers erased at their request keep an anonymised row so their orders
+-- still add up; erased_at records when their personal data was removed
+ALTER TABLE customers ADD COLUMN erased_at TIMESTAMP WITH TIME ZONE;
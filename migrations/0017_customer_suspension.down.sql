@@ -0,0 +1,4 @@
+DROP INDEX idx_customers_created_at;
+
+ALTER TABLE customers DROP COLUMN suspension_reason;
+ALTER TABLE customers DROP COLUMN suspended_at;
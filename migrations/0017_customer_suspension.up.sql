@@ -0,0 +1,6 @@
+-- Staff can suspend a customer, who is then shut out of the API until
+-- reactivated
+ALTER TABLE customers ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE customers ADD COLUMN suspension_reason VARCHAR(255);
+
+CREATE INDEX idx_customers_created_at ON customers(created_at);
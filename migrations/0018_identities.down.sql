@@ -0,0 +1,13 @@
+-- Customers go back to a single sign-in; those with several identities keep
+-- the one they used first
+ALTER TABLE customers ADD COLUMN oauth_id VARCHAR(255) UNIQUE;
+
+UPDATE customers c SET oauth_id = i.subject
+FROM (
+    SELECT DISTINCT ON (customer_id) customer_id, subject
+    FROM identities
+    ORDER BY customer_id, created_at, id
+) i
+WHERE i.customer_id = c.id;
+
+DROP TABLE identities;
@@ -0,0 +1,25 @@
+-- Create identities table, the external accounts (Google, Microsoft, ...)
+-- each customer signs in with. Replaces customers.oauth_id.
+CREATE TABLE identities (
+                            id SERIAL PRIMARY KEY,
+                            customer_id INTEGER NOT NULL REFERENCES customers(id),
+                            provider VARCHAR(50) NOT NULL,
+                            subject VARCHAR(255) NOT NULL,
+                            email VARCHAR(255),
+                            email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+                            last_login_at TIMESTAMP WITH TIME ZONE,
+                            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_identities_provider_subject ON identities(provider, subject);
+CREATE INDEX idx_identities_customer_id ON identities(customer_id);
+
+-- Existing sign-ins came from the single OAUTH_PROVIDER_URL provider, which
+-- is now named 'default'. Rename the provider here when moving those
+-- accounts to a named provider in OAUTH_PROVIDERS.
+INSERT INTO identities (customer_id, provider, subject, email, created_at)
+SELECT id, 'default', oauth_id, email, created_at
+FROM customers
+WHERE oauth_id IS NOT NULL AND oauth_id <> '';
+
+ALTER TABLE customers DROP COLUMN oauth_id;
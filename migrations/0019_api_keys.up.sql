@@ -0,0 +1,18 @@
+-- Create api_keys table, the keys service accounts such as the ERP and POS
+-- call the API with. Only a SHA-256 hash of each key is stored; prefix is
+-- the public part a key is looked up by.
+CREATE TABLE api_keys (
+                          id SERIAL PRIMARY KEY,
+                          name VARCHAR(100) NOT NULL,
+                          prefix VARCHAR(16) NOT NULL,
+                          key_hash VARCHAR(64) NOT NULL,
+                          scopes TEXT NOT NULL,
+                          expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                          last_used_at TIMESTAMP WITH TIME ZONE,
+                          revoked_at TIMESTAMP WITH TIME ZONE,
+                          created_by_id INTEGER NOT NULL REFERENCES customers(id),
+                          replaced_by_id INTEGER REFERENCES api_keys(id),
+                          created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys(prefix);
@@ -0,0 +1,21 @@
+-- Create auth_events table, the authentication audit log. customer_id is
+-- null for sign-ins that failed before the customer was known; alerts is
+-- the JSON list of anomaly rules the event tripped.
+CREATE TABLE auth_events (
+                             id SERIAL PRIMARY KEY,
+                             type VARCHAR(20) NOT NULL,
+                             customer_id INTEGER REFERENCES customers(id),
+                             actor_id INTEGER REFERENCES customers(id),
+                             provider VARCHAR(50),
+                             ip VARCHAR(45),
+                             user_agent VARCHAR(255),
+                             country VARCHAR(2),
+                             reason VARCHAR(100),
+                             alerts TEXT,
+                             created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_auth_events_type ON auth_events(type);
+CREATE INDEX idx_auth_events_customer_id ON auth_events(customer_id);
+CREATE INDEX idx_auth_events_ip ON auth_events(ip);
+CREATE INDEX idx_auth_events_created_at ON auth_events(created_at);
@@ -0,0 +1,20 @@
+-- Create audit_events table, the audit trail of every row created, updated
+-- or deleted through the repositories. changes is the JSON object of the
+-- columns that changed, each with its "from" and "to" value; actor_id is
+-- null for changes made by the system.
+CREATE TABLE audit_events (
+                              id SERIAL PRIMARY KEY,
+                              actor_type VARCHAR(20) NOT NULL,
+                              actor_id INTEGER,
+                              action VARCHAR(10) NOT NULL,
+                              entity VARCHAR(50) NOT NULL,
+                              entity_id VARCHAR(50) NOT NULL,
+                              changes TEXT,
+                              request_id VARCHAR(64),
+                              created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_events_actor ON audit_events(actor_type, actor_id);
+CREATE INDEX idx_audit_events_entity ON audit_events(entity, entity_id);
+CREATE INDEX idx_audit_events_request_id ON audit_events(request_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
@@ -0,0 +1,2 @@
+DROP INDEX idx_delivery_areas_place;
+CREATE UNIQUE INDEX idx_delivery_areas_place ON delivery_areas(county, town);
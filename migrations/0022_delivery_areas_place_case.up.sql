@@ -0,0 +1,5 @@
+-- A place belongs to one zone whatever the case of its name, as FindZone
+-- matches it. The index fails to build while two areas differ only in case;
+-- remove one of them first.
+DROP INDEX idx_delivery_areas_place;
+CREATE UNIQUE INDEX idx_delivery_areas_place ON delivery_areas(LOWER(county), LOWER(town));
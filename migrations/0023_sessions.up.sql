@@ -0,0 +1,12 @@
+-- Create sessions table, one row per customer sign-in. Only a SHA-256 hash
+-- of the bearer token is stored; deleting a row ends the session.
+CREATE TABLE sessions (
+                          id SERIAL PRIMARY KEY,
+                          customer_id INTEGER NOT NULL REFERENCES customers(id),
+                          token_hash VARCHAR(64) NOT NULL,
+                          expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                          created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_sessions_token_hash ON sessions(token_hash);
+CREATE INDEX idx_sessions_customer_id ON sessions(customer_id);
@@ -0,0 +1,97 @@
+// Package geoip looks up the country of an IP address in a local database
+// file, so no request leaves the server.
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DB is an IP-to-country database loaded from a CSV file of address ranges,
+// one per line as "first_ip,last_ip,country_code", e.g.
+//
+//	41.89.0.0,41.89.255.255,KE
+//
+// This is the layout of the free DB-IP "IP to Country Lite" download. IPv4
+// and IPv6 ranges can be mixed. A nil *DB knows no countries.
+type DB struct {
+	ranges []ipRange
+}
+
+type ipRange struct {
+	first, last netip.Addr
+	country     string
+}
+
+// Open loads the database at path.
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load reads a database in the format Open expects.
+func Load(r io.Reader) (*DB, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var ranges []ipRange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: want first_ip,last_ip,country", line)
+		}
+		first, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		last, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if first.Is4() != last.Is4() || last.Less(first) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, first, last)
+		}
+		ranges = append(ranges, ipRange{first: first, last: last, country: strings.ToUpper(strings.TrimSpace(record[2]))})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].first.Less(ranges[j].first) })
+	return &DB{ranges: ranges}, nil
+}
+
+// Country returns the ISO 3166 country code of ip, or "" when the address
+// is invalid, private or not in the database.
+func (db *DB) Country(ip string) string {
+	if db == nil {
+		return ""
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	// The last range starting at or before addr is the only one that can
+	// hold it
+	i := sort.Search(len(db.ranges), func(i int) bool { return addr.Less(db.ranges[i].first) }) - 1
+	if i < 0 || db.ranges[i].last.Less(addr) {
+		return ""
+	}
+	return db.ranges[i].country
+}
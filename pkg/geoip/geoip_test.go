@@ -0,0 +1,31 @@
+package geoip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountry(t *testing.T) {
+	db, err := Load(strings.NewReader(`105.160.0.0,105.167.255.255,KE
+41.0.0.0,41.0.255.255,ZA
+2c0f:fe38::,2c0f:fe38:ffff:ffff:ffff:ffff:ffff:ffff,ke
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "KE", db.Country("105.161.2.3"))
+	assert.Equal(t, "ZA", db.Country("41.0.255.255"))
+	assert.Equal(t, "KE", db.Country("::ffff:105.160.0.1"), "IPv4-mapped addresses are looked up as IPv4")
+	assert.Equal(t, "KE", db.Country("2c0f:fe38::1"))
+	assert.Empty(t, db.Country("105.168.0.0"))
+	assert.Empty(t, db.Country("10.0.0.1"))
+	assert.Empty(t, db.Country("not an ip"))
+
+	var none *DB
+	assert.Empty(t, none.Country("105.161.2.3"))
+
+	_, err = Load(strings.NewReader("105.167.255.255,105.160.0.0,KE\n"))
+	assert.Error(t, err)
+}
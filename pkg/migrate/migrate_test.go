@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0010_orders.up.sql":    {Data: []byte("CREATE TABLE orders ();")},
+		"0002_carts.up.sql":     {Data: []byte("CREATE TABLE carts ();")},
+		"0002_carts.down.sql":   {Data: []byte("DROP TABLE carts;")},
+		"0001_initial.up.sql":   {Data: []byte("CREATE TABLE customers ();")},
+		"README.md":             {Data: []byte("not a migration")},
+		"0003_notes.sql":        {Data: []byte("not a migration either")},
+		"archive/0004_x.up.sql": {Data: []byte("in a subdirectory")},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, migrations, 3)
+	assert.Equal(t, Migration{Version: 1, Name: "initial", Up: "CREATE TABLE customers ();"}, migrations[0])
+	assert.Equal(t, Migration{Version: 2, Name: "carts", Up: "CREATE TABLE carts ();", Down: "DROP TABLE carts;"}, migrations[1])
+	assert.Equal(t, int64(10), migrations[2].Version)
+	assert.Equal(t, "0010_orders", migrations[2].String())
+
+	_, err = Load(fstest.MapFS{
+		"0002_carts.up.sql":   {Data: []byte("CREATE TABLE carts ();")},
+		"0002_basket.up.sql":  {Data: []byte("CREATE TABLE baskets ();")},
+		"0001_initial.up.sql": {Data: []byte("CREATE TABLE customers ();")},
+	})
+	assert.ErrorContains(t, err, "version 2 is used by both")
+
+	_, err = Load(fstest.MapFS{
+		"0002_carts.down.sql": {Data: []byte("DROP TABLE carts;")},
+	})
+	assert.ErrorContains(t, err, "0002_carts has no up file")
+}
+
+// Every migration in the repository can be rolled back
+func TestRepositoryMigrations(t *testing.T) {
+	migrations, err := Load(os.DirFS("../../migrations"))
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "versions run without gaps")
+		assert.NotEmpty(t, migration.Down, "%s has no down file", migration)
+	}
+}
@@ -0,0 +1,94 @@
+package mpesa
+
+import "encoding/json"
+
+// ResultCodeSuccess is the stkCallback ResultCode of a completed payment.
+// Anything else (1032 cancelled by user, 1037 timeout, 2001 wrong PIN, ...)
+// means the customer did not pay.
+const ResultCodeSuccess = 0
+
+// Callback is the body Daraja POSTs to CallBackURL once the customer has
+// answered (or ignored) the STK prompt.
+type Callback struct {
+	Body struct {
+		STKCallback STKCallback `json:"stkCallback"`
+	} `json:"Body"`
+}
+
+type STKCallback struct {
+	MerchantRequestID string `json:"MerchantRequestID"`
+	CheckoutRequestID string `json:"CheckoutRequestID"`
+	ResultCode        int    `json:"ResultCode"`
+	ResultDesc        string `json:"ResultDesc"`
+	CallbackMetadata  struct {
+		Item []CallbackItem `json:"Item"`
+	} `json:"CallbackMetadata"`
+}
+
+type CallbackItem struct {
+	Name  string          `json:"Name"`
+	Value json.RawMessage `json:"Value"`
+}
+
+// CallbackAck is the acknowledgement Daraja expects in the callback response.
+type CallbackAck struct {
+	ResultCode int    `json:"ResultCode"`
+	ResultDesc string `json:"ResultDesc"`
+}
+
+func (c *STKCallback) Succeeded() bool {
+	return c.ResultCode == ResultCodeSuccess
+}
+
+// ReceiptNumber returns the M-Pesa transaction code, e.g. NLJ7RT61SV.
+func (c *STKCallback) ReceiptNumber() string {
+	return c.metadataString("MpesaReceiptNumber")
+}
+
+// Amount returns the amount the customer actually paid.
+func (c *STKCallback) Amount() float64 {
+	var amount float64
+	for _, item := range c.CallbackMetadata.Item {
+		if item.Name == "Amount" {
+			_ = json.Unmarshal(item.Value, &amount)
+		}
+	}
+	return amount
+}
+
+func (c *STKCallback) PhoneNumber() string {
+	return c.metadataString("PhoneNumber")
+}
+
+// metadataString returns a metadata value as a string. Daraja sends some
+// values (PhoneNumber, TransactionDate) as bare numbers.
+func (c *STKCallback) metadataString(name string) string {
+	for _, item := range c.CallbackMetadata.Item {
+		if item.Name != name {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(item.Value, &s); err == nil {
+			return s
+		}
+		var n json.Number
+		if err := json.Unmarshal(item.Value, &n); err == nil {
+			return n.String()
+		}
+		return string(item.Value)
+	}
+	return ""
+}
+
+// ResultCallback is the body Daraja POSTs to ResultURL for asynchronous
+// requests such as reversals.
+type ResultCallback struct {
+	Result struct {
+		ResultType               int    `json:"ResultType"`
+		ResultCode               int    `json:"ResultCode"`
+		ResultDesc               string `json:"ResultDesc"`
+		OriginatorConversationID string `json:"OriginatorConversationID"`
+		ConversationID           string `json:"ConversationID"`
+		TransactionID            string `json:"TransactionID"`
+	} `json:"Result"`
+}
@@ -0,0 +1,320 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the Daraja sandbox. Production deployments point
+// MPESA_BASE_URL at https://api.safaricom.co.ke instead.
+const DefaultBaseURL = "https://sandbox.safaricom.co.ke"
+
+// Daraja timestamps and passwords are computed in East Africa Time.
+var eat = time.FixedZone("EAT", 3*60*60)
+
+var ErrInvalidPhone = errors.New("invalid M-Pesa phone number")
+
+type Config struct {
+	BaseURL         string
+	ConsumerKey     string
+	ConsumerSecret  string
+	ShortCode       string
+	PassKey         string
+	CallbackURL     string
+	TransactionType string // CustomerPayBillOnline or CustomerBuyGoodsOnline
+
+	// Reversal (refund) settings. ResultURL receives the asynchronous outcome.
+	InitiatorName      string
+	SecurityCredential string
+	ResultURL          string
+}
+
+// Client talks to the Safaricom Daraja API. It caches the OAuth access token
+// until shortly before it expires.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+func NewClient(config Config, httpClient *http.Client) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	if config.TransactionType == "" {
+		config.TransactionType = "CustomerPayBillOnline"
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{config: config, httpClient: httpClient, now: time.Now}
+}
+
+type STKPushRequest struct {
+	Phone            string
+	Amount           int
+	AccountReference string
+	Description      string
+}
+
+type STKPushResponse struct {
+	MerchantRequestID   string `json:"MerchantRequestID"`
+	CheckoutRequestID   string `json:"CheckoutRequestID"`
+	ResponseCode        string `json:"ResponseCode"`
+	ResponseDescription string `json:"ResponseDescription"`
+	CustomerMessage     string `json:"CustomerMessage"`
+}
+
+type STKQueryResponse struct {
+	MerchantRequestID   string `json:"MerchantRequestID"`
+	CheckoutRequestID   string `json:"CheckoutRequestID"`
+	ResponseCode        string `json:"ResponseCode"`
+	ResponseDescription string `json:"ResponseDescription"`
+	ResultCode          string `json:"ResultCode"`
+	ResultDesc          string `json:"ResultDesc"`
+}
+
+// APIError is the error body Daraja returns on 4xx/5xx responses.
+type APIError struct {
+	StatusCode   int    `json:"-"`
+	RequestID    string `json:"requestId"`
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("daraja error (status %d, code %s): %s", e.StatusCode, e.ErrorCode, e.ErrorMessage)
+}
+
+// STKPush asks Safaricom to prompt the customer's handset for payment.
+func (c *Client) STKPush(ctx context.Context, req STKPushRequest) (*STKPushResponse, error) {
+	phone, err := NormalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount < 1 {
+		return nil, fmt.Errorf("invalid STK push amount %d", req.Amount)
+	}
+
+	password, timestamp := c.password()
+	payload := map[string]interface{}{
+		"BusinessShortCode": c.config.ShortCode,
+		"Password":          password,
+		"Timestamp":         timestamp,
+		"TransactionType":   c.config.TransactionType,
+		"Amount":            req.Amount,
+		"PartyA":            phone,
+		"PartyB":            c.config.ShortCode,
+		"PhoneNumber":       phone,
+		"CallBackURL":       c.config.CallbackURL,
+		"AccountReference":  truncate(req.AccountReference, 12),
+		"TransactionDesc":   truncate(req.Description, 13),
+	}
+
+	var resp STKPushResponse
+	if err := c.post(ctx, "/mpesa/stkpush/v1/processrequest", payload, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ResponseCode != "0" {
+		return nil, fmt.Errorf("STK push rejected (%s): %s", resp.ResponseCode, resp.ResponseDescription)
+	}
+	return &resp, nil
+}
+
+// STKQuery asks Daraja for the outcome of an earlier STK push.
+func (c *Client) STKQuery(ctx context.Context, checkoutRequestID string) (*STKQueryResponse, error) {
+	password, timestamp := c.password()
+	payload := map[string]interface{}{
+		"BusinessShortCode": c.config.ShortCode,
+		"Password":          password,
+		"Timestamp":         timestamp,
+		"CheckoutRequestID": checkoutRequestID,
+	}
+
+	var resp STKQueryResponse
+	if err := c.post(ctx, "/mpesa/stkpushquery/v1/query", payload, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+type ReversalRequest struct {
+	TransactionID string // M-Pesa receipt number of the payment to reverse
+	Amount        int
+	Remarks       string
+}
+
+type ReversalResponse struct {
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	ConversationID           string `json:"ConversationID"`
+	ResponseCode             string `json:"ResponseCode"`
+	ResponseDescription      string `json:"ResponseDescription"`
+}
+
+// Reverse asks Safaricom to send (part of) a payment back to the customer.
+// The outcome is posted to ResultURL and keyed by ConversationID.
+func (c *Client) Reverse(ctx context.Context, req ReversalRequest) (*ReversalResponse, error) {
+	if req.Amount < 1 {
+		return nil, fmt.Errorf("invalid reversal amount %d", req.Amount)
+	}
+
+	remarks := req.Remarks
+	if remarks == "" {
+		remarks = "Refund"
+	}
+	payload := map[string]interface{}{
+		"Initiator":              c.config.InitiatorName,
+		"SecurityCredential":     c.config.SecurityCredential,
+		"CommandID":              "TransactionReversal",
+		"TransactionID":          req.TransactionID,
+		"Amount":                 strconv.Itoa(req.Amount),
+		"ReceiverParty":          c.config.ShortCode,
+		"RecieverIdentifierType": "11", // sic, Daraja's spelling
+		"ResultURL":              c.config.ResultURL,
+		"QueueTimeOutURL":        c.config.ResultURL,
+		"Remarks":                truncate(remarks, 100),
+		"Occasion":               "",
+	}
+
+	var resp ReversalResponse
+	if err := c.post(ctx, "/mpesa/reversal/v1/request", payload, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ResponseCode != "0" {
+		return nil, fmt.Errorf("reversal rejected (%s): %s", resp.ResponseCode, resp.ResponseDescription)
+	}
+	return &resp, nil
+}
+
+func (c *Client) password() (string, string) {
+	timestamp := c.now().In(eat).Format("20060102150405")
+	raw := c.config.ShortCode + c.config.PassKey + timestamp
+	return base64.StdEncoding.EncodeToString([]byte(raw)), timestamp
+}
+
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && c.now().Before(c.tokenExpiry) {
+		return c.accessToken, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.config.BaseURL+"/oauth/v1/generate?grant_type=client_credentials", nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating token request: %w", err)
+	}
+	req.SetBasicAuth(c.config.ConsumerKey, c.config.ConsumerSecret)
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+	if err := c.do(req, &body); err != nil {
+		return "", fmt.Errorf("error fetching Daraja access token: %w", err)
+	}
+
+	ttl, err := strconv.Atoi(body.ExpiresIn)
+	if err != nil || ttl <= 0 {
+		ttl = 3599
+	}
+	c.accessToken = body.AccessToken
+	// Refresh a minute early so an in-flight request never carries a stale token
+	c.tokenExpiry = c.now().Add(time.Duration(ttl)*time.Second - time.Minute)
+
+	return c.accessToken, nil
+}
+
+func (c *Client) post(ctx context.Context, path string, payload interface{}, out interface{}) error {
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshaling Daraja payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.BaseURL+path, bytes.NewReader(jsonPayload))
+	if err != nil {
+		return fmt.Errorf("error creating Daraja request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling Daraja: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading Daraja response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(body, apiErr); err != nil || apiErr.ErrorMessage == "" {
+			apiErr.ErrorMessage = string(body)
+		}
+		return apiErr
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("error decoding Daraja response: %w", err)
+	}
+	return nil
+}
+
+// NormalizePhone converts the common Kenyan formats (07XXXXXXXX, 01XXXXXXXX,
+// +2547XXXXXXXX, 2547XXXXXXXX) to the 2547XXXXXXXX form Daraja expects.
+func NormalizePhone(phone string) (string, error) {
+	p := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(phone))
+	p = strings.TrimPrefix(p, "+")
+
+	switch {
+	case len(p) == 10 && strings.HasPrefix(p, "0"):
+		p = "254" + p[1:]
+	case len(p) == 9 && (strings.HasPrefix(p, "7") || strings.HasPrefix(p, "1")):
+		p = "254" + p
+	}
+
+	if len(p) != 12 || !strings.HasPrefix(p, "254") {
+		return "", ErrInvalidPhone
+	}
+	for _, r := range p {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+	return p, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
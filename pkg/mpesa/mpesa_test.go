@@ -0,0 +1,110 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDaraja mimics the three Daraja endpoints the client uses.
+func fakeDaraja(t *testing.T, tokenCalls *int, pushBody *map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/v1/generate", func(w http.ResponseWriter, r *http.Request) {
+		*tokenCalls++
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "key", user)
+		assert.Equal(t, "secret", pass)
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "tok", "expires_in": "3599"})
+	})
+	mux.HandleFunc("/mpesa/stkpush/v1/processrequest", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(pushBody))
+		_ = json.NewEncoder(w).Encode(STKPushResponse{
+			MerchantRequestID: "m-1",
+			CheckoutRequestID: "ws_CO_1",
+			ResponseCode:      "0",
+		})
+	})
+	mux.HandleFunc("/mpesa/stkpushquery/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(APIError{ErrorCode: "500.001.1001", ErrorMessage: "The transaction is being processed"})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient_STKPushAgainstFakeServer(t *testing.T) {
+	var tokenCalls int
+	var pushBody map[string]interface{}
+	srv := fakeDaraja(t, &tokenCalls, &pushBody)
+	defer srv.Close()
+
+	client := NewClient(Config{
+		BaseURL:        srv.URL,
+		ConsumerKey:    "key",
+		ConsumerSecret: "secret",
+		ShortCode:      "174379",
+		PassKey:        "pass",
+		CallbackURL:    "https://shop.example/payments/mpesa/callback",
+	}, srv.Client())
+	client.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	resp, err := client.STKPush(context.Background(), STKPushRequest{Phone: "0712 345 678", Amount: 150, AccountReference: "ORDER42"})
+	require.NoError(t, err)
+	assert.Equal(t, "ws_CO_1", resp.CheckoutRequestID)
+
+	assert.Equal(t, "254712345678", pushBody["PhoneNumber"])
+	assert.Equal(t, "20250102060405", pushBody["Timestamp"])
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("174379pass20250102060405")), pushBody["Password"])
+
+	// The access token is cached between calls
+	_, err = client.STKPush(context.Background(), STKPushRequest{Phone: "254712345678", Amount: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, tokenCalls)
+
+	_, err = client.STKQuery(context.Background(), "ws_CO_1")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "500.001.1001", apiErr.ErrorCode)
+}
+
+func TestNormalizePhone(t *testing.T) {
+	for in, want := range map[string]string{
+		"0712345678":    "254712345678",
+		"+254712345678": "254712345678",
+		"0110 123-456":  "254110123456",
+		"712345678":     "254712345678",
+	} {
+		got, err := NormalizePhone(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+
+	for _, in := range []string{"", "12345", "+1 555 123 4567", "07123456ab"} {
+		_, err := NormalizePhone(in)
+		assert.ErrorIs(t, err, ErrInvalidPhone, in)
+	}
+}
+
+func TestCallback_Metadata(t *testing.T) {
+	body := `{"Body":{"stkCallback":{"MerchantRequestID":"m-1","CheckoutRequestID":"ws_CO_1","ResultCode":0,
+		"ResultDesc":"The service request is processed successfully.","CallbackMetadata":{"Item":[
+		{"Name":"Amount","Value":150.00},{"Name":"MpesaReceiptNumber","Value":"NLJ7RT61SV"},
+		{"Name":"TransactionDate","Value":20191219102115},{"Name":"PhoneNumber","Value":254712345678}]}}}}`
+
+	var cb Callback
+	require.NoError(t, json.Unmarshal([]byte(body), &cb))
+
+	stk := cb.Body.STKCallback
+	assert.True(t, stk.Succeeded())
+	assert.Equal(t, "NLJ7RT61SV", stk.ReceiptNumber())
+	assert.Equal(t, 150.0, stk.Amount())
+	assert.Equal(t, "254712345678", stk.PhoneNumber())
+}
@@ -0,0 +1,423 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+const (
+	mockCodeTTL  = time.Minute
+	mockTokenTTL = time.Hour
+)
+
+// MockUser is an account at a MockIssuer.
+type MockUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// NewMockUser returns a verified user whose subject is their email and whose
+// name is taken from it, e.g. "amina.wanjiru@example.com" is "Amina Wanjiru".
+func NewMockUser(email string) MockUser {
+	local, _, _ := strings.Cut(email, "@")
+	words := strings.FieldsFunc(local, func(r rune) bool { return r == '.' || r == '_' || r == '-' || r == '+' })
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return MockUser{Subject: email, Email: email, EmailVerified: true, Name: strings.Join(words, " ")}
+}
+
+// MockIssuer is an in-process OpenID Connect issuer for local development
+// and tests. It serves discovery, JWKS, authorize, token and userinfo
+// endpoints under its issuer URL, signs ID tokens with a key generated at
+// start-up and enforces PKCE (S256). Any client ID is accepted.
+//
+// The authorize endpoint signs in the user named by login_hint (email or
+// subject), the only user when there is one, or else shows a page to pick
+// one.
+type MockIssuer struct {
+	issuer   string
+	basePath string
+	key      *rsa.PrivateKey
+	keyID    string
+	users    []MockUser
+	now      func() time.Time
+
+	mu     sync.Mutex
+	codes  map[string]mockGrant
+	tokens map[string]MockUser
+}
+
+type mockGrant struct {
+	user          MockUser
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+// NewMockIssuer creates an issuer that is served at issuerURL, e.g.
+// http://localhost:8080/dev/oidc. Requests reach it with their full path, so
+// it must not be mounted behind http.StripPrefix.
+func NewMockIssuer(issuerURL string, users ...MockUser) (*MockIssuer, error) {
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer URL: %w", err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := RandomString(8)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MockIssuer{
+		issuer:   strings.TrimSuffix(issuerURL, "/"),
+		basePath: strings.TrimSuffix(u.Path, "/"),
+		key:      key,
+		keyID:    keyID,
+		users:    users,
+		now:      time.Now,
+		codes:    make(map[string]mockGrant),
+		tokens:   make(map[string]MockUser),
+	}, nil
+}
+
+// NewMockIssuerServer starts a MockIssuer on a local test server. Close the
+// server when done.
+func NewMockIssuerServer(users ...MockUser) (*MockIssuer, *httptest.Server, error) {
+	var issuer *MockIssuer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer.ServeHTTP(w, r)
+	}))
+	issuer, err := NewMockIssuer(server.URL, users...)
+	if err != nil {
+		server.Close()
+		return nil, nil, err
+	}
+	return issuer, server, nil
+}
+
+// URL is the issuer URL, as it appears in discovery and ID tokens.
+func (m *MockIssuer) URL() string {
+	return m.issuer
+}
+
+// Client is an HTTP client that calls the issuer in-process, so a server can
+// use an issuer it hosts itself without a network round trip, including
+// before it starts listening.
+func (m *MockIssuer) Client() *http.Client {
+	return &http.Client{Transport: inProcessTransport{handler: m}}
+}
+
+// Provider returns an OIDCProvider for the issuer that talks to it
+// in-process. Unlike NewOIDCProvider it needs no discovery request, so it
+// works before the server hosting the issuer is up.
+func (m *MockIssuer) Provider(ctx context.Context, clientID, redirectURL string) *OIDCProvider {
+	client := m.Client()
+	config := &oidc.ProviderConfig{
+		IssuerURL:   m.issuer,
+		AuthURL:     m.issuer + "/authorize",
+		TokenURL:    m.issuer + "/token",
+		UserInfoURL: m.issuer + "/userinfo",
+		JWKSURL:     m.issuer + "/keys",
+		Algorithms:  []string{oidc.RS256},
+	}
+	ctx = oidc.ClientContext(ctx, client)
+	provider := newOIDCProvider(ctx, config.NewProvider(ctx), clientID, "", redirectURL)
+	provider.client = client
+	return provider
+}
+
+func (m *MockIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, m.basePath) {
+	case "/.well-known/openid-configuration":
+		m.discovery(w)
+	case "/keys":
+		m.keys(w)
+	case "/authorize":
+		m.authorize(w, r)
+	case "/token":
+		m.token(w, r)
+	case "/userinfo":
+		m.userInfo(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *MockIssuer) discovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.issuer,
+		"authorization_endpoint":                m.issuer + "/authorize",
+		"token_endpoint":                        m.issuer + "/token",
+		"userinfo_endpoint":                     m.issuer + "/userinfo",
+		"jwks_uri":                              m.issuer + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{oidc.RS256},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{oidc.ScopeOpenID, "profile", "email"},
+	})
+}
+
+func (m *MockIssuer) keys(w http.ResponseWriter) {
+	pub := m.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": oidc.RS256,
+			"use": "sig",
+			"kid": m.keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+var mockPickerPage = template.Must(template.New("picker").Parse(`<!DOCTYPE html>
+<html><head><title>Mock sign-in</title></head>
+<body>
+<h1>Sign in as</h1>
+<ul>{{range .}}<li><a href="{{.URL}}">{{.User.Name}} &lt;{{.User.Email}}&gt;</a></li>{{end}}</ul>
+</body></html>
+`))
+
+func (m *MockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("response_type") != "code" || q.Get("client_id") == "" || redirectURI == "" {
+		http.Error(w, "response_type=code, client_id and redirect_uri are required", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "a PKCE S256 code_challenge is required", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := m.pickUser(q.Get("login_hint"))
+	if !ok {
+		if q.Get("login_hint") != "" || len(m.users) == 0 {
+			http.Error(w, "unknown user", http.StatusBadRequest)
+			return
+		}
+		m.showPicker(w, r)
+		return
+	}
+
+	code, err := RandomString(24)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.mu.Lock()
+	m.codes[code] = mockGrant{
+		user:          user,
+		clientID:      q.Get("client_id"),
+		redirectURI:   redirectURI,
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		expiresAt:     m.now().Add(mockCodeTTL),
+	}
+	m.mu.Unlock()
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := target.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// pickUser finds the user named by hint, or the only user when there is no
+// hint.
+func (m *MockIssuer) pickUser(hint string) (MockUser, bool) {
+	if hint == "" {
+		if len(m.users) == 1 {
+			return m.users[0], true
+		}
+		return MockUser{}, false
+	}
+	for _, user := range m.users {
+		if strings.EqualFold(user.Email, hint) || user.Subject == hint {
+			return user, true
+		}
+	}
+	return MockUser{}, false
+}
+
+func (m *MockIssuer) showPicker(w http.ResponseWriter, r *http.Request) {
+	type choice struct {
+		User MockUser
+		URL  string
+	}
+	choices := make([]choice, 0, len(m.users))
+	for _, user := range m.users {
+		q := r.URL.Query()
+		q.Set("login_hint", user.Subject)
+		choices = append(choices, choice{User: user, URL: r.URL.Path + "?" + q.Encode()})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = mockPickerPage.Execute(w, choices)
+}
+
+func (m *MockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+	}
+
+	code := r.PostForm.Get("code")
+	m.mu.Lock()
+	grant, found := m.codes[code]
+	// Codes are single use, whether or not the exchange succeeds
+	delete(m.codes, code)
+	m.mu.Unlock()
+
+	switch {
+	case !found || !m.now().Before(grant.expiresAt):
+		tokenError(w, "invalid_grant")
+		return
+	case grant.clientID != clientID || grant.redirectURI != r.PostForm.Get("redirect_uri"):
+		tokenError(w, "invalid_grant")
+		return
+	case s256Challenge(r.PostForm.Get("code_verifier")) != grant.codeChallenge:
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	idToken, err := m.signIDToken(grant)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	accessToken, err := RandomString(24)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.mu.Lock()
+	m.tokens[accessToken] = grant.user
+	m.mu.Unlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(mockTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func (m *MockIssuer) userInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	m.mu.Lock()
+	user, found := m.tokens[accessToken]
+	m.mu.Unlock()
+	if !ok || !found {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "invalid access token", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, userClaims(user))
+}
+
+// signIDToken issues an RS256 ID token for the grant's user.
+func (m *MockIssuer) signIDToken(grant mockGrant) (string, error) {
+	now := m.now()
+	claims := userClaims(grant.user)
+	claims["iss"] = m.issuer
+	claims["aud"] = grant.clientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(mockTokenTTL).Unix()
+	if grant.nonce != "" {
+		claims["nonce"] = grant.nonce
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": oidc.RS256, "typ": "JWT", "kid": m.keyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func userClaims(user MockUser) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":            user.Subject,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	}
+}
+
+func s256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// inProcessTransport serves requests by calling a handler directly.
+type inProcessTransport struct {
+	handler http.Handler
+}
+
+func (t inProcessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
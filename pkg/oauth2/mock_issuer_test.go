@@ -0,0 +1,115 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mockRedirectURL = "http://localhost:8080/auth/callback/dev"
+
+// authorizeCode follows an auth code URL to the issuer and returns the code
+// it redirects back with.
+func authorizeCode(t *testing.T, client *http.Client, authURL, state string) string {
+	t.Helper()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(location.String(), mockRedirectURL))
+	assert.Equal(t, state, location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestMockIssuer_LoginWithDiscovery(t *testing.T) {
+	issuer, server, err := NewMockIssuerServer(NewMockUser("amina.wanjiru@example.com"))
+	require.NoError(t, err)
+	defer server.Close()
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, "savannah", "", mockRedirectURL, issuer.URL())
+	require.NoError(t, err, "discovery works against the mock")
+
+	verifier := GenerateVerifier()
+	code := authorizeCode(t, server.Client(), provider.GetAuthCodeURL("st", "no", verifier), "st")
+
+	token, err := provider.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+	idToken, err := provider.VerifyIDToken(ctx, token, "no")
+	require.NoError(t, err)
+
+	var claims struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	require.NoError(t, idToken.Claims(&claims))
+	assert.Equal(t, "amina.wanjiru@example.com", claims.Subject)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "Amina Wanjiru", claims.Name)
+
+	_, err = provider.Exchange(ctx, code, verifier)
+	assert.Error(t, err, "codes are single use")
+
+	req, err := http.NewRequest(http.MethodGet, issuer.URL()+"/userinfo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMockIssuer_InProcessProvider(t *testing.T) {
+	issuer, err := NewMockIssuer("http://localhost:8080/dev/oidc",
+		NewMockUser("amina@example.com"), NewMockUser("baraka@example.com"))
+	require.NoError(t, err)
+	provider := issuer.Provider(context.Background(), "savannah", mockRedirectURL)
+	client := issuer.Client()
+	ctx := context.Background()
+
+	verifier := GenerateVerifier()
+	authURL := provider.GetAuthCodeURL("st", "no", verifier)
+
+	// With several users and no hint, the issuer asks who to sign in as
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+
+	code := authorizeCode(t, client, authURL+"&login_hint=baraka@example.com", "st")
+	_, err = provider.Exchange(ctx, code, GenerateVerifier())
+	assert.Error(t, err, "the PKCE verifier must match the challenge")
+
+	code = authorizeCode(t, client, authURL+"&login_hint=baraka@example.com", "st")
+	token, err := provider.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	_, err = provider.VerifyIDToken(ctx, token, "other")
+	assert.ErrorIs(t, err, ErrNonceMismatch)
+	idToken, err := provider.VerifyIDToken(ctx, token, "no")
+	require.NoError(t, err)
+	assert.Equal(t, "baraka@example.com", idToken.Subject)
+}
+
+func TestIDTokenClaims(t *testing.T) {
+	token := &IDToken{claims: map[string]interface{}{"sub": "u1", "email_verified": true}}
+	var claims struct {
+		Subject       string `json:"sub"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	require.NoError(t, token.Claims(&claims))
+	assert.Equal(t, "u1", claims.Subject)
+	assert.True(t, claims.EmailVerified)
+}
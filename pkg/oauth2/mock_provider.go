@@ -1,21 +1,28 @@
 package oauth2
 
-import "context"
+import (
+	"context"
 
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// MockProvider is an OAuthProvider whose methods are stubbed with functions.
+// Use a MockIssuer instead when a test needs real, verifiable ID tokens.
 type MockProvider struct {
-	GetAuthCodeURLFunc func(state string) string
-	ExchangeFunc       func(ctx context.Context, code string) (*Token, error)
-	VerifyIDTokenFunc  func(ctx context.Context, token *Token) (*IDToken, error)
+	GetAuthCodeURLFunc func(state, nonce, codeVerifier string) string
+	ExchangeFunc       func(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error)
+	VerifyIDTokenFunc  func(ctx context.Context, token *oauth2.Token, nonce string) (*oidc.IDToken, error)
 }
 
-func (m *MockProvider) GetAuthCodeURL(state string) string {
-	return m.GetAuthCodeURLFunc(state)
+func (m *MockProvider) GetAuthCodeURL(state, nonce, codeVerifier string) string {
+	return m.GetAuthCodeURLFunc(state, nonce, codeVerifier)
 }
 
-func (m *MockProvider) Exchange(ctx context.Context, code string) (*Token, error) {
-	return m.ExchangeFunc(ctx, code)
+func (m *MockProvider) Exchange(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error) {
+	return m.ExchangeFunc(ctx, code, codeVerifier)
 }
 
-func (m *MockProvider) VerifyIDToken(ctx context.Context, token *Token) (*IDToken, error) {
-	return m.VerifyIDTokenFunc(ctx, token)
+func (m *MockProvider) VerifyIDToken(ctx context.Context, token *oauth2.Token, nonce string) (*oidc.IDToken, error) {
+	return m.VerifyIDTokenFunc(ctx, token, nonce)
 }
@@ -2,6 +2,7 @@ package oauth2
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,16 +14,32 @@ import (
 	"golang.org/x/oauth2"
 )
 
+var (
+	ErrMissingIDToken = errors.New("no id_token in token response")
+	ErrNonceMismatch  = errors.New("id_token nonce does not match the login")
+)
+
+// OAuthProvider runs the authorization code flow with PKCE (S256) and a
+// nonce. The code verifier and nonce passed to GetAuthCodeURL must be passed
+// again to Exchange and VerifyIDToken.
 type OAuthProvider interface {
-	GetAuthCodeURL(state string) string
-	Exchange(ctx context.Context, code string) (*oauth2.Token, error)
-	VerifyIDToken(ctx context.Context, token *oauth2.Token) (*oidc.IDToken, error)
+	GetAuthCodeURL(state, nonce, codeVerifier string) string
+	Exchange(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error)
+	VerifyIDToken(ctx context.Context, token *oauth2.Token, nonce string) (*oidc.IDToken, error)
+}
+
+// GenerateVerifier returns a new PKCE code verifier.
+func GenerateVerifier() string {
+	return oauth2.GenerateVerifier()
 }
 
 type OIDCProvider struct {
 	config   *oauth2.Config
 	verifier *oidc.IDTokenVerifier
 	ctx      context.Context
+	// client, when set, is used for the token exchange instead of the
+	// default HTTP client
+	client *http.Client
 }
 
 func NewOIDCProvider(ctx context.Context, clientID, clientSecret, redirectURL, providerURL string) (*OIDCProvider, error) {
@@ -30,7 +47,10 @@ func NewOIDCProvider(ctx context.Context, clientID, clientSecret, redirectURL, p
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OIDC provider: %w", err)
 	}
+	return newOIDCProvider(ctx, provider, clientID, clientSecret, redirectURL), nil
+}
 
+func newOIDCProvider(ctx context.Context, provider *oidc.Provider, clientID, clientSecret, redirectURL string) *OIDCProvider {
 	config := &oauth2.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
@@ -45,24 +65,37 @@ func NewOIDCProvider(ctx context.Context, clientID, clientSecret, redirectURL, p
 		config:   config,
 		verifier: verifier,
 		ctx:      ctx,
-	}, nil
+	}
 }
 
-func (p *OIDCProvider) GetAuthCodeURL(state string) string {
-	return p.config.AuthCodeURL(state)
+func (p *OIDCProvider) GetAuthCodeURL(state, nonce, codeVerifier string) string {
+	return p.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier))
 }
 
-func (p *OIDCProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
-	return p.config.Exchange(ctx, code)
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error) {
+	if p.client != nil {
+		ctx = oidc.ClientContext(ctx, p.client)
+	}
+	return p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
 }
 
-func (p *OIDCProvider) VerifyIDToken(ctx context.Context, token *oauth2.Token) (*oidc.IDToken, error) {
+// VerifyIDToken checks the ID token's signature, issuer, audience and expiry,
+// and that it carries the nonce sent with the login, so a token issued for
+// another login cannot be replayed.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, token *oauth2.Token, nonce string) (*oidc.IDToken, error) {
 	rawIDToken, ok := token.Extra("id_token").(string)
 	if !ok {
-		return nil, errors.New("no id_token in token response")
+		return nil, ErrMissingIDToken
 	}
 
-	return p.verifier.Verify(ctx, rawIDToken)
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrNonceMismatch
+	}
+	return idToken, nil
 }
 
 func (p *OIDCProvider) GetUserInfo(ctx context.Context, token *oauth2.Token) (map[string]interface{}, error) {
@@ -1,6 +1,9 @@
 package oauth2
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 type Provider interface {
 	GetAuthCodeURL(state string) string
@@ -18,7 +21,11 @@ type IDToken struct {
 	claims map[string]interface{}
 }
 
+// Claims unmarshals the token's claims into v, as oidc.IDToken.Claims does.
 func (t *IDToken) Claims(v interface{}) error {
-	// Implement claims unmarshaling
-	return nil
+	raw, err := json.Marshal(t.claims)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
 }
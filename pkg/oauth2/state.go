@@ -0,0 +1,116 @@
+package oauth2
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrStateInvalid = errors.New("login state is invalid")
+	ErrStateExpired = errors.New("login state has expired")
+)
+
+// LoginState is what the login step remembers for the callback: the
+// provider, the state and nonce sent to it and the PKCE code verifier.
+type LoginState struct {
+	Provider     string `json:"p"`
+	State        string `json:"s"`
+	Nonce        string `json:"n"`
+	CodeVerifier string `json:"v"`
+	ExpiresAt    int64  `json:"e"`
+}
+
+// StateCodec seals a LoginState into an opaque cookie value with AES-256-GCM,
+// so the client can neither read nor alter it, and it expires after TTL
+// whatever the cookie's own lifetime.
+type StateCodec struct {
+	aead cipher.AEAD
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewStateCodec derives the key from secret. An empty secret gets a random
+// key, so login states do not survive a restart and are not shared between
+// instances.
+func NewStateCodec(secret string, ttl time.Duration) (*StateCodec, error) {
+	var key [32]byte
+	if secret == "" {
+		if _, err := rand.Read(key[:]); err != nil {
+			return nil, err
+		}
+	} else {
+		key = sha256.Sum256([]byte(secret))
+	}
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &StateCodec{aead: aead, ttl: ttl, now: time.Now}, nil
+}
+
+// TTL is how long a sealed state stays valid.
+func (c *StateCodec) TTL() time.Duration {
+	return c.ttl
+}
+
+// Seal stamps the state with its expiry and encrypts it.
+func (c *StateCodec) Seal(state *LoginState) (string, error) {
+	state.ExpiresAt = c.now().Add(c.ttl).Unix()
+	plain, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plain, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a sealed state. It returns ErrStateInvalid when the value was
+// not sealed with this key or has been tampered with, and ErrStateExpired once
+// the state is past its expiry.
+func (c *StateCodec) Open(value string) (*LoginState, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, ErrStateInvalid
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrStateInvalid
+	}
+
+	var state LoginState
+	if err := json.Unmarshal(plain, &state); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateInvalid, err)
+	}
+	if c.now().Unix() >= state.ExpiresAt {
+		return nil, ErrStateExpired
+	}
+	return &state, nil
+}
+
+// RandomString returns n random bytes, base64url encoded, for use as an
+// OAuth state or nonce.
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,44 @@
+package oauth2
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateCodec(t *testing.T) {
+	codec, err := NewStateCodec("secret", 10*time.Minute)
+	require.NoError(t, err)
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	codec.now = func() time.Time { return now }
+
+	login := &LoginState{Provider: "google", State: "st", Nonce: "no", CodeVerifier: "ve"}
+	sealed, err := codec.Seal(login)
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "google", "the state is encrypted")
+
+	opened, err := codec.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, login, opened)
+
+	// Flipping any character breaks the authentication tag
+	tampered := []byte(sealed)
+	tampered[len(tampered)/2] ^= 1
+	_, err = codec.Open(string(tampered))
+	assert.ErrorIs(t, err, ErrStateInvalid)
+
+	other, err := NewStateCodec("another secret", 10*time.Minute)
+	require.NoError(t, err)
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, ErrStateInvalid, "sealed with another key")
+
+	_, err = codec.Open(strings.Repeat("A", 10))
+	assert.ErrorIs(t, err, ErrStateInvalid)
+
+	now = now.Add(10 * time.Minute)
+	_, err = codec.Open(sealed)
+	assert.ErrorIs(t, err, ErrStateExpired)
+}
@@ -0,0 +1,106 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemoryProvider is an in-process PaymentProvider for tests and local runs.
+// Payments stay pending until Complete or Fail is called; refunds succeed
+// immediately unless RefundStatus says otherwise.
+type MemoryProvider struct {
+	name string
+
+	mu           sync.Mutex
+	seq          int
+	payments     map[string]*Result
+	Refunds      []RefundRequest
+	RefundStatus Status
+	InitiateErr  error
+	RefundErr    error
+}
+
+func NewMemoryProvider(name string) *MemoryProvider {
+	return &MemoryProvider{
+		name:         name,
+		payments:     make(map[string]*Result),
+		RefundStatus: StatusSucceeded,
+	}
+}
+
+func (p *MemoryProvider) Name() string {
+	return p.name
+}
+
+func (p *MemoryProvider) Initiate(ctx context.Context, req InitiateRequest) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.InitiateErr != nil {
+		return nil, p.InitiateErr
+	}
+
+	p.seq++
+	result := &Result{
+		Reference: fmt.Sprintf("%s-pay-%d", p.name, p.seq),
+		Status:    StatusPending,
+		Amount:    req.Amount,
+	}
+	p.payments[result.Reference] = result
+
+	copied := *result
+	return &copied, nil
+}
+
+func (p *MemoryProvider) QueryStatus(ctx context.Context, reference string) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result, ok := p.payments[reference]
+	if !ok {
+		return nil, ErrUnknownReference
+	}
+	copied := *result
+	return &copied, nil
+}
+
+func (p *MemoryProvider) Refund(ctx context.Context, req RefundRequest) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.payments[req.PaymentReference]; !ok {
+		return nil, ErrUnknownReference
+	}
+	if p.RefundErr != nil {
+		return nil, p.RefundErr
+	}
+
+	p.seq++
+	p.Refunds = append(p.Refunds, req)
+	return &Result{
+		Reference: fmt.Sprintf("%s-refund-%d", p.name, p.seq),
+		Status:    p.RefundStatus,
+		Amount:    req.Amount,
+	}, nil
+}
+
+// Complete marks a payment as paid, as a provider callback would.
+func (p *MemoryProvider) Complete(reference, transactionID string) {
+	p.settle(reference, StatusSucceeded, transactionID)
+}
+
+// Fail marks a payment as declined.
+func (p *MemoryProvider) Fail(reference string) {
+	p.settle(reference, StatusFailed, "")
+}
+
+func (p *MemoryProvider) settle(reference string, status Status, transactionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.payments[reference]; ok {
+		result.Status = status
+		result.ProviderTransactionID = transactionID
+	}
+}
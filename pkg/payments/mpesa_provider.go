@@ -0,0 +1,144 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Mutonya/Savanah/pkg/mpesa"
+)
+
+// ProviderMpesa is the name the M-Pesa provider is registered under.
+const ProviderMpesa = "mpesa"
+
+// Daraja answers an STK query with this error code while the customer has not
+// yet responded to the prompt.
+const mpesaStillProcessing = "500.001.1001"
+
+// MpesaProvider adapts the Daraja client to PaymentProvider. Payments are STK
+// pushes; refunds are transaction reversals.
+type MpesaProvider struct {
+	client *mpesa.Client
+}
+
+func NewMpesaProvider(client *mpesa.Client) *MpesaProvider {
+	return &MpesaProvider{client: client}
+}
+
+func (p *MpesaProvider) Name() string {
+	return ProviderMpesa
+}
+
+func (p *MpesaProvider) Initiate(ctx context.Context, req InitiateRequest) (*Result, error) {
+	// The customer is charged the total rounded up to a whole shilling;
+	// the payment records that amount, so refunding it all is whole too
+	amount := shillings(req.Amount)
+
+	resp, err := p.client.STKPush(ctx, mpesa.STKPushRequest{
+		Phone:            req.Phone,
+		Amount:           amount,
+		AccountReference: req.Reference,
+		Description:      req.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Reference:   resp.CheckoutRequestID,
+		Status:      StatusPending,
+		Amount:      float64(amount),
+		Code:        resp.ResponseCode,
+		Description: resp.CustomerMessage,
+	}, nil
+}
+
+func (p *MpesaProvider) QueryStatus(ctx context.Context, reference string) (*Result, error) {
+	resp, err := p.client.STKQuery(ctx, reference)
+	var apiErr *mpesa.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode == mpesaStillProcessing {
+		return &Result{Reference: reference, Status: StatusPending, Description: apiErr.ErrorMessage}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Reference:   reference,
+		Status:      StatusFailed,
+		Code:        resp.ResultCode,
+		Description: resp.ResultDesc,
+	}
+	if resp.ResultCode == strconv.Itoa(mpesa.ResultCodeSuccess) {
+		result.Status = StatusSucceeded
+	}
+	return result, nil
+}
+
+func (p *MpesaProvider) Refund(ctx context.Context, req RefundRequest) (*Result, error) {
+	if req.ProviderTransactionID == "" {
+		return nil, errors.New("payment has no M-Pesa receipt number to reverse")
+	}
+
+	// Rounding a refund either way would pay back more or less than asked
+	amount := shillings(req.Amount)
+	if float64(amount) != math.Round(req.Amount*100)/100 {
+		return nil, fmt.Errorf("%w: M-Pesa refunds are in whole shillings, not %.2f", ErrInvalidAmount, req.Amount)
+	}
+
+	resp, err := p.client.Reverse(ctx, mpesa.ReversalRequest{
+		TransactionID: req.ProviderTransactionID,
+		Amount:        amount,
+		Remarks:       req.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Reference:   resp.ConversationID,
+		Status:      StatusPending,
+		Amount:      req.Amount,
+		Code:        resp.ResponseCode,
+		Description: resp.ResponseDescription,
+	}, nil
+}
+
+// MpesaCallbackResult converts an STK callback into a provider-neutral result.
+func MpesaCallbackResult(callback *mpesa.STKCallback) *Result {
+	result := &Result{
+		Reference:   callback.CheckoutRequestID,
+		Status:      StatusFailed,
+		Code:        strconv.Itoa(callback.ResultCode),
+		Description: callback.ResultDesc,
+	}
+	if callback.Succeeded() {
+		result.Status = StatusSucceeded
+		result.ProviderTransactionID = callback.ReceiptNumber()
+		result.Amount = callback.Amount()
+	}
+	return result
+}
+
+// MpesaReversalResult converts a reversal result callback into a refund result.
+func MpesaReversalResult(callback *mpesa.ResultCallback) *Result {
+	result := &Result{
+		Reference:             callback.Result.ConversationID,
+		Status:                StatusFailed,
+		ProviderTransactionID: callback.Result.TransactionID,
+		Code:                  strconv.Itoa(callback.Result.ResultCode),
+		Description:           callback.Result.ResultDesc,
+	}
+	if callback.Result.ResultCode == mpesa.ResultCodeSuccess {
+		result.Status = StatusSucceeded
+	}
+	return result
+}
+
+// shillings rounds an amount up to a whole shilling, the only unit M-Pesa
+// moves. It rounds to cents first, so 150.00000001 is still 150.
+func shillings(amount float64) int {
+	return int(math.Ceil(math.Round(amount*100) / 100))
+}
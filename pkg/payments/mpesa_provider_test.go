@@ -0,0 +1,22 @@
+package payments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShillingsRoundsUpWholeCents(t *testing.T) {
+	assert.Equal(t, 150, shillings(150))
+	assert.Equal(t, 150, shillings(150.000000001))
+	assert.Equal(t, 151, shillings(150.01))
+}
+
+func TestMpesaProvider_RefundRejectsPartShillings(t *testing.T) {
+	// The amount is checked before Daraja is called, so there is no client
+	p := NewMpesaProvider(nil)
+
+	_, err := p.Refund(context.Background(), RefundRequest{ProviderTransactionID: "QWE123", Amount: 99.5})
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
@@ -0,0 +1,58 @@
+package payments
+
+import (
+	"context"
+	"errors"
+)
+
+// Status is the provider-neutral state of a payment or refund.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+var (
+	ErrUnknownReference = errors.New("unknown payment reference")
+	// ErrInvalidAmount is returned for an amount the provider cannot move,
+	// e.g. part of a shilling through M-Pesa.
+	ErrInvalidAmount = errors.New("amount cannot be paid through this provider")
+)
+
+// PaymentProvider is implemented by every payment method the shop accepts.
+// Initiate and Refund may complete asynchronously: a pending result is later
+// settled by a provider callback or by QueryStatus during reconciliation.
+type PaymentProvider interface {
+	Name() string
+	Initiate(ctx context.Context, req InitiateRequest) (*Result, error)
+	QueryStatus(ctx context.Context, reference string) (*Result, error)
+	Refund(ctx context.Context, req RefundRequest) (*Result, error)
+}
+
+type InitiateRequest struct {
+	OrderID     uint
+	Amount      float64
+	Phone       string // payer MSISDN for mobile money
+	Reference   string // shown to the payer, e.g. on the M-Pesa prompt
+	Description string
+}
+
+type RefundRequest struct {
+	// PaymentReference and ProviderTransactionID identify the original payment
+	PaymentReference      string
+	ProviderTransactionID string
+	Amount                float64
+	Reason                string
+}
+
+// Result is what a provider reports about a payment or refund.
+type Result struct {
+	Reference             string // provider handle used for callbacks and queries
+	Status                Status
+	ProviderTransactionID string // e.g. the M-Pesa receipt number
+	Amount                float64
+	Code                  string
+	Description           string
+}